@@ -0,0 +1,34 @@
+package grpcserver
+
+import (
+	billingv1 "billing_enginee/api/proto/billing/v1"
+	"billing_enginee/internal/usecase"
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type CustomerService struct {
+	billingv1.UnimplementedCustomerServiceServer
+	customerUsecase usecase.CustomerUsecase
+}
+
+func NewCustomerService(customerUsecase usecase.CustomerUsecase) *CustomerService {
+	return &CustomerService{
+		customerUsecase: customerUsecase,
+	}
+}
+
+func (s *CustomerService) IsDelinquent(ctx context.Context, req *billingv1.IsDelinquentRequest) (*billingv1.IsDelinquentResponse, error) {
+	if req.GetCustomerId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "customer_id must be a positive integer")
+	}
+
+	isDelinquent, err := s.customerUsecase.IsDelinquent(ctx, uint(req.GetCustomerId()))
+	if err != nil {
+		return nil, statusFromError(ctx, err, "Failed to check delinquency status")
+	}
+
+	return &billingv1.IsDelinquentResponse{IsDelinquent: isDelinquent}, nil
+}
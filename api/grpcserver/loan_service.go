@@ -0,0 +1,107 @@
+package grpcserver
+
+import (
+	loan_dto_handler "billing_enginee/api/handler/dto/loan"
+	billingv1 "billing_enginee/api/proto/billing/v1"
+	"billing_enginee/internal/usecase"
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type LoanService struct {
+	billingv1.UnimplementedLoanServiceServer
+	loanUsecase usecase.LoanUsecase
+}
+
+func NewLoanService(loanUsecase usecase.LoanUsecase) *LoanService {
+	return &LoanService{
+		loanUsecase: loanUsecase,
+	}
+}
+
+func (s *LoanService) CreateLoan(ctx context.Context, req *billingv1.CreateLoanRequest) (*billingv1.CreateLoanResponse, error) {
+	// Validate with the same rules as the HTTP API
+	request := loan_dto_handler.CreateLoanRequest{
+		CustomerID: uint(req.GetCustomerId()),
+		Name:       req.GetName(),
+		Email:      req.GetEmail(),
+		Phone:      req.GetPhone(),
+		Amount:     req.GetAmount(),
+		TermWeeks:  int(req.GetTermWeeks()),
+		Rates:      req.GetRates(),
+	}
+	if err := binding.Validator.ValidateStruct(&request); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return nil, status.Error(codes.InvalidArgument, joinMessages(request.CustomValidationMessages(validationErrors)))
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	response, err := s.loanUsecase.CreateLoan(ctx, request.CustomerID, request.Name, request.Email, request.Phone, request.Amount, request.TermWeeks, request.Rates)
+	if err != nil {
+		return nil, statusFromError(ctx, err, "Failed to create loan")
+	}
+
+	return &billingv1.CreateLoanResponse{
+		LoanId:            uint64(response.LoanID),
+		TotalAmount:       response.TotalAmount,
+		OutstandingAmount: response.OutstandingAmount,
+		Week:              int32(response.Week),
+		DueDate:           response.DueDate.Format("2006-01-02"),
+	}, nil
+}
+
+func (s *LoanService) GetOutstanding(ctx context.Context, req *billingv1.GetOutstandingRequest) (*billingv1.GetOutstandingResponse, error) {
+	if req.GetLoanId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "loan_id must be a positive integer")
+	}
+
+	response, err := s.loanUsecase.GetOutstanding(ctx, uint(req.GetLoanId()))
+	if err != nil {
+		return nil, statusFromError(ctx, err, "Failed to get outstanding amount")
+	}
+
+	return &billingv1.GetOutstandingResponse{
+		LoanId:            uint64(response.LoanID),
+		TotalAmount:       response.TotalAmount,
+		OutstandingAmount: response.OutstandingAmount,
+		Week:              int32(response.WeeksOutstanding),
+		DueDate:           response.DueDate.Format("2006-01-02"),
+	}, nil
+}
+
+func (s *LoanService) MakePayment(ctx context.Context, req *billingv1.MakePaymentRequest) (*billingv1.MakePaymentResponse, error) {
+	if req.GetLoanId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "loan_id must be a positive integer")
+	}
+	if req.GetAmount() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be greater than zero")
+	}
+
+	if err := s.loanUsecase.MakePayment(ctx, uint(req.GetLoanId()), req.GetAmount()); err != nil {
+		return nil, statusFromError(ctx, err, "Failed to make payment")
+	}
+
+	return &billingv1.MakePaymentResponse{Message: "Payment successful"}, nil
+}
+
+// joinMessages renders validation messages in a stable order
+func joinMessages(messages map[string]string) string {
+	fields := make([]string, 0, len(messages))
+	for field := range messages {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field + ": " + messages[field]
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,216 @@
+// Package grpcserver exposes the loan and customer use cases over gRPC, next to the Gin HTTP API.
+// Every call goes through the same steps as an HTTP request: request ID, authentication, permission and transaction.
+package grpcserver
+
+import (
+	billingv1 "billing_enginee/api/proto/billing/v1"
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// APIKeyMetadata carries an API key, like the X-API-Key header of the HTTP API
+	APIKeyMetadata = "x-api-key"
+	// AuthorizationMetadata carries a staff JWT as "Bearer <token>"
+	AuthorizationMetadata = "authorization"
+	// RequestIDMetadata lets callers correlate their call with the audit entries it wrote
+	RequestIDMetadata = "x-request-id"
+)
+
+// methodPermissions lists the permission required by each method, methods missing here are denied
+var methodPermissions = map[string]auth.Permission{
+	billingv1.LoanService_CreateLoan_FullMethodName:       auth.PermissionCreateLoans,
+	billingv1.LoanService_GetOutstanding_FullMethodName:   auth.PermissionReadLoans,
+	billingv1.LoanService_MakePayment_FullMethodName:      auth.PermissionRecordPayments,
+	billingv1.CustomerService_IsDelinquent_FullMethodName: auth.PermissionReadCustomers,
+}
+
+// NewServer creates a gRPC server with the loan and customer services registered
+func NewServer(uow pkg.UnitOfWork, authenticator auth.Authenticator, tenants tenant.Registry, loanUsecase usecase.LoanUsecase, customerUsecase usecase.CustomerUsecase) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		requestContextInterceptor(),
+		authInterceptor(authenticator, tenants),
+		transactionInterceptor(uow),
+	))
+	billingv1.RegisterLoanServiceServer(server, NewLoanService(loanUsecase))
+	billingv1.RegisterCustomerServiceServer(server, NewCustomerService(customerUsecase))
+	return server
+}
+
+// requestContextInterceptor stores the request ID and the anonymous actor like RequestIDMiddleware and
+// AuditContextMiddleware, traces the call like TracingMiddleware, echoes the request ID in the response header
+// and logs the call once it is answered
+func requestContextInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		requestID := firstMetadata(ctx, RequestIDMetadata)
+		if requestID == "" || len(requestID) > 64 {
+			requestID = pkg.GenerateTraceID()
+		}
+		if err := grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadata, requestID)); err != nil {
+			log.WithError(err).Warn("Failed to set request ID header")
+		}
+
+		ctx = pkg.WithRequestID(ctx, requestID)
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(incomingMetadata(ctx)))
+		ctx, span := pkg.StartServerSpan(ctx, info.FullMethod,
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", info.FullMethod),
+			attribute.String("request.id", requestID),
+		)
+		resp, err := handler(pkg.WithActor(ctx, pkg.AnonymousActor), req)
+		span.SetAttributes(attribute.String("rpc.grpc.status_code", status.Code(err).String()))
+		pkg.EndSpan(span, err)
+
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"method":    info.FullMethod,
+			"code":      status.Code(err).String(),
+			"latencyMs": time.Since(start).Milliseconds(),
+		}).Info("Call completed")
+		return resp, err
+	}
+}
+
+// authInterceptor authenticates the credentials of the call and checks the permission of the method.
+// Unlike HTTP there are no public methods, so calls without credentials are rejected.
+func authInterceptor(authenticator auth.Authenticator, tenants tenant.Registry) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		header := http.Header{}
+		if key := firstMetadata(ctx, APIKeyMetadata); key != "" {
+			header.Set(auth.APIKeyHeader, key)
+		}
+		if authorization := firstMetadata(ctx, AuthorizationMetadata); authorization != "" {
+			header.Set("Authorization", authorization)
+		}
+
+		principal, err := authenticator.Authenticate(header)
+		if err != nil {
+			if errors.Is(err, auth.ErrNoCredentials) {
+				return nil, status.Error(codes.Unauthenticated, "Authentication required")
+			}
+			return nil, status.Error(codes.Unauthenticated, "Invalid credentials")
+		}
+		if _, err := tenants.Get(principal.TenantID); err != nil {
+			pkg.Logger(ctx).WithFields(log.Fields{
+				"actor":    principal.Actor(),
+				"tenantID": principal.TenantID,
+			}).Warn("Rejected credential of an unknown tenant")
+			return nil, status.Error(codes.Unauthenticated, "Invalid credentials")
+		}
+
+		permission, ok := methodPermissions[info.FullMethod]
+		if !ok || !principal.Can(permission) {
+			pkg.Logger(ctx).WithFields(log.Fields{
+				"actor":      principal.Actor(),
+				"role":       principal.Role.String(),
+				"method":     info.FullMethod,
+				"permission": permission,
+			}).Warn("Permission denied")
+			return nil, status.Error(codes.PermissionDenied, "Role "+principal.Role.String()+" is not allowed to call "+info.FullMethod)
+		}
+
+		ctx = pkg.WithActor(ctx, principal.Actor())
+		return handler(pkg.WithTenantID(ctx, principal.TenantID), req)
+	}
+}
+
+// transactionInterceptor runs the call in a unit of work, committed when the call succeeds and rolled back otherwise
+func transactionInterceptor(uow pkg.UnitOfWork) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		// The unit of work rolls back before passing the panic on
+		defer func() {
+			if r := recover(); r != nil {
+				pkg.Logger(ctx).WithField("panic", r).Error("Panic occurred, transaction rolled back")
+				resp, err = nil, status.Error(codes.Internal, "Unexpected server error")
+			}
+		}()
+
+		err = uow.Do(ctx, func(ctx context.Context) error {
+			var handlerErr error
+			resp, handlerErr = handler(ctx, req)
+			return handlerErr
+		})
+		if err != nil {
+			// Errors of the handler already are statuses, anything else comes from the transaction itself
+			if _, ok := status.FromError(err); !ok {
+				pkg.Logger(ctx).WithError(err).Error("Failed to complete transaction")
+				return nil, status.Error(codes.Internal, "Failed to complete transaction")
+			}
+			return nil, err
+		}
+		return resp, nil
+	}
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	if values := incomingMetadata(ctx).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func incomingMetadata(ctx context.Context) metadata.MD {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return metadata.MD{}
+	}
+	return md
+}
+
+// metadataCarrier reads the traceparent of the caller from the metadata of the call
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// statusFromError maps an error of a use case to a gRPC status by its kind, unknown errors are not leaked to the caller
+func statusFromError(ctx context.Context, err error, message string) error {
+	var domainErr *entity.DomainError
+	if !errors.As(err, &domainErr) {
+		pkg.Logger(ctx).WithError(err).Error(message)
+		return status.Error(codes.Internal, message)
+	}
+
+	switch domainErr.Kind() {
+	case enum.ErrorKindValidation:
+		return status.Error(codes.InvalidArgument, domainErr.Message())
+	case enum.ErrorKindNotFound:
+		return status.Error(codes.NotFound, domainErr.Message())
+	case enum.ErrorKindConflict:
+		return status.Error(codes.Aborted, domainErr.Message())
+	default:
+		return status.Error(codes.FailedPrecondition, domainErr.Message())
+	}
+}
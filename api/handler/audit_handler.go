@@ -0,0 +1,64 @@
+package handler
+
+import (
+	audit_dto_handler "billing_enginee/api/handler/dto/audit"
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type AuditHandler struct {
+	auditUsecase usecase.AuditUsecase
+}
+
+func NewAuditHandler(auditUsecase usecase.AuditUsecase) *AuditHandler {
+	return &AuditHandler{
+		auditUsecase: auditUsecase,
+	}
+}
+
+func (h *AuditHandler) GetEntries(c *gin.Context) {
+	idParam := c.Query("id")
+	entityID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil || entityID == 0 {
+		pkg.Logger(c.Request.Context()).WithFields(log.Fields{
+			"idParam": idParam,
+			"error":   err,
+		}).Error("Invalid audit entity ID format")
+		c.Error(entity.NewValidationError("id must be a valid positive integer"))
+		return
+	}
+
+	entries, err := h.auditUsecase.GetEntries(c.Request.Context(), c.Query("entity"), uint(entityID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := make([]audit_dto_handler.AuditEntryResponse, len(entries))
+	for i, entry := range entries {
+		response[i] = auditEntryResponse(entry)
+	}
+	c.JSON(http.StatusOK, audit_dto_handler.AuditListResponse{Entries: response})
+}
+
+func auditEntryResponse(entry *entity.AuditEntry) audit_dto_handler.AuditEntryResponse {
+	return audit_dto_handler.AuditEntryResponse{
+		ID:        strconv.FormatUint(uint64(entry.GetID()), 10),
+		Entity:    entry.EntityType(),
+		EntityID:  strconv.FormatUint(uint64(entry.EntityID()), 10),
+		Action:    entry.Action(),
+		Actor:     entry.Actor(),
+		RequestID: entry.RequestID(),
+		Before:    entry.Before(),
+		After:     entry.After(),
+		Reason:    entry.Reason(),
+		CreatedAt: entry.CreatedAt().Format(time.RFC3339),
+	}
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	customer_dto_handler "billing_enginee/api/handler/dto/customer"
+	"billing_enginee/internal/entity"
 	"billing_enginee/internal/usecase"
 	"billing_enginee/pkg"
 	"net/http"
@@ -24,27 +26,23 @@ func (h *CustomerHandler) IsDelinquent(c *gin.Context) {
 	customerIDParam := c.Param("customer_id")
 	customerID, err := strconv.ParseUint(customerIDParam, 10, 32)
 	if err != nil || customerID == 0 {
-		log.WithFields(log.Fields{
+		pkg.Logger(c.Request.Context()).WithFields(log.Fields{
 			"customerIDParam": customerIDParam,
 			"error":           err,
 		}).Error("Invalid customer ID format")
-		c.JSON(http.StatusBadRequest, pkg.ErrorResponse{
-			Code:    "INVALID_INPUT",
-			Message: "Customer ID must be a valid positive integer",
-			TraceID: pkg.GenerateTraceID(),
-		})
+		c.Error(entity.NewValidationError("Customer ID must be a valid positive integer"))
 		return
 	}
 
-	isDelinquent, err := h.customerUsecase.IsDelinquent(c, uint(customerID))
+	isDelinquent, err := h.customerUsecase.IsDelinquent(c.Request.Context(), uint(customerID))
 	if err != nil {
-		log.WithFields(log.Fields{
+		pkg.Logger(c.Request.Context()).WithFields(log.Fields{
 			"customerID": customerID,
 			"error":      err,
 		}).Error("Failed to check if customer is delinquent")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check delinquency status"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"is_delinquent": isDelinquent})
+	c.JSON(http.StatusOK, customer_dto_handler.DelinquencyResponse{IsDelinquent: isDelinquent})
 }
@@ -0,0 +1,20 @@
+package audit_dto_handler
+
+// AuditEntryResponse represents a recorded change of an entity
+type AuditEntryResponse struct {
+	ID        string                 `json:"id"`
+	Entity    string                 `json:"entity"`
+	EntityID  string                 `json:"entity_id"`
+	Action    string                 `json:"action"`
+	Actor     string                 `json:"actor"`
+	RequestID string                 `json:"request_id"`
+	Before    map[string]interface{} `json:"before"`
+	After     map[string]interface{} `json:"after"`
+	Reason    string                 `json:"reason"`
+	CreatedAt string                 `json:"created_at"`
+}
+
+// AuditListResponse lists the recorded changes of an entity, oldest first
+type AuditListResponse struct {
+	Entries []AuditEntryResponse `json:"entries"`
+}
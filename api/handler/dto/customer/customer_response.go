@@ -0,0 +1,6 @@
+package customer_dto_handler
+
+// DelinquencyResponse tells whether a customer is delinquent
+type DelinquencyResponse struct {
+	IsDelinquent bool `json:"is_delinquent"`
+}
@@ -0,0 +1,21 @@
+package health_dto_handler
+
+// StatusResponse represents the liveness of the service
+type StatusResponse struct {
+	Status string `json:"status"`
+}
+
+// ReadinessResponse represents the outcome of the readiness checks, "ok" or the reason a check failed
+type ReadinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// VersionResponse represents the build of the service and the schema it runs on
+type VersionResponse struct {
+	GitSHA                string `json:"git_sha"`
+	BuildTime             string `json:"build_time"`
+	GoVersion             string `json:"go_version"`
+	SchemaVersion         string `json:"schema_version"`
+	ExpectedSchemaVersion string `json:"expected_schema_version"`
+}
@@ -0,0 +1,24 @@
+package job_dto_handler
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// RunJobRequest represents the payload for re-running a daily job as of a business date
+type RunJobRequest struct {
+	AsOf string `json:"as_of" binding:"required,datetime=2006-01-02"`
+}
+
+// Custom error messages for validation
+func (r *RunJobRequest) CustomValidationMessages(err error) map[string]string {
+	validationErrors := err.(validator.ValidationErrors)
+	errorMessages := make(map[string]string)
+
+	for _, fieldError := range validationErrors {
+		switch fieldError.Field() {
+		case "AsOf":
+			errorMessages["as_of"] = "as_of is required and should be a date in YYYY-MM-DD format."
+		}
+	}
+	return errorMessages
+}
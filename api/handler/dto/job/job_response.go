@@ -0,0 +1,20 @@
+package job_dto_handler
+
+// JobRunResponse represents one run of a daily job
+type JobRunResponse struct {
+	ID             string `json:"id"`
+	Job            string `json:"job"`
+	BusinessDate   string `json:"business_date"`
+	Trigger        string `json:"trigger"`
+	Status         string `json:"status"`
+	ItemsProcessed int    `json:"items_processed"`
+	ItemsFailed    int    `json:"items_failed"`
+	Error          string `json:"error,omitempty"`
+	StartedAt      string `json:"started_at"`
+	FinishedAt     string `json:"finished_at,omitempty"`
+}
+
+// JobRunListResponse lists the latest runs of a daily job, newest first
+type JobRunListResponse struct {
+	Runs []JobRunResponse `json:"runs"`
+}
@@ -9,6 +9,7 @@ type CreateLoanRequest struct {
 	CustomerID uint    `json:"customer_id" binding:"required"`
 	Name       string  `json:"name" binding:"required,alpha_space"`
 	Email      string  `json:"email" binding:"required,email"`
+	Phone      string  `json:"phone" binding:"omitempty,e164"`
 	Amount     float64 `json:"amount" binding:"required,money"`
 	TermWeeks  int     `json:"term_weeks" binding:"required,min=1"`
 	Rates      float64 `json:"rates" binding:"required,percentage"`
@@ -27,6 +28,8 @@ func (r *CreateLoanRequest) CustomValidationMessages(err error) map[string]strin
 			errorMessages["name"] = "name is required and should contain only alphabets and spaces."
 		case "Email":
 			errorMessages["email"] = "email is required and should be in a valid email format."
+		case "Phone":
+			errorMessages["phone"] = "phone should be in E.164 format, e.g. +6281234567890."
 		case "Amount":
 			errorMessages["amount"] = "amount is required and should be in a valid money format."
 		case "TermWeeks":
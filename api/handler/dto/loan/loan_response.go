@@ -0,0 +1,24 @@
+package loan_dto_handler
+
+// LoanResponse represents a created loan and its first installment
+type LoanResponse struct {
+	LoanID            string  `json:"loan_id"`
+	TotalAmount       float64 `json:"total_amount"`
+	OutstandingAmount float64 `json:"outstanding_amount"`
+	Week              int     `json:"week"`
+	DueDate           string  `json:"due_date"`
+}
+
+// OutstandingResponse represents the outstanding amount of a loan and its next installment
+type OutstandingResponse struct {
+	LoanID            string  `json:"loan_id"`
+	TotalAmount       float64 `json:"total_amount"`
+	OutstandingAmount float64 `json:"outstanding_amount"`
+	DueDate           string  `json:"due_date"`
+	Week              int     `json:"week"`
+}
+
+// PaymentResponse confirms a recorded payment
+type PaymentResponse struct {
+	Message string `json:"message"`
+}
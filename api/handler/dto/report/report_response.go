@@ -0,0 +1,44 @@
+package report_dto_handler
+
+// PortfolioResponse represents the totals of the loan book
+type PortfolioResponse struct {
+	LoanCount            int64            `json:"loan_count"`
+	TotalDisbursed       float64          `json:"total_disbursed"`
+	TotalRepayable       float64          `json:"total_repayable"`
+	OutstandingAmount    float64          `json:"outstanding_amount"`
+	OutstandingPrincipal float64          `json:"outstanding_principal"`
+	OutstandingInterest  float64          `json:"outstanding_interest"`
+	LoansByStatus        map[string]int64 `json:"loans_by_status"`
+}
+
+// PortfolioAtRiskResponse represents the principal at risk at a date
+type PortfolioAtRiskResponse struct {
+	AsOf                 string    `json:"as_of"`
+	OutstandingPrincipal float64   `json:"outstanding_principal"`
+	Par30                ParBucket `json:"par30"`
+	Par90                ParBucket `json:"par90"`
+}
+
+// ParBucket represents the loans with an installment overdue for more than a number of days
+type ParBucket struct {
+	Principal float64 `json:"principal"`
+	Loans     int64   `json:"loans"`
+	Ratio     float64 `json:"ratio"`
+}
+
+// CollectionsResponse represents the payments collected in a date range
+type CollectionsResponse struct {
+	Period         string             `json:"period"`
+	From           string             `json:"from"`
+	To             string             `json:"to"`
+	TotalCollected float64            `json:"total_collected"`
+	TotalCount     int64              `json:"total_count"`
+	Periods        []CollectionPeriod `json:"periods"`
+}
+
+// CollectionPeriod represents the payments collected in a day, week or month
+type CollectionPeriod struct {
+	PeriodStart       string  `json:"period_start"`
+	InstallmentsCount int64   `json:"installments_count"`
+	AmountCollected   float64 `json:"amount_collected"`
+}
@@ -0,0 +1,24 @@
+package simulation_dto_handler
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// AdvanceClockRequest represents the payload for moving the business date of the simulation forward
+type AdvanceClockRequest struct {
+	Days int `json:"days" binding:"required,min=1,max=366"`
+}
+
+// Custom error messages for validation
+func (r *AdvanceClockRequest) CustomValidationMessages(err error) map[string]string {
+	validationErrors := err.(validator.ValidationErrors)
+	errorMessages := make(map[string]string)
+
+	for _, fieldError := range validationErrors {
+		switch fieldError.Field() {
+		case "Days":
+			errorMessages["days"] = "days is required and should be between 1 and 366."
+		}
+	}
+	return errorMessages
+}
@@ -0,0 +1,9 @@
+package simulation_dto_handler
+
+// ClockResponse represents the clock of the simulation mode
+type ClockResponse struct {
+	Now       string `json:"now"`
+	DaysAhead int    `json:"days_ahead"`
+	// BusinessDates is the current date of every tenant, by tenant ID
+	BusinessDates map[string]string `json:"business_dates"`
+}
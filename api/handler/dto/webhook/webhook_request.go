@@ -0,0 +1,30 @@
+package webhook_dto_handler
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// CreateWebhookRequest represents the payload for subscribing to webhook events
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url"`
+	Secret     string   `json:"secret" binding:"required,min=16"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,oneof=loan.created payment.received installment.overdue loan.closed"`
+}
+
+// Custom error messages for validation
+func (r *CreateWebhookRequest) CustomValidationMessages(err error) map[string]string {
+	validationErrors := err.(validator.ValidationErrors)
+	errorMessages := make(map[string]string)
+
+	for _, fieldError := range validationErrors {
+		switch fieldError.Field() {
+		case "URL":
+			errorMessages["url"] = "url is required and should be a valid URL."
+		case "Secret":
+			errorMessages["secret"] = "secret is required and should be at least 16 characters long."
+		default:
+			errorMessages["event_types"] = "event types are required and should be any of loan.created, payment.received, installment.overdue, loan.closed."
+		}
+	}
+	return errorMessages
+}
@@ -0,0 +1,34 @@
+package webhook_dto_handler
+
+// SubscriptionResponse represents a webhook subscription, the secret is never returned
+type SubscriptionResponse struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// SubscriptionListResponse lists the webhook subscriptions
+type SubscriptionListResponse struct {
+	Webhooks []SubscriptionResponse `json:"webhooks"`
+}
+
+// DeliveryResponse represents a delivery of an event to a subscription
+type DeliveryResponse struct {
+	ID             string  `json:"id"`
+	EventID        string  `json:"event_id"`
+	EventType      string  `json:"event_type"`
+	Status         string  `json:"status"`
+	Attempts       int     `json:"attempts"`
+	NextAttemptAt  string  `json:"next_attempt_at"`
+	ResponseStatus *int    `json:"response_status"`
+	LastError      *string `json:"last_error"`
+	DeliveredAt    string  `json:"delivered_at,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+}
+
+// DeliveryListResponse lists the deliveries of a subscription
+type DeliveryListResponse struct {
+	Deliveries []DeliveryResponse `json:"deliveries"`
+}
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"billing_enginee/internal/entity"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// bindingError turns an error binding a request body into a validation error,
+// with a message per invalid field when the body failed the validation of its DTO
+func bindingError(err error, fieldMessages func(error) map[string]string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return entity.NewFieldValidationError("Request body is invalid", fieldMessages(validationErrors))
+	}
+	return entity.NewValidationError(err.Error())
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	health_dto_handler "billing_enginee/api/handler/dto/health"
+	"billing_enginee/internal/health"
+	"billing_enginee/migrations"
+	"billing_enginee/pkg"
+	"database/sql"
+	"net/http"
+	"runtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+	sqlDB   *sql.DB
+}
+
+func NewHealthHandler(checker *health.Checker, sqlDB *sql.DB) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+		sqlDB:   sqlDB,
+	}
+}
+
+// Liveness answers as long as the process serves requests, it does not look at the dependencies
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, health_dto_handler.StatusResponse{Status: "ok"})
+}
+
+// Readiness answers 503 when a readiness check fails or the service is shutting down
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.checker.Check(c.Request.Context())
+
+	response := health_dto_handler.ReadinessResponse{Status: "ok", Checks: make(map[string]string, len(report.Checks))}
+	for name, err := range report.Checks {
+		response.Checks[name] = "ok"
+		if err != nil {
+			response.Checks[name] = err.Error()
+		}
+	}
+
+	if !report.Ready {
+		pkg.Logger(c.Request.Context()).WithField("checks", response.Checks).Warn("Service is not ready")
+		response.Status = "unavailable"
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Version answers the build of the service and the schema version of the database, empty when it cannot be read
+func (h *HealthHandler) Version(c *gin.Context) {
+	gitSHA, buildTime := pkg.BuildInfo()
+
+	schemaVersion, err := health.SchemaVersion(c.Request.Context(), h.sqlDB)
+	if err != nil {
+		pkg.Logger(c.Request.Context()).WithError(err).Warn("Failed to read schema version")
+	}
+
+	c.JSON(http.StatusOK, health_dto_handler.VersionResponse{
+		GitSHA:                gitSHA,
+		BuildTime:             buildTime,
+		GoVersion:             runtime.Version(),
+		SchemaVersion:         schemaVersion,
+		ExpectedSchemaVersion: migrations.LatestVersion(),
+	})
+}
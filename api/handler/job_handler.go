@@ -0,0 +1,101 @@
+package handler
+
+import (
+	job_dto_handler "billing_enginee/api/handler/dto/job"
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultJobRunLimit is how many runs are listed when the limit is not given
+const defaultJobRunLimit = 20
+
+type JobHandler struct {
+	jobRunUsecase usecase.JobRunUsecase
+}
+
+func NewJobHandler(jobRunUsecase usecase.JobRunUsecase) *JobHandler {
+	return &JobHandler{
+		jobRunUsecase: jobRunUsecase,
+	}
+}
+
+// RunUpdatePaymentStatus re-runs the payment status update of the tenant as of the requested business date.
+// The request runs outside a transaction, the run is recorded whether it fails or not and a failed run is
+// returned with an error status.
+func (h *JobHandler) RunUpdatePaymentStatus(c *gin.Context) {
+	var request job_dto_handler.RunJobRequest
+
+	// Bind and validate JSON request
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(bindingError(err, request.CustomValidationMessages))
+		return
+	}
+
+	// The binding already checked the format
+	asOf, _ := time.Parse(reportDateLayout, request.AsOf)
+	run, err := h.jobRunUsecase.RerunUpdatePaymentStatus(c.Request.Context(), asOf)
+	if err != nil && run == nil {
+		c.Error(err)
+		return
+	}
+	if err != nil {
+		// The usecase already logged the error of the run, it is recorded in the run
+		c.JSON(http.StatusInternalServerError, jobRunResponse(run))
+		return
+	}
+
+	c.JSON(http.StatusCreated, jobRunResponse(run))
+}
+
+func (h *JobHandler) GetUpdatePaymentStatusRuns(c *gin.Context) {
+	limit := defaultJobRunLimit
+	if limitParam := c.Query("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil {
+			pkg.Logger(c.Request.Context()).WithFields(log.Fields{
+				"limitParam": limitParam,
+				"error":      err,
+			}).Error("Invalid job run limit format")
+			c.Error(usecase.ErrInvalidJobRunLimit)
+			return
+		}
+		limit = parsed
+	}
+
+	runs, err := h.jobRunUsecase.GetRuns(c.Request.Context(), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := make([]job_dto_handler.JobRunResponse, len(runs))
+	for i, run := range runs {
+		response[i] = jobRunResponse(run)
+	}
+	c.JSON(http.StatusOK, job_dto_handler.JobRunListResponse{Runs: response})
+}
+
+func jobRunResponse(run *entity.JobRun) job_dto_handler.JobRunResponse {
+	response := job_dto_handler.JobRunResponse{
+		ID:             strconv.FormatUint(uint64(run.GetID()), 10),
+		Job:            run.Job(),
+		BusinessDate:   run.BusinessDate().Format(reportDateLayout),
+		Trigger:        run.Trigger(),
+		Status:         run.Status(),
+		ItemsProcessed: run.ItemsProcessed(),
+		ItemsFailed:    run.ItemsFailed(),
+		Error:          run.ErrorMessage(),
+		StartedAt:      run.StartedAt().Format(time.RFC3339),
+	}
+	if run.FinishedAt() != nil {
+		response.FinishedAt = run.FinishedAt().Format(time.RFC3339)
+	}
+	return response
+}
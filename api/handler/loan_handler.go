@@ -2,12 +2,12 @@ package handler
 
 import (
 	loan_dto_handler "billing_enginee/api/handler/dto/loan"
+	"billing_enginee/internal/entity"
 	"billing_enginee/internal/usecase"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 )
 
 type LoanHandler struct {
@@ -25,80 +25,77 @@ func (h *LoanHandler) CreateLoan(c *gin.Context) {
 
 	// Bind and validate JSON request
 	if err := c.ShouldBindJSON(&request); err != nil {
-		// Check if it's a validation error
-		if validationErrors, ok := err.(validator.ValidationErrors); ok {
-			errorMessages := request.CustomValidationMessages(validationErrors)
-			c.JSON(http.StatusBadRequest, gin.H{"errors": errorMessages})
-		} else {
-			// General error if it's not a validation error
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		}
+		c.Error(bindingError(err, request.CustomValidationMessages))
 		return
 	}
 
 	// Create the loan via the usecase
-	response, err := h.loanUsecase.CreateLoan(c, request.CustomerID, request.Name, request.Email, request.Amount, request.TermWeeks, request.Rates)
+	response, err := h.loanUsecase.CreateLoan(c.Request.Context(), request.CustomerID, request.Name, request.Email, request.Phone, request.Amount, request.TermWeeks, request.Rates)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	// Return success response
-	c.JSON(http.StatusOK, gin.H{
-		"loan_id":            strconv.FormatUint(uint64(response.LoanID), 10),
-		"total_amount":       response.TotalAmount,
-		"outstanding_amount": response.OutstandingAmount,
-		"week":               response.Week,
-		"due_date":           response.DueDate.Format("2006-01-02"),
+	c.JSON(http.StatusOK, loan_dto_handler.LoanResponse{
+		LoanID:            strconv.FormatUint(uint64(response.LoanID), 10),
+		TotalAmount:       response.TotalAmount,
+		OutstandingAmount: response.OutstandingAmount,
+		Week:              response.Week,
+		DueDate:           response.DueDate.Format("2006-01-02"),
 	})
 }
 
 func (h *LoanHandler) GetOutstanding(c *gin.Context) {
-	loanIDParam := c.Param("loan_id")
-	loanID, err := strconv.ParseUint(loanIDParam, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan ID"})
+	loanID, ok := parseLoanID(c)
+	if !ok {
 		return
 	}
 
 	// Get outstanding payments via usecase
-	response, err := h.loanUsecase.GetOutstanding(c, uint(loanID))
+	response, err := h.loanUsecase.GetOutstanding(c.Request.Context(), loanID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	// Return the response
-	c.JSON(http.StatusOK, gin.H{
-		"loan_id":            strconv.FormatUint(uint64(response.LoanID), 10),
-		"total_amount":       response.TotalAmount,
-		"outstanding_amount": response.OutstandingAmount,
-		"due_date":           response.DueDate.Format("2006-01-02"),
-		"week":               response.WeeksOutstanding,
+	c.JSON(http.StatusOK, loan_dto_handler.OutstandingResponse{
+		LoanID:            strconv.FormatUint(uint64(response.LoanID), 10),
+		TotalAmount:       response.TotalAmount,
+		OutstandingAmount: response.OutstandingAmount,
+		DueDate:           response.DueDate.Format("2006-01-02"),
+		Week:              response.WeeksOutstanding,
 	})
 }
 
 func (h *LoanHandler) MakePayment(c *gin.Context) {
-	loanIDParam := c.Param("loan_id")
-	loanID, err := strconv.ParseUint(loanIDParam, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan ID"})
+	loanID, ok := parseLoanID(c)
+	if !ok {
 		return
 	}
 
 	amountStr := c.Query("amount")
 	amount, err := strconv.ParseFloat(amountStr, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount"})
+		c.Error(entity.NewValidationError("Invalid amount"))
 		return
 	}
 
 	// Call the use case to process the payment
-	err = h.loanUsecase.MakePayment(c, uint(loanID), amount)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.loanUsecase.MakePayment(c.Request.Context(), loanID, amount); err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Payment successful"})
+	c.JSON(http.StatusOK, loan_dto_handler.PaymentResponse{Message: "Payment successful"})
+}
+
+func parseLoanID(c *gin.Context) (uint, bool) {
+	loanID, err := strconv.ParseUint(c.Param("loan_id"), 10, 32)
+	if err != nil {
+		c.Error(entity.NewValidationError("Invalid loan ID"))
+		return 0, false
+	}
+	return uint(loanID), true
 }
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type MetricsHandler struct {
+	gatherer prometheus.Gatherer
+}
+
+func NewMetricsHandler(gatherer prometheus.Gatherer) *MetricsHandler {
+	return &MetricsHandler{gatherer: gatherer}
+}
+
+// GetMetrics serves the metrics in the Prometheus text format
+func (h *MetricsHandler) GetMetrics(c *gin.Context) {
+	promhttp.HandlerFor(h.gatherer, promhttp.HandlerOpts{}).ServeHTTP(c.Writer, c.Request)
+}
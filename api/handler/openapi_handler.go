@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"billing_enginee/api/openapi"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OpenAPIHandler struct{}
+
+func NewOpenAPIHandler() *OpenAPIHandler {
+	return &OpenAPIHandler{}
+}
+
+func (h *OpenAPIHandler) GetDocument(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openapi.Document())
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	report_dto_handler "billing_enginee/api/handler/dto/report"
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const reportDateLayout = "2006-01-02"
+
+type ReportHandler struct {
+	reportUsecase usecase.ReportUsecase
+	tenants       tenant.Registry
+	clock         pkg.Clock
+}
+
+// NewReportHandler returns the report handler, report dates default to the business date of the tenant on the clock
+func NewReportHandler(reportUsecase usecase.ReportUsecase, tenants tenant.Registry, clock pkg.Clock) *ReportHandler {
+	return &ReportHandler{
+		reportUsecase: reportUsecase,
+		tenants:       tenants,
+		clock:         clock,
+	}
+}
+
+func (h *ReportHandler) GetPortfolio(c *gin.Context) {
+	response, err := h.reportUsecase.GetPortfolio(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report_dto_handler.PortfolioResponse{
+		LoanCount:            response.LoanCount,
+		TotalDisbursed:       response.TotalDisbursed,
+		TotalRepayable:       response.TotalRepayable,
+		OutstandingAmount:    response.OutstandingTotal,
+		OutstandingPrincipal: response.OutstandingPrincipal,
+		OutstandingInterest:  response.OutstandingInterest,
+		LoansByStatus:        response.LoansByStatus,
+	})
+}
+
+func (h *ReportHandler) GetPortfolioAtRisk(c *gin.Context) {
+	today, ok := h.today(c)
+	if !ok {
+		return
+	}
+	asOf, ok := parseReportDate(c, "as_of", today)
+	if !ok {
+		return
+	}
+
+	response, err := h.reportUsecase.GetPortfolioAtRisk(c.Request.Context(), asOf)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report_dto_handler.PortfolioAtRiskResponse{
+		AsOf:                 response.AsOf.Format(reportDateLayout),
+		OutstandingPrincipal: response.OutstandingPrincipal,
+		Par30: report_dto_handler.ParBucket{
+			Principal: response.Par30Principal,
+			Loans:     response.Par30Loans,
+			Ratio:     response.Par30Ratio,
+		},
+		Par90: report_dto_handler.ParBucket{
+			Principal: response.Par90Principal,
+			Loans:     response.Par90Loans,
+			Ratio:     response.Par90Ratio,
+		},
+	})
+}
+
+func (h *ReportHandler) GetCollections(c *gin.Context) {
+	today, ok := h.today(c)
+	if !ok {
+		return
+	}
+	to, ok := parseReportDate(c, "to", today)
+	if !ok {
+		return
+	}
+	from, ok := parseReportDate(c, "from", to.AddDate(0, 0, -30))
+	if !ok {
+		return
+	}
+	period := c.DefaultQuery("period", "day")
+
+	// The "to" date is inclusive, so the range ends at the start of the following day
+	response, err := h.reportUsecase.GetCollections(c.Request.Context(), period, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	periods := make([]report_dto_handler.CollectionPeriod, len(response.PeriodBreakdown))
+	for i, collection := range response.PeriodBreakdown {
+		periods[i] = report_dto_handler.CollectionPeriod{
+			PeriodStart:       collection.PeriodStart.Format(reportDateLayout),
+			InstallmentsCount: collection.InstallmentsCount,
+			AmountCollected:   collection.AmountCollected,
+		}
+	}
+
+	c.JSON(http.StatusOK, report_dto_handler.CollectionsResponse{
+		Period:         response.Period,
+		From:           from.Format(reportDateLayout),
+		To:             to.Format(reportDateLayout),
+		TotalCollected: response.TotalCollected,
+		TotalCount:     response.TotalCount,
+		Periods:        periods,
+	})
+}
+
+// parseReportDate reads a YYYY-MM-DD query parameter in the timezone of the default, falling back to the default
+// when it is absent. It records a validation error and returns false when the value cannot be parsed.
+func parseReportDate(c *gin.Context, param string, defaultValue time.Time) (time.Time, bool) {
+	value := c.Query(param)
+	if value == "" {
+		return defaultValue, true
+	}
+
+	date, err := time.ParseInLocation(reportDateLayout, value, defaultValue.Location())
+	if err != nil {
+		pkg.Logger(c.Request.Context()).WithFields(log.Fields{
+			"param": param,
+			"value": value,
+			"error": err,
+		}).Error("Invalid report date format")
+		c.Error(entity.NewValidationError(param + " must be a date in YYYY-MM-DD format"))
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// today returns the business date of the tenant of the request, at midnight in its timezone. It records the error
+// and returns false when the tenant cannot be resolved.
+func (h *ReportHandler) today(c *gin.Context) (time.Time, bool) {
+	reportTenant, err := h.tenants.ForContext(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return time.Time{}, false
+	}
+	now := reportTenant.Now(h.clock)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), true
+}
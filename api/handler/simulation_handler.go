@@ -0,0 +1,54 @@
+package handler
+
+import (
+	simulation_dto_handler "billing_enginee/api/handler/dto/simulation"
+	"billing_enginee/internal/usecase"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SimulationHandler struct {
+	simulationUsecase usecase.SimulationUsecase
+}
+
+func NewSimulationHandler(simulationUsecase usecase.SimulationUsecase) *SimulationHandler {
+	return &SimulationHandler{
+		simulationUsecase: simulationUsecase,
+	}
+}
+
+func (h *SimulationHandler) GetClock(c *gin.Context) {
+	c.JSON(http.StatusOK, clockResponse(h.simulationUsecase.GetClock(c.Request.Context())))
+}
+
+func (h *SimulationHandler) AdvanceClock(c *gin.Context) {
+	var request simulation_dto_handler.AdvanceClockRequest
+
+	// Bind and validate JSON request
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(bindingError(err, request.CustomValidationMessages))
+		return
+	}
+
+	response, err := h.simulationUsecase.Advance(c.Request.Context(), request.Days)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, clockResponse(response))
+}
+
+func clockResponse(clock *usecase.SimulationClockResponse) simulation_dto_handler.ClockResponse {
+	businessDates := make(map[string]string, len(clock.BusinessDates))
+	for tenantID, date := range clock.BusinessDates {
+		businessDates[tenantID] = date.Format(reportDateLayout)
+	}
+	return simulation_dto_handler.ClockResponse{
+		Now:           clock.Now.Format(time.RFC3339),
+		DaysAhead:     clock.DaysAhead,
+		BusinessDates: businessDates,
+	}
+}
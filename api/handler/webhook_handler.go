@@ -0,0 +1,132 @@
+package handler
+
+import (
+	webhook_dto_handler "billing_enginee/api/handler/dto/webhook"
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type WebhookHandler struct {
+	webhookUsecase usecase.WebhookUsecase
+}
+
+func NewWebhookHandler(webhookUsecase usecase.WebhookUsecase) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUsecase: webhookUsecase,
+	}
+}
+
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var request webhook_dto_handler.CreateWebhookRequest
+
+	// Bind and validate JSON request
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(bindingError(err, request.CustomValidationMessages))
+		return
+	}
+
+	subscription, err := h.webhookUsecase.CreateSubscription(c.Request.Context(), request.URL, request.Secret, request.EventTypes)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscriptionResponse(subscription))
+}
+
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	subscriptions, err := h.webhookUsecase.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := make([]webhook_dto_handler.SubscriptionResponse, len(subscriptions))
+	for i, subscription := range subscriptions {
+		response[i] = subscriptionResponse(subscription)
+	}
+	c.JSON(http.StatusOK, webhook_dto_handler.SubscriptionListResponse{Webhooks: response})
+}
+
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	subscriptionID, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookUsecase.DeleteSubscription(c.Request.Context(), subscriptionID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	subscriptionID, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.webhookUsecase.ListDeliveries(c.Request.Context(), subscriptionID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := make([]webhook_dto_handler.DeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		response[i] = deliveryResponse(delivery)
+	}
+	c.JSON(http.StatusOK, webhook_dto_handler.DeliveryListResponse{Deliveries: response})
+}
+
+// subscriptionResponse renders a subscription, the secret is never returned
+func subscriptionResponse(subscription *entity.WebhookSubscription) webhook_dto_handler.SubscriptionResponse {
+	return webhook_dto_handler.SubscriptionResponse{
+		ID:         strconv.FormatUint(uint64(subscription.GetID()), 10),
+		URL:        subscription.URL(),
+		EventTypes: subscription.EventTypeNames(),
+		Active:     subscription.Active(),
+		CreatedAt:  subscription.CreatedAt().Format(time.RFC3339),
+	}
+}
+
+func deliveryResponse(delivery *entity.WebhookDelivery) webhook_dto_handler.DeliveryResponse {
+	response := webhook_dto_handler.DeliveryResponse{
+		ID:             strconv.FormatUint(uint64(delivery.GetID()), 10),
+		EventID:        delivery.EventID(),
+		EventType:      delivery.EventType(),
+		Status:         delivery.Status(),
+		Attempts:       delivery.Attempts(),
+		NextAttemptAt:  delivery.NextAttemptAt().Format(time.RFC3339),
+		ResponseStatus: delivery.ResponseStatus(),
+		LastError:      delivery.LastError(),
+		CreatedAt:      delivery.CreatedAt().Format(time.RFC3339),
+	}
+	if delivery.DeliveredAt() != nil {
+		response.DeliveredAt = delivery.DeliveredAt().Format(time.RFC3339)
+	}
+	return response
+}
+
+func parseWebhookID(c *gin.Context) (uint, bool) {
+	webhookIDParam := c.Param("webhook_id")
+	webhookID, err := strconv.ParseUint(webhookIDParam, 10, 32)
+	if err != nil || webhookID == 0 {
+		pkg.Logger(c.Request.Context()).WithFields(log.Fields{
+			"webhookIDParam": webhookIDParam,
+			"error":          err,
+		}).Error("Invalid webhook ID format")
+		c.Error(entity.NewValidationError("Webhook ID must be a valid positive integer"))
+		return 0, false
+	}
+	return uint(webhookID), true
+}
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"billing_enginee/pkg"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditContextMiddleware records requests as made by the anonymous actor until AuthMiddleware identifies the caller
+func AuditContextMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(pkg.WithActor(c.Request.Context(), pkg.AnonymousActor))
+		c.Next()
+	}
+}
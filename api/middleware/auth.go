@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/pkg"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// PrincipalContextKey holds the authenticated *auth.Principal of the request
+const PrincipalContextKey = "principal"
+
+// AuthMiddleware authenticates the credentials of the request. Requests without credentials continue
+// without a principal, so public routes keep working; protected routes reject them in RequirePermission.
+// Credentials that are present but invalid, or that belong to an unknown tenant, are rejected with 401 right away.
+func AuthMiddleware(authenticator auth.Authenticator, tenants tenant.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authenticator.Authenticate(c.Request.Header)
+		if err != nil {
+			if errors.Is(err, auth.ErrNoCredentials) {
+				c.Next()
+				return
+			}
+			unauthorized(c, "Invalid credentials")
+			return
+		}
+
+		if _, err := tenants.Get(principal.TenantID); err != nil {
+			pkg.Logger(c.Request.Context()).WithFields(log.Fields{
+				"actor":    principal.Actor(),
+				"tenantID": principal.TenantID,
+			}).Warn("Rejected credential of an unknown tenant")
+			unauthorized(c, "Invalid credentials")
+			return
+		}
+
+		c.Set(PrincipalContextKey, principal)
+		ctx := pkg.WithActor(c.Request.Context(), principal.Actor())
+		c.Request = c.Request.WithContext(pkg.WithTenantID(ctx, principal.TenantID))
+		c.Next()
+	}
+}
+
+// RequirePermission answers 401 when the request is not authenticated and 403 when its role lacks the permission
+func RequirePermission(permission auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			unauthorized(c, "Authentication required")
+			return
+		}
+
+		if !principal.Can(permission) {
+			pkg.Logger(c.Request.Context()).WithFields(log.Fields{
+				"actor":      principal.Actor(),
+				"role":       principal.Role.String(),
+				"permission": permission,
+			}).Warn("Permission denied")
+			abortWithError(c, http.StatusForbidden, pkg.ErrorResponse{
+				Code:    "FORBIDDEN",
+				Message: "Role " + principal.Role.String() + " is not allowed to " + string(permission),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetPrincipal returns the authenticated principal of the request, nil when there is none
+func GetPrincipal(c *gin.Context) *auth.Principal {
+	value, exists := c.Get(PrincipalContextKey)
+	if !exists {
+		return nil
+	}
+	principal, _ := value.(*auth.Principal)
+	return principal
+}
+
+func unauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="billing"`)
+	abortWithError(c, http.StatusUnauthorized, pkg.ErrorResponse{
+		Code:    "UNAUTHORIZED",
+		Message: message,
+	})
+}
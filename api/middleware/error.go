@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/pkg"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// errorStatuses maps each kind of domain error to its status code and error code
+var errorStatuses = map[enum.ErrorKind]struct {
+	status int
+	code   string
+}{
+	enum.ErrorKindValidation:   {http.StatusBadRequest, "INVALID_INPUT"},
+	enum.ErrorKindNotFound:     {http.StatusNotFound, "NOT_FOUND"},
+	enum.ErrorKindConflict:     {http.StatusConflict, "CONFLICT"},
+	enum.ErrorKindBusinessRule: {http.StatusUnprocessableEntity, "BUSINESS_RULE_VIOLATION"},
+}
+
+// ErrorMiddleware renders the error a handler recorded with c.Error once the request is done, after the
+// transaction was rolled back. Domain errors are answered with the status of their kind, any other error
+// is logged and answered with 500 without exposing its message.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+
+		var domainErr *entity.DomainError
+		if errors.As(err, &domainErr) {
+			if mapped, ok := errorStatuses[domainErr.Kind()]; ok {
+				abortWithError(c, mapped.status, pkg.ErrorResponse{
+					Code:    mapped.code,
+					Message: domainErr.Message(),
+					Errors:  domainErr.Fields(),
+				})
+				return
+			}
+		}
+
+		pkg.Logger(c.Request.Context()).WithFields(log.Fields{
+			"method": c.Request.Method,
+			"path":   c.FullPath(),
+			"error":  err,
+		}).Error("Request failed")
+		abortWithError(c, http.StatusInternalServerError, pkg.ErrorResponse{
+			Code:    "INTERNAL_ERROR",
+			Message: "An unexpected error occurred",
+		})
+	}
+}
+
+// abortWithError answers the error with the request ID as its trace ID, so it can be found in the logs and the audit log
+func abortWithError(c *gin.Context, status int, response pkg.ErrorResponse) {
+	response.TraceID = pkg.GetRequestID(c.Request.Context())
+	if response.TraceID == "" {
+		response.TraceID = pkg.GenerateTraceID()
+	}
+	c.AbortWithStatusJSON(status, response)
+}
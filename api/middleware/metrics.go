@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"billing_enginee/internal/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that matched no route, so unknown paths do not each get their own series
+const unmatchedRoute = "unmatched"
+
+// MetricsMiddleware records the latency and status of every request under its route template, e.g. /api/v1/loans/:loan_id/payment
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		metrics.ObserveHTTPRequest(c.Request.Method, route, strconv.Itoa(c.Writer.Status()), time.Since(start))
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"billing_enginee/pkg"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// RequestIDHeader lets callers correlate their request with the logs and audit entries it wrote
+	RequestIDHeader = "X-Request-ID"
+	// maxRequestIDLength bounds request IDs sent by callers, longer ones are replaced
+	maxRequestIDLength = 64
+)
+
+// RequestIDMiddleware accepts the X-Request-ID of the caller or generates one, stores it in the request context
+// for pkg.Logger and the audit log, and echoes it in the response header
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = pkg.GenerateTraceID()
+		}
+
+		c.Request = c.Request.WithContext(pkg.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// RequestLoggerMiddleware logs every request once it is answered, with its request ID
+func RequestLoggerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		entry := pkg.Logger(c.Request.Context()).WithFields(log.Fields{
+			"method":    c.Request.Method,
+			"path":      c.Request.URL.Path,
+			"status":    c.Writer.Status(),
+			"latencyMs": time.Since(start).Milliseconds(),
+			"clientIP":  c.ClientIP(),
+		})
+		if c.Writer.Status() >= 500 {
+			entry.Error("Request completed")
+			return
+		}
+		entry.Info("Request completed")
+	}
+}
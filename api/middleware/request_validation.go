@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"billing_enginee/internal/entity"
+	"errors"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestValidationMiddleware rejects the requests that do not match their operation in the OpenAPI document
+// with 400, before they reach the handler or open a transaction. Requests to routes the document does not
+// describe, e.g. the probes and /metrics, are left to the router.
+func RequestValidationMiddleware(router routers.Router) gin.HandlerFunc {
+	options := &openapi3filter.Options{
+		// Credentials are checked by AuthMiddleware and the permissions of the routes
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		// Every invalid field is reported, like the validation of the request DTOs does
+		MultiError: true,
+	}
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		// The body is read and put back for the handler
+		err = openapi3filter.ValidateRequest(c.Request.Context(), &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		})
+		if err != nil {
+			c.Error(requestValidationError(err))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestValidationError reports every parameter and field of the body that does not match the document
+func requestValidationError(err error) error {
+	fields := make(map[string]string)
+	var reasons []string
+	collectRequestErrors(err, "", fields, &reasons)
+
+	if len(fields) > 0 {
+		return entity.NewFieldValidationError("Request is invalid", fields)
+	}
+	if len(reasons) == 0 {
+		return entity.NewValidationError("Request does not match the API")
+	}
+	return entity.NewValidationError("Request does not match the API: " + strings.Join(reasons, "; "))
+}
+
+// collectRequestErrors adds the message of each invalid parameter or body field to fields, keeping the first
+// one of a field, and the errors that concern no field to reasons
+func collectRequestErrors(err error, parameter string, fields map[string]string, reasons *[]string) {
+	addField := func(field string, message string) {
+		if _, ok := fields[field]; !ok {
+			fields[field] = message
+		}
+	}
+
+	var multiErr openapi3.MultiError
+	var requestErr *openapi3filter.RequestError
+	var schemaErr *openapi3.SchemaError
+	switch {
+	case errors.As(err, &multiErr):
+		for _, e := range multiErr {
+			collectRequestErrors(e, parameter, fields, reasons)
+		}
+	case errors.As(err, &requestErr):
+		if requestErr.Parameter != nil {
+			parameter = requestErr.Parameter.Name
+		}
+		if requestErr.Err != nil {
+			collectRequestErrors(requestErr.Err, parameter, fields, reasons)
+		} else if parameter != "" {
+			addField(parameter, requestErr.Reason)
+		} else {
+			*reasons = append(*reasons, requestErr.Reason)
+		}
+	case errors.As(err, &schemaErr):
+		field := parameter
+		if field == "" {
+			field = strings.Join(schemaErr.JSONPointer(), ".")
+		}
+		switch {
+		case field == "":
+			*reasons = append(*reasons, schemaErr.Reason)
+		case schemaErr.SchemaField == "required":
+			addField(field, field+" is required")
+		default:
+			addField(field, schemaErr.Reason)
+		}
+	case parameter != "":
+		addField(parameter, err.Error())
+	default:
+		*reasons = append(*reasons, err.Error())
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"billing_enginee/pkg"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -8,57 +9,74 @@ import (
 	"gorm.io/gorm"
 )
 
-func TransactionMiddleware(db *gorm.DB) gin.HandlerFunc {
+// TransactionMiddleware runs the request in a transaction. It is committed when the request succeeds and
+// rolled back when the handler records an error or responds with an error status, so a handler that only
+// writes the failure to the response cannot commit a half-done use case. The routes given as "METHOD /path"
+// in ownUnitsOfWork run outside of it, their use cases commit their own units of work.
+func TransactionMiddleware(db *gorm.DB, ownUnitsOfWork ...string) gin.HandlerFunc {
+	outsideTransaction := make(map[string]bool, len(ownUnitsOfWork))
+	for _, route := range ownUnitsOfWork {
+		outsideTransaction[route] = true
+	}
+
 	return func(c *gin.Context) {
+		if outsideTransaction[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+
 		// Start a new transaction
 		tx := db.Begin()
 		if tx.Error != nil {
-			log.WithFields(log.Fields{
+			pkg.Logger(c.Request.Context()).WithFields(log.Fields{
 				"error": tx.Error,
 			}).Error("Failed to start transaction")
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+			abortWithError(c, http.StatusInternalServerError, pkg.ErrorResponse{Code: "INTERNAL_ERROR", Message: "Failed to start transaction"})
 			return
 		}
 
-		log.Info("Transaction started")
-		// Store the transaction in the context
-		c.Set("db_tx", tx)
+		pkg.Logger(c.Request.Context()).Info("Transaction started")
+		// Store the transaction in the request context, the repositories join it through the unit of work
+		c.Request = c.Request.WithContext(pkg.WithTx(c.Request.Context(), tx))
 
 		// Ensure rollback if panic occurs
 		defer func() {
 			if r := recover(); r != nil {
 				tx.Rollback()
-				log.WithField("panic", r).Error("Panic occurred, transaction rolled back")
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Unexpected server error"})
+				pkg.Logger(c.Request.Context()).WithField("panic", r).Error("Panic occurred, transaction rolled back")
+				abortWithError(c, http.StatusInternalServerError, pkg.ErrorResponse{Code: "INTERNAL_ERROR", Message: "Unexpected server error"})
 			}
 		}()
 
 		// Process the request
 		c.Next()
 
-		// Check if there are any errors during the request
-		if len(c.Errors) > 0 {
+		// Check if the request failed
+		if len(c.Errors) > 0 || c.Writer.Status() >= http.StatusBadRequest {
 			// Rollback the transaction if any errors occurred
 			if err := tx.Rollback().Error; err != nil {
-				log.WithFields(log.Fields{
+				pkg.Logger(c.Request.Context()).WithFields(log.Fields{
 					"error": err,
 				}).Error("Failed to rollback transaction")
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to rollback transaction"})
+				abortWithError(c, http.StatusInternalServerError, pkg.ErrorResponse{Code: "INTERNAL_ERROR", Message: "Failed to rollback transaction"})
 				return
 			}
-			log.Info("Transaction rolled back due to errors")
+			pkg.Logger(c.Request.Context()).WithField("status", c.Writer.Status()).Info("Transaction rolled back due to errors")
 			return
 		}
 
 		// Commit the transaction if no errors occurred
-		if err := tx.Commit().Error; err != nil {
-			log.WithFields(log.Fields{
+		_, span := pkg.StartSpan(c.Request.Context(), "db.commit")
+		err := tx.Commit().Error
+		pkg.EndSpan(span, err)
+		if err != nil {
+			pkg.Logger(c.Request.Context()).WithFields(log.Fields{
 				"error": err,
 			}).Error("Failed to commit transaction")
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			abortWithError(c, http.StatusInternalServerError, pkg.ErrorResponse{Code: "INTERNAL_ERROR", Message: "Failed to commit transaction"})
 			return
 		}
 
-		log.Info("Transaction committed successfully")
+		pkg.Logger(c.Request.Context()).Info("Transaction committed successfully")
 	}
 }
@@ -0,0 +1,42 @@
+// Package openapi holds the OpenAPI 3 description of the /api/v1 routes.
+// The document is maintained by hand alongside the handlers, the contract specs fail when they drift apart.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+//go:embed openapi.json
+var document []byte
+
+// Document returns the OpenAPI document as JSON
+func Document() []byte {
+	return document
+}
+
+// Load parses and validates the document
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI document: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	return doc, nil
+}
+
+// NewRouter returns the router finding the operation of a request in the document
+func NewRouter(doc *openapi3.T) (routers.Router, error) {
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build OpenAPI router: %w", err)
+	}
+	return router, nil
+}
@@ -0,0 +1,317 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: billing/v1/billing.proto
+
+package billingv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	LoanService_CreateLoan_FullMethodName     = "/billing.v1.LoanService/CreateLoan"
+	LoanService_GetOutstanding_FullMethodName = "/billing.v1.LoanService/GetOutstanding"
+	LoanService_MakePayment_FullMethodName    = "/billing.v1.LoanService/MakePayment"
+)
+
+// LoanServiceClient is the client API for LoanService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// LoanService creates loans and records their weekly installments.
+// Calls authenticate with the x-api-key or authorization metadata, like the HTTP API.
+type LoanServiceClient interface {
+	// CreateLoan creates a loan and schedules its weekly installments. Requires loans:create.
+	CreateLoan(ctx context.Context, in *CreateLoanRequest, opts ...grpc.CallOption) (*CreateLoanResponse, error)
+	// GetOutstanding returns the outstanding amount of a loan and its next installment. Requires loans:read.
+	GetOutstanding(ctx context.Context, in *GetOutstandingRequest, opts ...grpc.CallOption) (*GetOutstandingResponse, error)
+	// MakePayment pays the next installment of a loan. Requires payments:record.
+	MakePayment(ctx context.Context, in *MakePaymentRequest, opts ...grpc.CallOption) (*MakePaymentResponse, error)
+}
+
+type loanServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLoanServiceClient(cc grpc.ClientConnInterface) LoanServiceClient {
+	return &loanServiceClient{cc}
+}
+
+func (c *loanServiceClient) CreateLoan(ctx context.Context, in *CreateLoanRequest, opts ...grpc.CallOption) (*CreateLoanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateLoanResponse)
+	err := c.cc.Invoke(ctx, LoanService_CreateLoan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loanServiceClient) GetOutstanding(ctx context.Context, in *GetOutstandingRequest, opts ...grpc.CallOption) (*GetOutstandingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOutstandingResponse)
+	err := c.cc.Invoke(ctx, LoanService_GetOutstanding_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loanServiceClient) MakePayment(ctx context.Context, in *MakePaymentRequest, opts ...grpc.CallOption) (*MakePaymentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MakePaymentResponse)
+	err := c.cc.Invoke(ctx, LoanService_MakePayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LoanServiceServer is the server API for LoanService service.
+// All implementations must embed UnimplementedLoanServiceServer
+// for forward compatibility.
+//
+// LoanService creates loans and records their weekly installments.
+// Calls authenticate with the x-api-key or authorization metadata, like the HTTP API.
+type LoanServiceServer interface {
+	// CreateLoan creates a loan and schedules its weekly installments. Requires loans:create.
+	CreateLoan(context.Context, *CreateLoanRequest) (*CreateLoanResponse, error)
+	// GetOutstanding returns the outstanding amount of a loan and its next installment. Requires loans:read.
+	GetOutstanding(context.Context, *GetOutstandingRequest) (*GetOutstandingResponse, error)
+	// MakePayment pays the next installment of a loan. Requires payments:record.
+	MakePayment(context.Context, *MakePaymentRequest) (*MakePaymentResponse, error)
+	mustEmbedUnimplementedLoanServiceServer()
+}
+
+// UnimplementedLoanServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLoanServiceServer struct{}
+
+func (UnimplementedLoanServiceServer) CreateLoan(context.Context, *CreateLoanRequest) (*CreateLoanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateLoan not implemented")
+}
+func (UnimplementedLoanServiceServer) GetOutstanding(context.Context, *GetOutstandingRequest) (*GetOutstandingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOutstanding not implemented")
+}
+func (UnimplementedLoanServiceServer) MakePayment(context.Context, *MakePaymentRequest) (*MakePaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MakePayment not implemented")
+}
+func (UnimplementedLoanServiceServer) mustEmbedUnimplementedLoanServiceServer() {}
+func (UnimplementedLoanServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeLoanServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LoanServiceServer will
+// result in compilation errors.
+type UnsafeLoanServiceServer interface {
+	mustEmbedUnimplementedLoanServiceServer()
+}
+
+func RegisterLoanServiceServer(s grpc.ServiceRegistrar, srv LoanServiceServer) {
+	// If the following call pancis, it indicates UnimplementedLoanServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LoanService_ServiceDesc, srv)
+}
+
+func _LoanService_CreateLoan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateLoanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoanServiceServer).CreateLoan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LoanService_CreateLoan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoanServiceServer).CreateLoan(ctx, req.(*CreateLoanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LoanService_GetOutstanding_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOutstandingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoanServiceServer).GetOutstanding(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LoanService_GetOutstanding_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoanServiceServer).GetOutstanding(ctx, req.(*GetOutstandingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LoanService_MakePayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MakePaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoanServiceServer).MakePayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LoanService_MakePayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoanServiceServer).MakePayment(ctx, req.(*MakePaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LoanService_ServiceDesc is the grpc.ServiceDesc for LoanService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LoanService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "billing.v1.LoanService",
+	HandlerType: (*LoanServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateLoan",
+			Handler:    _LoanService_CreateLoan_Handler,
+		},
+		{
+			MethodName: "GetOutstanding",
+			Handler:    _LoanService_GetOutstanding_Handler,
+		},
+		{
+			MethodName: "MakePayment",
+			Handler:    _LoanService_MakePayment_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "billing/v1/billing.proto",
+}
+
+const (
+	CustomerService_IsDelinquent_FullMethodName = "/billing.v1.CustomerService/IsDelinquent"
+)
+
+// CustomerServiceClient is the client API for CustomerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CustomerService answers questions about customers.
+type CustomerServiceClient interface {
+	// IsDelinquent tells whether a customer is delinquent. Requires customers:read.
+	IsDelinquent(ctx context.Context, in *IsDelinquentRequest, opts ...grpc.CallOption) (*IsDelinquentResponse, error)
+}
+
+type customerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCustomerServiceClient(cc grpc.ClientConnInterface) CustomerServiceClient {
+	return &customerServiceClient{cc}
+}
+
+func (c *customerServiceClient) IsDelinquent(ctx context.Context, in *IsDelinquentRequest, opts ...grpc.CallOption) (*IsDelinquentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IsDelinquentResponse)
+	err := c.cc.Invoke(ctx, CustomerService_IsDelinquent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CustomerServiceServer is the server API for CustomerService service.
+// All implementations must embed UnimplementedCustomerServiceServer
+// for forward compatibility.
+//
+// CustomerService answers questions about customers.
+type CustomerServiceServer interface {
+	// IsDelinquent tells whether a customer is delinquent. Requires customers:read.
+	IsDelinquent(context.Context, *IsDelinquentRequest) (*IsDelinquentResponse, error)
+	mustEmbedUnimplementedCustomerServiceServer()
+}
+
+// UnimplementedCustomerServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCustomerServiceServer struct{}
+
+func (UnimplementedCustomerServiceServer) IsDelinquent(context.Context, *IsDelinquentRequest) (*IsDelinquentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsDelinquent not implemented")
+}
+func (UnimplementedCustomerServiceServer) mustEmbedUnimplementedCustomerServiceServer() {}
+func (UnimplementedCustomerServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeCustomerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CustomerServiceServer will
+// result in compilation errors.
+type UnsafeCustomerServiceServer interface {
+	mustEmbedUnimplementedCustomerServiceServer()
+}
+
+func RegisterCustomerServiceServer(s grpc.ServiceRegistrar, srv CustomerServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCustomerServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CustomerService_ServiceDesc, srv)
+}
+
+func _CustomerService_IsDelinquent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IsDelinquentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CustomerServiceServer).IsDelinquent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CustomerService_IsDelinquent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CustomerServiceServer).IsDelinquent(ctx, req.(*IsDelinquentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CustomerService_ServiceDesc is the grpc.ServiceDesc for CustomerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CustomerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "billing.v1.CustomerService",
+	HandlerType: (*CustomerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "IsDelinquent",
+			Handler:    _CustomerService_IsDelinquent_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "billing/v1/billing.proto",
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"billing_enginee/api/handler"
+	"billing_enginee/api/middleware"
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAuditRoutes(router *gin.Engine, auditUsecase usecase.AuditUsecase) {
+	// Initialize the audit handler
+	auditHandler := handler.NewAuditHandler(auditUsecase)
+
+	// Define routes
+	v1 := router.Group("/api/v1")
+	{
+		v1.GET("/audit", middleware.RequirePermission(auth.PermissionReadAudit), auditHandler.GetEntries)
+	}
+}
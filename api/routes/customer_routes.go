@@ -2,6 +2,8 @@ package routes
 
 import (
 	"billing_enginee/api/handler"
+	"billing_enginee/api/middleware"
+	"billing_enginee/internal/auth"
 	"billing_enginee/internal/usecase"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +16,6 @@ func SetupCustomerRoutes(router *gin.Engine, customerUsecase usecase.CustomerUse
 	// Define routes
 	api := router.Group("/api/v1")
 	{
-		api.GET("/customers/:customer_id/is_delinquent", customerHandler.IsDelinquent)
+		api.GET("/customers/:customer_id/is_delinquent", middleware.RequirePermission(auth.PermissionReadCustomers), customerHandler.IsDelinquent)
 	}
 }
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"billing_enginee/api/handler"
+	"billing_enginee/internal/health"
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupHealthRoutes(router *gin.Engine, checker *health.Checker, sqlDB *sql.DB) {
+	// Initialize the health handler
+	healthHandler := handler.NewHealthHandler(checker, sqlDB)
+
+	// Probed by the orchestrator, they do not require a permission
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
+	router.GET("/version", healthHandler.Version)
+}
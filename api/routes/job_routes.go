@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"billing_enginee/api/handler"
+	"billing_enginee/api/middleware"
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobRoutesOutsideTransaction are the job routes not run in the transaction of the request, a run records its
+// outcome in its own units of work so a failed run is kept
+var JobRoutesOutsideTransaction = []string{http.MethodPost + " /api/v1/jobs/update-payment-status/runs"}
+
+// SetupJobRoutes registers the run history and the manual re-runs of the daily jobs
+func SetupJobRoutes(router *gin.Engine, jobRunUsecase usecase.JobRunUsecase) {
+	// Initialize the job handler
+	jobHandler := handler.NewJobHandler(jobRunUsecase)
+
+	// Define routes
+	v1 := router.Group("/api/v1")
+	{
+		v1.GET("/jobs/update-payment-status/runs", middleware.RequirePermission(auth.PermissionRunJobs), jobHandler.GetUpdatePaymentStatusRuns)
+		v1.POST("/jobs/update-payment-status/runs", middleware.RequirePermission(auth.PermissionRunJobs), jobHandler.RunUpdatePaymentStatus)
+	}
+}
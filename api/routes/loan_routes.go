@@ -2,6 +2,8 @@ package routes
 
 import (
 	"billing_enginee/api/handler"
+	"billing_enginee/api/middleware"
+	"billing_enginee/internal/auth"
 	"billing_enginee/internal/usecase"
 
 	"github.com/gin-gonic/gin"
@@ -14,8 +16,8 @@ func SetupLoanRoutes(router *gin.Engine, loanUsecase usecase.LoanUsecase) {
 	// Define routes
 	v1 := router.Group("/api/v1")
 	{
-		v1.POST("/loans", loanHandler.CreateLoan)
-		v1.GET("/loans/:loan_id/outstanding", loanHandler.GetOutstanding)
-		v1.POST("/loans/:loan_id/payment", loanHandler.MakePayment) // Route for making a payment
+		v1.POST("/loans", middleware.RequirePermission(auth.PermissionCreateLoans), loanHandler.CreateLoan)
+		v1.GET("/loans/:loan_id/outstanding", middleware.RequirePermission(auth.PermissionReadLoans), loanHandler.GetOutstanding)
+		v1.POST("/loans/:loan_id/payment", middleware.RequirePermission(auth.PermissionRecordPayments), loanHandler.MakePayment) // Route for making a payment
 	}
 }
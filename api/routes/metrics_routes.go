@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"billing_enginee/api/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func SetupMetricsRoutes(router *gin.Engine, gatherer prometheus.Gatherer) {
+	// Initialize the metrics handler
+	metricsHandler := handler.NewMetricsHandler(gatherer)
+
+	// Scraped by Prometheus, it does not require a permission
+	router.GET("/metrics", metricsHandler.GetMetrics)
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"billing_enginee/api/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupOpenAPIRoutes(router *gin.Engine) {
+	// Initialize the OpenAPI handler
+	openAPIHandler := handler.NewOpenAPIHandler()
+
+	// The document is public, it does not require a permission
+	router.GET("/openapi.json", openAPIHandler.GetDocument)
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"billing_enginee/api/handler"
+	"billing_enginee/api/middleware"
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupReportRoutes(router *gin.Engine, reportUsecase usecase.ReportUsecase, tenants tenant.Registry, clock pkg.Clock) {
+	// Initialize the report handler
+	reportHandler := handler.NewReportHandler(reportUsecase, tenants, clock)
+
+	// Define routes
+	v1 := router.Group("/api/v1")
+	{
+		v1.GET("/reports/portfolio", middleware.RequirePermission(auth.PermissionReadReports), reportHandler.GetPortfolio)
+		v1.GET("/reports/portfolio-at-risk", middleware.RequirePermission(auth.PermissionReadReports), reportHandler.GetPortfolioAtRisk)
+		v1.GET("/reports/collections", middleware.RequirePermission(auth.PermissionReadReports), reportHandler.GetCollections)
+	}
+}
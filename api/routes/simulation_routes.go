@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"billing_enginee/api/handler"
+	"billing_enginee/api/middleware"
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SimulationRoutesOutsideTransaction are the simulation routes not run in the transaction of the request, every
+// simulated day commits on its own like a scheduled run
+var SimulationRoutesOutsideTransaction = []string{http.MethodPost + " /api/v1/simulation/clock/advance"}
+
+// SetupSimulationRoutes registers the clock of the simulation mode, only deployments that enable it serve these routes
+func SetupSimulationRoutes(router *gin.Engine, simulationUsecase usecase.SimulationUsecase) {
+	// Initialize the simulation handler
+	simulationHandler := handler.NewSimulationHandler(simulationUsecase)
+
+	// Define routes
+	v1 := router.Group("/api/v1")
+	{
+		v1.GET("/simulation/clock", middleware.RequirePermission(auth.PermissionSimulate), simulationHandler.GetClock)
+		v1.POST("/simulation/clock/advance", middleware.RequirePermission(auth.PermissionSimulate), simulationHandler.AdvanceClock)
+	}
+}
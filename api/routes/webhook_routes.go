@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"billing_enginee/api/handler"
+	"billing_enginee/api/middleware"
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupWebhookRoutes(router *gin.Engine, webhookUsecase usecase.WebhookUsecase) {
+	// Initialize the webhook handler
+	webhookHandler := handler.NewWebhookHandler(webhookUsecase)
+
+	// Define routes
+	v1 := router.Group("/api/v1")
+	{
+		v1.POST("/webhooks", middleware.RequirePermission(auth.PermissionManageWebhooks), webhookHandler.CreateSubscription)
+		v1.GET("/webhooks", middleware.RequirePermission(auth.PermissionManageWebhooks), webhookHandler.ListSubscriptions)
+		v1.DELETE("/webhooks/:webhook_id", middleware.RequirePermission(auth.PermissionManageWebhooks), webhookHandler.DeleteSubscription)
+		v1.GET("/webhooks/:webhook_id/deliveries", middleware.RequirePermission(auth.PermissionManageWebhooks), webhookHandler.ListDeliveries)
+	}
+}
@@ -0,0 +1,96 @@
+// cmd/api/jobs.go
+package main
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/pkg/config"
+	"billing_enginee/pkg/container"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// jobsUsage documents the jobs command
+const jobsUsage = "usage: billing_enginee jobs run YYYY-MM-DD [tenant] | history [tenant]"
+
+// runJobs re-runs or lists the runs of the daily payment status update of a tenant, the default one when
+// it is not given, instead of starting the service:
+//
+//	jobs run YYYY-MM-DD [tenant]  runs the payment status update again as of the business date
+//	jobs history [tenant]         lists the latest runs, newest first
+func runJobs(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(jobsUsage)
+	}
+
+	c, err := container.NewContainer(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeResources(c.SQLDB)
+
+	switch args[0] {
+	case "run":
+		if len(args) < 2 {
+			return errors.New(jobsUsage)
+		}
+		asOf, err := time.Parse("2006-01-02", args[1])
+		if err != nil {
+			return fmt.Errorf("business date must be in YYYY-MM-DD format, got %q", args[1])
+		}
+		t, err := c.Tenants.Get(jobsTenantID(args[2:]))
+		if err != nil {
+			return err
+		}
+		run, err := c.JobRunUsecase.RerunUpdatePaymentStatus(pkg.NewTenantContext(t.ID), asOf)
+		if err != nil {
+			return err
+		}
+		log.WithFields(log.Fields{
+			"tenantID":       t.ID,
+			"jobRunID":       run.GetID(),
+			"businessDate":   run.BusinessDate().Format("2006-01-02"),
+			"itemsProcessed": run.ItemsProcessed(),
+		}).Info("Payment status update re-run")
+		return nil
+	case "history":
+		t, err := c.Tenants.Get(jobsTenantID(args[1:]))
+		if err != nil {
+			return err
+		}
+		runs, err := c.JobRunUsecase.GetRuns(pkg.NewTenantContext(t.ID), usecase.MaxJobRuns)
+		if err != nil {
+			return err
+		}
+		printJobRuns(runs)
+		return nil
+	default:
+		return fmt.Errorf("unknown jobs command %q, %s", args[0], jobsUsage)
+	}
+}
+
+// jobsTenantID returns the tenant given on the command line, the default tenant when there is none
+func jobsTenantID(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return tenant.DefaultTenantID
+}
+
+// printJobRuns writes the runs as a table on stdout
+func printJobRuns(runs []*entity.JobRun) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tBUSINESS DATE\tTRIGGER\tSTATUS\tITEMS\tFAILED\tSTARTED AT\tERROR")
+	for _, run := range runs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n", run.GetID(), run.BusinessDate().Format("2006-01-02"),
+			run.Trigger(), run.Status(), run.ItemsProcessed(), run.ItemsFailed(), run.StartedAt().Format(time.RFC3339), run.ErrorMessage())
+	}
+	w.Flush()
+}
@@ -2,81 +2,178 @@
 package main
 
 import (
+	"billing_enginee/api/grpcserver"
 	"billing_enginee/api/middleware"
 	"billing_enginee/api/routes"
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/health"
 	"billing_enginee/internal/runner"
-	"billing_enginee/internal/usecase"
+	"billing_enginee/internal/tenant"
 	"billing_enginee/pkg"
+	"billing_enginee/pkg/config"
 	"billing_enginee/pkg/container"
 	"context"
 	"database/sql"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/getkin/kin-openapi/routers"
 	"github.com/gin-gonic/gin"
 	"github.com/robfig/cron/v3"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 	"gorm.io/gorm"
 )
 
 func main() {
-	// Load environment variables
-	pkg.LoadEnv(".env")
+	// Load and validate the configuration, the service does not start with an invalid one
+	cfg, err := config.Load(".env")
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
 
 	// Set up logging
-	pkg.SetupLogger()
+	pkg.SetupLogger(cfg.Log)
+
+	// The migrate command manages the schema instead of starting the service
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Failed to migrate: %v", err)
+		}
+		return
+	}
+
+	// The jobs command re-runs or lists the runs of the daily jobs instead of starting the service
+	if len(os.Args) > 1 && os.Args[1] == "jobs" {
+		if err := runJobs(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Failed to run jobs command: %v", err)
+		}
+		return
+	}
+
+	// Set up tracing, spans are flushed on shutdown
+	shutdownTracing, err := pkg.SetupTracing(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
 
 	// Create dependency container
-	c, err := container.NewContainer()
+	c, err := container.NewContainer(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize dependencies: %v", err)
 	}
 	defer closeResources(c.SQLDB)
 
+	// The simulated clock is kept by one replica, another one would serve a business date of its own
+	if cfg.Features.Simulation {
+		unlock := lockSimulation(c.JobLock)
+		defer unlock()
+	}
+
+	// Probes are registered before the middleware, so they answer without credentials or a transaction
+	// and /readyz can report a database that is down
+	routes.SetupHealthRoutes(c.Router, c.Health, c.SQLDB)
+
 	// Set up middleware
-	setupMiddleware(c.Router, c.DB)
+	setupMiddleware(c.Router, c.DB, c.Authenticator, c.Tenants, c.OpenAPIRouter, cfg.Features)
 
 	// Set up HTTP routes
-	setupRoutes(c.Router, c.CustomerUsecase, c.LoanUsecase)
+	setupRoutes(c)
 
-	// Initialize and register scheduler tasks
-	scheduler := startScheduler()
-	registerSchedulerTasks(scheduler, c.PaymentUsecase, c.DB)
+	// Initialize and register scheduler tasks, replicas that only serve requests run without them
+	scheduler, schedulerRunning := startScheduler(c.Leader)
+	catchUp := &runner.CatchUpStatus{}
+	if cfg.Scheduler.Enabled {
+		registerSchedulerTasks(scheduler, cfg.Scheduler, c)
+		c.Health.AddCheck("scheduler", health.SchedulerCheck(schedulerRunning.Load, catchUp.Err))
+	} else {
+		log.Info("Scheduler disabled, daily jobs will not run on this instance")
+	}
+	logReadiness(c.Health)
 
 	// Start HTTP server
-	srv := createHTTPServer(c.Router)
+	srv := createHTTPServer(c.Router, cfg.HTTP)
 	startHTTPServer(srv)
 
+	// Business dates missed while no instance was running are caught up by the leader once it serves, so it
+	// answers its probes during a long catch-up
+	if cfg.Scheduler.Enabled && cfg.Scheduler.CatchUpDays > 0 {
+		runner.StartCatchUpUpdatePaymentStatus(catchUp, c.Leader, c.JobRunUsecase, c.Tenants, cfg.Scheduler.CatchUpDays)
+	}
+
+	// Start gRPC server on its own port
+	var grpcSrv *grpc.Server
+	if cfg.Features.GRPC {
+		grpcSrv = grpcserver.NewServer(c.UnitOfWork, c.Authenticator, c.Tenants, c.LoanUsecase, c.CustomerUsecase)
+		startGRPCServer(grpcSrv, cfg.GRPC.Port)
+	}
+
 	// Handle graceful shutdown
-	gracefulShutdown(srv, scheduler)
+	gracefulShutdown(srv, grpcSrv, cfg.HTTP.ShutdownTimeout, scheduler, schedulerRunning, c.Leader, c.Health, shutdownTracing)
 }
 
-// startScheduler initializes and starts the cron scheduler.
-func startScheduler() *cron.Cron {
-	c := cron.New(cron.WithLocation(time.FixedZone("Asia/Jakarta", 7*60*60))) // UTC+7
+// startScheduler initializes and starts the cron scheduler, and returns a flag telling whether it still runs.
+// Daily tasks run once per tenant in the timezone of that tenant. Every replica schedules the tasks, only the
+// leader runs them.
+func startScheduler(leader pkg.Leader) (*cron.Cron, *atomic.Bool) {
+	c := cron.New(cron.WithChain(runner.OnLeader(leader)))
 	c.Start()
-	return c
+
+	running := &atomic.Bool{}
+	running.Store(true)
+	return c, running
 }
 
-// registerSchedulerTasks registers tasks to be run by the scheduler.
-func registerSchedulerTasks(scheduler *cron.Cron, paymentUsecase usecase.PaymentUsecase, db *gorm.DB) {
+// registerSchedulerTasks registers tasks to be run by the scheduler on the cron specs of the configuration.
+func registerSchedulerTasks(scheduler *cron.Cron, cfg config.SchedulerConfig, c *container.Container) {
 	// Register tasks separately
-	runner.RegisterUpdatePaymentStatusScheduler(scheduler, paymentUsecase, db)
+	runner.RegisterUpdatePaymentStatusScheduler(scheduler, cfg.PaymentStatusCron, c.JobRunUsecase, c.Tenants, c.Clock)
+	runner.RegisterPaymentReminderScheduler(scheduler, cfg.PaymentReminderCron, c.NotificationUsecase, c.Tenants, c.Clock)
+	runner.RegisterWebhookDeliveryScheduler(scheduler, cfg.WebhookDeliveryCron, c.WebhookUsecase)
+	runner.RegisterOutboxRelayScheduler(scheduler, cfg.OutboxRelayCron, c.OutboxUsecase)
+	runner.RegisterNotificationDeliveryScheduler(scheduler, cfg.NotificationDeliveryCron, c.NotificationUsecase)
 
 	// Easily add more scheduled tasks by calling other functions here
 }
 
 // setupMiddleware applies global middleware to the router.
-func setupMiddleware(router *gin.Engine, db *gorm.DB) {
+func setupMiddleware(router *gin.Engine, db *gorm.DB, authenticator auth.Authenticator, tenants tenant.Registry, openAPIRouter routers.Router, features config.FeaturesConfig) {
 	// Apply CORS, logging, and any other middleware
-	router.Use(middleware.TransactionMiddleware(db))
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.TracingMiddleware())
+	router.Use(middleware.RequestLoggerMiddleware())
+	if features.Metrics {
+		router.Use(middleware.MetricsMiddleware())
+	}
+	router.Use(middleware.AuditContextMiddleware())
+	router.Use(middleware.ErrorMiddleware())
+	router.Use(middleware.AuthMiddleware(authenticator, tenants))
+	router.Use(middleware.RequestValidationMiddleware(openAPIRouter))
+	router.Use(middleware.TransactionMiddleware(db, slices.Concat(routes.JobRoutesOutsideTransaction, routes.SimulationRoutesOutsideTransaction)...))
 	// Add more middleware as needed
 }
 
+// logReadiness warns about the readiness checks that fail at startup. The servers start anyway,
+// /readyz keeps the traffic away until the checks pass, e.g. once the migrations ran.
+func logReadiness(checker *health.Checker) {
+	report := checker.Check(context.Background())
+	for name, err := range report.Checks {
+		if err != nil {
+			log.WithFields(log.Fields{
+				"check": name,
+				"error": err,
+			}).Warn("Service is not ready")
+		}
+	}
+}
+
 // closeResources closes the SQL database connection gracefully.
 func closeResources(sqlDB *sql.DB) {
 	if err := sqlDB.Close(); err != nil {
@@ -86,23 +183,49 @@ func closeResources(sqlDB *sql.DB) {
 }
 
 // setupRoutes registers the application routes with the router.
-func setupRoutes(router *gin.Engine, customerUsecase usecase.CustomerUsecase, loanUsecase usecase.LoanUsecase) {
-	routes.SetupCustomerRoutes(router, customerUsecase)
-	routes.SetupLoanRoutes(router, loanUsecase)
+func setupRoutes(c *container.Container) {
+	routes.SetupCustomerRoutes(c.Router, c.CustomerUsecase)
+	routes.SetupLoanRoutes(c.Router, c.LoanUsecase)
+	routes.SetupReportRoutes(c.Router, c.ReportUsecase, c.Tenants, c.Clock)
+	routes.SetupWebhookRoutes(c.Router, c.WebhookUsecase)
+	routes.SetupAuditRoutes(c.Router, c.AuditUsecase)
+	routes.SetupJobRoutes(c.Router, c.JobRunUsecase)
+	routes.SetupOpenAPIRoutes(c.Router)
+	if c.Config.Features.Metrics {
+		routes.SetupMetricsRoutes(c.Router, c.Metrics)
+	}
+	if c.Config.Features.Simulation {
+		log.Warn("Simulation mode enabled, the business date moves with the simulation clock")
+		routes.SetupSimulationRoutes(c.Router, c.SimulationUsecase)
+	}
 	// Add more route setups as needed
 }
 
-// createHTTPServer creates and configures an HTTP server.
-func createHTTPServer(router *gin.Engine) *http.Server {
-	// Load the port from the environment variable, with a default if not set
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // Default port
+// simulationLockKey is the job lock held by the replica in simulation mode
+const simulationLockKey = "simulation"
+
+// lockSimulation holds the simulation lock for the life of the process, and stops the service when another
+// replica in simulation mode holds it
+func lockSimulation(jobLock pkg.JobLock) func() {
+	unlock, acquired, err := jobLock.TryLock(context.Background(), simulationLockKey)
+	if err != nil {
+		log.Fatalf("Failed to take the simulation lock: %v", err)
+	}
+	if !acquired {
+		log.Fatal("Another replica runs in simulation mode, the simulation mode runs on a single replica")
 	}
+	return unlock
+}
 
+// createHTTPServer creates and configures an HTTP server.
+func createHTTPServer(router *gin.Engine, cfg config.HTTPConfig) *http.Server {
 	return &http.Server{
-		Addr:    fmt.Sprintf(":%s", port), // Set the address using the port
-		Handler: router,
+		Addr:              fmt.Sprintf(":%d", cfg.Port), // Set the address using the port
+		Handler:           router,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
 	}
 }
 
@@ -116,20 +239,70 @@ func startHTTPServer(srv *http.Server) {
 	}()
 }
 
-// gracefulShutdown handles the graceful shutdown of the HTTP server upon receiving a termination signal.
-func gracefulShutdown(srv *http.Server, scheduler *cron.Cron) {
+// startGRPCServer starts the gRPC server in a separate goroutine.
+func startGRPCServer(srv *grpc.Server, port int) {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		log.Fatalf("Failed to listen for gRPC: %v", err)
+	}
+
+	go func() {
+		log.Infof("gRPC server running on port %s", lis.Addr())
+		if err := srv.Serve(lis); err != nil && err != grpc.ErrServerStopped {
+			log.Fatalf("gRPC server error: %v", err)
+		}
+	}()
+}
+
+// gracefulShutdown handles the graceful shutdown of the HTTP and gRPC servers upon receiving a termination signal.
+// Readiness fails from the start of the shutdown, and the spans of the last requests are flushed once the servers stopped.
+// grpcSrv is nil when the gRPC server is disabled.
+func gracefulShutdown(srv *http.Server, grpcSrv *grpc.Server, timeout time.Duration, scheduler *cron.Cron, schedulerRunning *atomic.Bool, leader pkg.Leader, checker *health.Checker, shutdownTracing func(context.Context) error) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Info("Shutting down server...")
+	checker.SetShuttingDown()
 
 	// Stop scheduler
-	scheduler.Stop()
+	schedulerStopped := scheduler.Stop()
+	schedulerRunning.Store(false)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
+
+	// Let in-flight calls finish within the same deadline as the HTTP server
+	grpcStopped := make(chan struct{})
+	go func() {
+		if grpcSrv != nil {
+			grpcSrv.GracefulStop()
+		}
+		close(grpcStopped)
+	}()
+
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Failed to gracefully shutdown: %v", err)
 	}
+
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		log.Warn("gRPC server did not stop in time, closing remaining calls")
+		if grpcSrv != nil {
+			grpcSrv.Stop()
+		}
+	}
+
+	// The next leader may start as soon as the jobs running here are done
+	select {
+	case <-schedulerStopped.Done():
+		leader.Resign()
+	case <-ctx.Done():
+		log.Warn("Scheduled jobs did not finish in time, the leadership is released when the process exits")
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.Errorf("Failed to flush traces: %v", err)
+	}
 	log.Info("Server exited gracefully")
 }
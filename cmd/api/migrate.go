@@ -0,0 +1,91 @@
+// cmd/api/migrate.go
+package main
+
+import (
+	"billing_enginee/migrations"
+	"billing_enginee/pkg"
+	"billing_enginee/pkg/config"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// migrateUsage documents the migrate command
+const migrateUsage = "usage: billing_enginee migrate up | down [steps] | status"
+
+// runMigrate applies, rolls back or lists the embedded migrations, instead of starting the service:
+//
+//	migrate up            applies every pending migration
+//	migrate down [steps]  rolls back the newest applied migrations, one by default
+//	migrate status        lists the migrations and whether they are applied
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	_, sqlDB, err := pkg.InitDB(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer closeResources(sqlDB)
+
+	ctx := context.Background()
+	migrator := migrations.NewMigrator(sqlDB, cfg.Database.Driver)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		log.WithFields(log.Fields{
+			"applied": len(applied),
+			"version": migrations.LatestVersion(),
+		}).Info("Schema is up to date")
+		return nil
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number, got %q", args[1])
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		log.WithField("rolled_back", len(rolledBack)).Info("Migrations rolled back")
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(statuses)
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, %s", args[0], migrateUsage)
+	}
+}
+
+// printMigrationStatus writes the migrations and their state as a table on stdout
+func printMigrationStatus(statuses []migrations.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+	for _, status := range statuses {
+		name, state := "(not embedded in this binary)", "pending"
+		if status.Migration != nil {
+			name = status.Migration.Name
+		}
+		if status.Applied {
+			state = "applied"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", status.Version, name, state)
+	}
+	w.Flush()
+}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"billing_enginee/internal/tenant"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// APIKeyHeader carries the API key of service clients
+	APIKeyHeader = "X-API-Key"
+	bearerPrefix = "Bearer "
+)
+
+var (
+	ErrNoCredentials      = errors.New("no credentials provided")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator resolves the principal from the credentials of a request.
+// It returns ErrNoCredentials when the request carries none and ErrInvalidCredentials when they are rejected.
+type Authenticator interface {
+	Authenticate(header http.Header) (*Principal, error)
+}
+
+// APIKey is a service client credential, only the SHA-256 hash of the key is kept
+type APIKey struct {
+	Name     string
+	Role     Role
+	Hash     string
+	TenantID string
+}
+
+// StaffClaims are the claims of the JWTs issued to staff members
+type StaffClaims struct {
+	Role   string `json:"role"`
+	Tenant string `json:"tenant,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type authenticator struct {
+	apiKeys   []APIKey
+	jwtSecret []byte
+	jwtIssuer string
+}
+
+// NewAuthenticator accepts the given API keys and JWTs signed with HS256 by jwtSecret.
+// JWTs are disabled when jwtSecret is empty, and their issuer is only checked when jwtIssuer is set.
+func NewAuthenticator(apiKeys []APIKey, jwtSecret string, jwtIssuer string) Authenticator {
+	return &authenticator{
+		apiKeys:   apiKeys,
+		jwtSecret: []byte(jwtSecret),
+		jwtIssuer: jwtIssuer,
+	}
+}
+
+// NewAuthenticatorFromConfig builds the authenticator from the API keys, as listed by ParseAPIKeys,
+// and the secret and issuer of staff JWTs
+func NewAuthenticatorFromConfig(apiKeysValue string, jwtSecret string, jwtIssuer string) (Authenticator, error) {
+	apiKeys, err := ParseAPIKeys(apiKeysValue)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(apiKeys) == 0 && jwtSecret == "" {
+		log.Warn("No API keys or JWT secret configured, every protected route will answer 401")
+	}
+	return NewAuthenticator(apiKeys, jwtSecret, jwtIssuer), nil
+}
+
+// ParseAPIKeys reads a comma separated list of name:role:sha256-hex[:tenant] entries
+func ParseAPIKeys(value string) ([]APIKey, error) {
+	var apiKeys []APIKey
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 3 || len(parts) > 4 || parts[0] == "" || len(parts[2]) != sha256.Size*2 {
+			return nil, errors.Errorf("invalid API key entry %q, expected name:role:sha256-hex[:tenant]", parts[0])
+		}
+		role, err := ParseRole(parts[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid role of API key %q", parts[0])
+		}
+		tenantID := tenant.DefaultTenantID
+		if len(parts) == 4 && parts[3] != "" {
+			tenantID = parts[3]
+		}
+		apiKeys = append(apiKeys, APIKey{Name: parts[0], Role: role, Hash: strings.ToLower(parts[2]), TenantID: tenantID})
+	}
+	return apiKeys, nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 of the key, the form API keys are configured in
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *authenticator) Authenticate(header http.Header) (*Principal, error) {
+	if key := header.Get(APIKeyHeader); key != "" {
+		return a.authenticateAPIKey(key)
+	}
+	if authorization := header.Get("Authorization"); authorization != "" {
+		if !strings.HasPrefix(authorization, bearerPrefix) {
+			return nil, ErrInvalidCredentials
+		}
+		return a.authenticateJWT(strings.TrimPrefix(authorization, bearerPrefix))
+	}
+	return nil, ErrNoCredentials
+}
+
+func (a *authenticator) authenticateAPIKey(key string) (*Principal, error) {
+	hash := []byte(HashAPIKey(key))
+	for _, apiKey := range a.apiKeys {
+		if subtle.ConstantTimeCompare(hash, []byte(apiKey.Hash)) == 1 {
+			tenantID := apiKey.TenantID
+			if tenantID == "" {
+				tenantID = tenant.DefaultTenantID
+			}
+			return &Principal{Subject: apiKey.Name, Role: apiKey.Role, Method: MethodAPIKey, TenantID: tenantID}, nil
+		}
+	}
+
+	log.Warn("Rejected unknown API key")
+	return nil, ErrInvalidCredentials
+}
+
+func (a *authenticator) authenticateJWT(tokenString string) (*Principal, error) {
+	if len(a.jwtSecret) == 0 {
+		log.Warn("Rejected JWT, no JWT secret is configured")
+		return nil, ErrInvalidCredentials
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if a.jwtIssuer != "" {
+		options = append(options, jwt.WithIssuer(a.jwtIssuer))
+	}
+
+	var claims StaffClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(*jwt.Token) (interface{}, error) {
+		return a.jwtSecret, nil
+	}, options...)
+	if err != nil {
+		log.WithError(err).Warn("Rejected invalid JWT")
+		return nil, ErrInvalidCredentials
+	}
+
+	role, err := ParseRole(claims.Role)
+	if err != nil || claims.Subject == "" {
+		log.WithField("subject", claims.Subject).Warn("Rejected JWT without a valid subject and role")
+		return nil, ErrInvalidCredentials
+	}
+	tenantID := claims.Tenant
+	if tenantID == "" {
+		tenantID = tenant.DefaultTenantID
+	}
+	return &Principal{Subject: claims.Subject, Role: role, Method: MethodJWT, TenantID: tenantID}, nil
+}
+
+type staticAuthenticator struct {
+	principal *Principal
+}
+
+// NewStaticAuthenticator authenticates every request as the given principal, it is meant for tests
+func NewStaticAuthenticator(principal *Principal) Authenticator {
+	return &staticAuthenticator{
+		principal: principal,
+	}
+}
+
+func (a *staticAuthenticator) Authenticate(http.Header) (*Principal, error) {
+	return a.principal, nil
+}
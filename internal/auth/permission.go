@@ -0,0 +1,53 @@
+package auth
+
+// Permission is an action on the API that a role may be granted
+type Permission string
+
+const (
+	PermissionReadLoans      Permission = "loans:read"
+	PermissionCreateLoans    Permission = "loans:create"
+	PermissionRecordPayments Permission = "payments:record"
+	PermissionReadCustomers  Permission = "customers:read"
+	PermissionReadReports    Permission = "reports:read"
+	PermissionReadAudit      Permission = "audit:read"
+	PermissionManageWebhooks Permission = "webhooks:manage"
+	PermissionSimulate       Permission = "simulation:manage"
+	PermissionRunJobs        Permission = "jobs:run"
+)
+
+// rolePermissions maps every role to what it may do:
+//   - viewer reads loans and customers
+//   - agent originates loans and collects payments
+//   - finance collects payments and reads reports and the audit log
+//   - admin may do everything, including managing webhooks, re-running the daily jobs and moving the clock of
+//     the simulation mode
+var rolePermissions = map[Role][]Permission{
+	RoleViewer: {
+		PermissionReadLoans,
+		PermissionReadCustomers,
+	},
+	RoleAgent: {
+		PermissionReadLoans,
+		PermissionReadCustomers,
+		PermissionCreateLoans,
+		PermissionRecordPayments,
+	},
+	RoleFinance: {
+		PermissionReadLoans,
+		PermissionReadCustomers,
+		PermissionRecordPayments,
+		PermissionReadReports,
+		PermissionReadAudit,
+	},
+	RoleAdmin: {
+		PermissionReadLoans,
+		PermissionReadCustomers,
+		PermissionCreateLoans,
+		PermissionRecordPayments,
+		PermissionReadReports,
+		PermissionReadAudit,
+		PermissionManageWebhooks,
+		PermissionSimulate,
+		PermissionRunJobs,
+	},
+}
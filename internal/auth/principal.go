@@ -0,0 +1,27 @@
+package auth
+
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Subject  string // API key name or staff user ID
+	Role     Role
+	Method   string // MethodAPIKey or MethodJWT
+	TenantID string // Tenant whose data the principal works on
+}
+
+// Actor identifies the principal in the audit log, e.g. "api_key:collector-app" or "user:42"
+func (p *Principal) Actor() string {
+	if p.Method == MethodAPIKey {
+		return "api_key:" + p.Subject
+	}
+	return "user:" + p.Subject
+}
+
+// Can reports whether the principal is granted the permission
+func (p *Principal) Can(permission Permission) bool {
+	return p.Role.Can(permission)
+}
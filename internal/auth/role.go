@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type Role int
+
+const (
+	RoleViewer Role = iota
+	RoleAgent
+	RoleFinance
+	RoleAdmin
+)
+
+var roleNames = []string{
+	"viewer",
+	"agent",
+	"finance",
+	"admin",
+}
+
+// String method to convert Role to string
+func (role Role) String() string {
+	if int(role) >= 0 && int(role) < len(roleNames) {
+		return roleNames[role]
+	}
+	return "unknown"
+}
+
+// ParseRole converts string to Role
+func ParseRole(role string) (Role, error) {
+	for i, name := range roleNames {
+		if name == role {
+			return Role(i), nil
+		}
+	}
+	log.WithField("role", role).Error("Failed to parse Role")
+	return -1, fmt.Errorf("invalid role: %s", role)
+}
+
+// Can reports whether the role is granted the permission
+func (role Role) Can(permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
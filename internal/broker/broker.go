@@ -0,0 +1,49 @@
+package broker
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Message is a domain event handed to the broker by the outbox relay
+type Message struct {
+	ID         string    `json:"id"`
+	Key        string    `json:"key"` // Messages sharing a key are published in order
+	Type       string    `json:"type"`
+	TenantID   string    `json:"tenant_id"` // Consumers only act on the data of this tenant
+	Payload    []byte    `json:"payload"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Broker publishes messages to consumers. Publishing is at-least-once, consumers must deduplicate by message ID.
+type Broker interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+type multiBroker struct {
+	brokers []Broker
+}
+
+// NewMultiBroker publishes every message to all the given brokers, it fails when any of them fails
+func NewMultiBroker(brokers ...Broker) Broker {
+	return &multiBroker{
+		brokers: brokers,
+	}
+}
+
+func (b *multiBroker) Publish(ctx context.Context, msg Message) error {
+	var failures []string
+	for _, broker := range b.brokers {
+		if err := broker.Publish(ctx, msg); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.New("failed to publish message: " + strings.Join(failures, "; "))
+	}
+	return nil
+}
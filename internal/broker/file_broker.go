@@ -0,0 +1,50 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// FileBroker appends every message as a JSON line to a file, it lets local runs inspect published events
+type FileBroker struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileBroker(path string) *FileBroker {
+	return &FileBroker{
+		path: path,
+	}
+}
+
+func (b *FileBroker) Publish(_ context.Context, msg Message) error {
+	line, err := json.Marshal(struct {
+		Message
+		Payload json.RawMessage `json:"payload"`
+	}{Message: msg, Payload: msg.Payload})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode message")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	file, err := os.OpenFile(b.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open broker file")
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "failed to write message to broker file")
+	}
+	// The message counts as published only once it is durably written
+	if err := file.Sync(); err != nil {
+		return errors.Wrap(err, "failed to sync broker file")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package broker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Handler consumes a message, returning an error makes the relay retry it later
+type Handler func(ctx context.Context, msg Message) error
+
+// InProcessBroker dispatches messages synchronously to handlers registered in the same process
+type InProcessBroker struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func NewInProcessBroker() *InProcessBroker {
+	return &InProcessBroker{}
+}
+
+// Subscribe registers a handler that receives every published message
+func (b *InProcessBroker) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+func (b *InProcessBroker) Publish(ctx context.Context, msg Message) error {
+	b.mu.RLock()
+	handlers := append([]Handler(nil), b.handlers...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, msg); err != nil {
+			log.WithFields(log.Fields{
+				"messageID": msg.ID,
+				"type":      msg.Type,
+				"error":     err,
+			}).Error("In-process handler failed to consume message")
+			return errors.Wrap(err, "in-process handler failed")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package entity
+
+import (
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/model"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AuditEntry is an append-only record of a write made to an entity
+type AuditEntry struct {
+	id         uint
+	entityType enum.AuditEntityType
+	entityID   uint
+	action     enum.AuditAction
+	actor      string
+	requestID  string
+	before     map[string]interface{}
+	after      map[string]interface{}
+	reason     string
+	createdAt  time.Time
+}
+
+// CreateAuditEntry initializes the audit entry of a write, before is nil for creates and after is nil for deletes
+func CreateAuditEntry(
+	entityType enum.AuditEntityType,
+	entityID uint,
+	action enum.AuditAction,
+	actor string,
+	requestID string,
+	before map[string]interface{},
+	after map[string]interface{},
+	reason string,
+) *AuditEntry {
+	return &AuditEntry{
+		entityType: entityType,
+		entityID:   entityID,
+		action:     action,
+		actor:      actor,
+		requestID:  requestID,
+		before:     before,
+		after:      after,
+		reason:     reason,
+		createdAt:  time.Now(),
+	}
+}
+
+// MakeAuditEntry converts a model.AuditLog to an entity.AuditEntry
+func MakeAuditEntry(m *model.AuditLog) (*AuditEntry, error) {
+	entityType, err := enum.ParseAuditEntityType(m.EntityType)
+	if err != nil {
+		return nil, err
+	}
+	action, err := enum.ParseAuditAction(m.Action)
+	if err != nil {
+		return nil, err
+	}
+	before, err := unmarshalSnapshot(m.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := unmarshalSnapshot(m.After)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &AuditEntry{
+		id:         m.ID,
+		entityType: entityType,
+		entityID:   m.EntityID,
+		action:     action,
+		actor:      m.Actor,
+		before:     before,
+		after:      after,
+		reason:     m.Reason,
+		createdAt:  m.CreatedAt,
+	}
+	if m.RequestID != nil {
+		entry.requestID = *m.RequestID
+	}
+	return entry, nil
+}
+
+// ToModel converts an entity.AuditEntry to a model.AuditLog
+func (e *AuditEntry) ToModel() (*model.AuditLog, error) {
+	before, err := marshalSnapshot(e.before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := marshalSnapshot(e.after)
+	if err != nil {
+		return nil, err
+	}
+
+	auditModel := &model.AuditLog{
+		ID:         e.id,
+		EntityType: e.entityType.String(),
+		EntityID:   e.entityID,
+		Action:     e.action.String(),
+		Actor:      e.actor,
+		Before:     before,
+		After:      after,
+		Reason:     e.reason,
+		CreatedAt:  e.createdAt,
+	}
+	if e.requestID != "" {
+		auditModel.RequestID = &e.requestID
+	}
+	return auditModel, nil
+}
+
+func marshalSnapshot(snapshot map[string]interface{}) (*string, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal audit snapshot")
+	}
+	value := string(encoded)
+	return &value, nil
+}
+
+func unmarshalSnapshot(encoded *string) (map[string]interface{}, error) {
+	if encoded == nil {
+		return nil, nil
+	}
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal([]byte(*encoded), &snapshot); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal audit snapshot")
+	}
+	return snapshot, nil
+}
+
+func (e *AuditEntry) SetID(id uint) {
+	e.id = id
+}
+
+func (e *AuditEntry) GetID() uint {
+	return e.id
+}
+
+func (e *AuditEntry) EntityType() string {
+	return e.entityType.String()
+}
+
+func (e *AuditEntry) EntityID() uint {
+	return e.entityID
+}
+
+func (e *AuditEntry) Action() string {
+	return e.action.String()
+}
+
+func (e *AuditEntry) Actor() string {
+	return e.actor
+}
+
+func (e *AuditEntry) RequestID() string {
+	return e.requestID
+}
+
+// Before returns the changed fields before the write, nil for creates
+func (e *AuditEntry) Before() map[string]interface{} {
+	return e.before
+}
+
+// After returns the changed fields after the write, nil for deletes
+func (e *AuditEntry) After() map[string]interface{} {
+	return e.after
+}
+
+func (e *AuditEntry) Reason() string {
+	return e.reason
+}
+
+func (e *AuditEntry) CreatedAt() time.Time {
+	return e.createdAt
+}
@@ -3,25 +3,31 @@ package entity
 import "billing_enginee/internal/model"
 
 type Customer struct {
-	id    uint
-	name  string
-	email string
-	loans *[]Loan
+	id         uint
+	externalID uint // The customer ID given by the tenant
+	name       string
+	email      string
+	phone      string
+	loans      *[]Loan
 }
 
-func CreateCustomer(id uint, name, email string) *Customer {
+// CreateCustomer initializes a new customer of the tenant, known to it by externalID
+func CreateCustomer(externalID uint, name, email, phone string) *Customer {
 	return &Customer{
-		id:    id,
-		name:  name,
-		email: email,
+		externalID: externalID,
+		name:       name,
+		email:      email,
+		phone:      phone,
 	}
 }
 
 func MakeCustomer(m *model.Customer) (*Customer, error) {
 	c := &Customer{
-		id:    m.ID,
-		name:  m.Name,
-		email: m.Email,
+		id:         m.ID,
+		externalID: m.ExternalID,
+		name:       m.Name,
+		email:      m.Email,
+		phone:      m.Phone,
 	}
 	if m.Loans != nil && len(*m.Loans) > 0 {
 		loans := make([]Loan, len(*m.Loans))
@@ -41,9 +47,11 @@ func MakeCustomer(m *model.Customer) (*Customer, error) {
 // Add ToModel method to convert entity.Customer to model.Customer
 func (c *Customer) ToModel() *model.Customer {
 	m := &model.Customer{
-		ID:    c.id,
-		Name:  c.name,
-		Email: c.email,
+		ID:         c.id,
+		ExternalID: c.externalID,
+		Name:       c.name,
+		Email:      c.email,
+		Phone:      c.phone,
 	}
 
 	if c.loans != nil && len(*c.loans) > 0 {
@@ -67,7 +75,33 @@ func (c *Customer) GetID() uint {
 	return c.id
 }
 
-func (c *Customer) IsDelinquent() bool {
+// ExternalID returns the customer ID given by the tenant, the one the API takes
+func (c *Customer) ExternalID() uint {
+	return c.externalID
+}
+
+// Name returns the customer's name
+func (c *Customer) Name() string {
+	return c.name
+}
+
+// Email returns the customer's email address
+func (c *Customer) Email() string {
+	return c.email
+}
+
+// Phone returns the customer's phone number, empty when unknown
+func (c *Customer) Phone() string {
+	return c.phone
+}
+
+// Loans returns the loans of the customer with their installments, nil when none were loaded
+func (c *Customer) Loans() *[]Loan {
+	return c.loans
+}
+
+// IsDelinquent reports whether the customer missed at least minPendingInstallments installments
+func (c *Customer) IsDelinquent(minPendingInstallments int) bool {
 	pendingCount := 0
 	for _, loan := range *c.loans {
 		for _, payment := range *loan.GetPayments() {
@@ -75,8 +109,8 @@ func (c *Customer) IsDelinquent() bool {
 				pendingCount++
 			}
 
-			// If 2 or more pending payments found, customer is delinquent
-			if pendingCount >= 2 {
+			// If enough pending payments found, customer is delinquent
+			if pendingCount >= minPendingInstallments {
 				return true
 			}
 		}
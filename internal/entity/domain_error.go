@@ -0,0 +1,58 @@
+package entity
+
+import "billing_enginee/internal/entity/enum"
+
+var (
+	ErrLoanNotFound                = NewNotFoundError("loan not found")
+	ErrCustomerNotFound            = NewNotFoundError("customer not found")
+	ErrWebhookSubscriptionNotFound = NewNotFoundError("webhook subscription not found")
+	ErrLoanLocked                  = NewConflictError("loan is being updated by another request, retry later")
+	ErrNoOutstandingPayment        = NewBusinessRuleError("loan has no outstanding payment")
+	ErrPaymentAmountMismatch       = NewBusinessRuleError("payment amount does not match outstanding balance")
+)
+
+// DomainError is an error of the billing rules. Its kind tells the API how to report it,
+// its message is safe to show to the caller.
+type DomainError struct {
+	kind    enum.ErrorKind
+	message string
+	fields  map[string]string
+}
+
+func NewValidationError(message string) *DomainError {
+	return &DomainError{kind: enum.ErrorKindValidation, message: message}
+}
+
+func NewNotFoundError(message string) *DomainError {
+	return &DomainError{kind: enum.ErrorKindNotFound, message: message}
+}
+
+func NewConflictError(message string) *DomainError {
+	return &DomainError{kind: enum.ErrorKindConflict, message: message}
+}
+
+func NewBusinessRuleError(message string) *DomainError {
+	return &DomainError{kind: enum.ErrorKindBusinessRule, message: message}
+}
+
+// NewFieldValidationError reports the invalid fields of a request, each with its own message
+func NewFieldValidationError(message string, fields map[string]string) *DomainError {
+	return &DomainError{kind: enum.ErrorKindValidation, message: message, fields: fields}
+}
+
+func (e *DomainError) Error() string {
+	return e.message
+}
+
+func (e *DomainError) Kind() enum.ErrorKind {
+	return e.kind
+}
+
+func (e *DomainError) Message() string {
+	return e.message
+}
+
+// Fields maps each invalid field to its message, it is empty for errors that do not concern fields
+func (e *DomainError) Fields() map[string]string {
+	return e.fields
+}
@@ -0,0 +1,71 @@
+package enum
+
+import (
+	"fmt"
+)
+
+type AuditAction int
+
+const (
+	AuditActionCreate AuditAction = iota
+	AuditActionUpdate
+	AuditActionDelete
+)
+
+var auditActionNames = []string{
+	"create",
+	"update",
+	"delete",
+}
+
+// String method to convert AuditAction to string
+func (action AuditAction) String() string {
+	if int(action) >= 0 && int(action) < len(auditActionNames) {
+		return auditActionNames[action]
+	}
+	return "unknown"
+}
+
+// ParseAuditAction converts string to AuditAction
+func ParseAuditAction(action string) (AuditAction, error) {
+	for i, name := range auditActionNames {
+		if name == action {
+			return AuditAction(i), nil
+		}
+	}
+	return -1, fmt.Errorf("invalid audit action: %s", action)
+}
+
+type AuditEntityType int
+
+const (
+	AuditEntityCustomer AuditEntityType = iota
+	AuditEntityLoan
+	AuditEntityPayment
+	AuditEntityWebhookSubscription
+)
+
+var auditEntityTypeNames = []string{
+	"customer",
+	"loan",
+	"payment",
+	"webhook_subscription",
+}
+
+// String method to convert AuditEntityType to string
+func (entityType AuditEntityType) String() string {
+	if int(entityType) >= 0 && int(entityType) < len(auditEntityTypeNames) {
+		return auditEntityTypeNames[entityType]
+	}
+	return "unknown"
+}
+
+// ParseAuditEntityType converts string to AuditEntityType
+func ParseAuditEntityType(entityType string) (AuditEntityType, error) {
+	for i, name := range auditEntityTypeNames {
+		if name == entityType {
+			return AuditEntityType(i), nil
+		}
+	}
+	return -1, fmt.Errorf("invalid audit entity type: %s", entityType)
+}
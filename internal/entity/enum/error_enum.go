@@ -0,0 +1,30 @@
+package enum
+
+// ErrorKind classifies domain errors, callers use it to report an error without knowing every error
+type ErrorKind int
+
+const (
+	// ErrorKindValidation is input that is malformed or out of range
+	ErrorKindValidation ErrorKind = iota
+	// ErrorKindNotFound is a reference to something that does not exist
+	ErrorKindNotFound
+	// ErrorKindConflict is a change that clashes with a concurrent change, retrying may succeed
+	ErrorKindConflict
+	// ErrorKindBusinessRule is valid input that the current state of a loan does not allow
+	ErrorKindBusinessRule
+)
+
+var errorKindNames = []string{
+	"validation",
+	"not_found",
+	"conflict",
+	"business_rule",
+}
+
+// String method to convert ErrorKind to string
+func (kind ErrorKind) String() string {
+	if int(kind) < len(errorKindNames) {
+		return errorKindNames[kind]
+	}
+	return "unknown"
+}
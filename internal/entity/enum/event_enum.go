@@ -0,0 +1,44 @@
+package enum
+
+import (
+	"fmt"
+)
+
+type EventType int
+
+const (
+	EventTypeLoanCreated EventType = iota
+	EventTypePaymentReceived
+	EventTypeInstallmentOverdue
+	EventTypeLoanClosed
+)
+
+var eventTypeNames = []string{
+	"loan.created",
+	"payment.received",
+	"installment.overdue",
+	"loan.closed",
+}
+
+// String method to convert EventType to string
+func (eventType EventType) String() string {
+	if int(eventType) >= 0 && int(eventType) < len(eventTypeNames) {
+		return eventTypeNames[eventType]
+	}
+	return "unknown"
+}
+
+// ParseEventType converts string to EventType
+func ParseEventType(eventType string) (EventType, error) {
+	for i, name := range eventTypeNames {
+		if name == eventType {
+			return EventType(i), nil
+		}
+	}
+	return -1, fmt.Errorf("invalid event type: %s", eventType)
+}
+
+// EventTypeNames returns the names of all supported event types
+func EventTypeNames() []string {
+	return append([]string(nil), eventTypeNames...)
+}
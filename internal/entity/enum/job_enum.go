@@ -0,0 +1,74 @@
+package enum
+
+import (
+	"fmt"
+)
+
+type JobRunStatus int
+
+const (
+	JobRunStatusRunning JobRunStatus = iota
+	JobRunStatusSucceeded
+	JobRunStatusFailed
+	JobRunStatusPartiallyFailed
+)
+
+var jobRunStatusNames = []string{
+	"running",
+	"succeeded",
+	"failed",
+	"partially_failed",
+}
+
+// String method to convert JobRunStatus to string
+func (status JobRunStatus) String() string {
+	if int(status) >= 0 && int(status) < len(jobRunStatusNames) {
+		return jobRunStatusNames[status]
+	}
+	return "unknown"
+}
+
+// ParseJobRunStatus converts string to JobRunStatus
+func ParseJobRunStatus(status string) (JobRunStatus, error) {
+	for i, name := range jobRunStatusNames {
+		if name == status {
+			return JobRunStatus(i), nil
+		}
+	}
+	return -1, fmt.Errorf("invalid job run status: %s", status)
+}
+
+// JobRunTrigger tells what started a job run
+type JobRunTrigger int
+
+const (
+	JobRunTriggerSchedule JobRunTrigger = iota
+	JobRunTriggerCatchUp
+	JobRunTriggerManual
+	JobRunTriggerSimulation
+)
+
+var jobRunTriggerNames = []string{
+	"schedule",
+	"catch_up",
+	"manual",
+	"simulation",
+}
+
+// String method to convert JobRunTrigger to string
+func (trigger JobRunTrigger) String() string {
+	if int(trigger) >= 0 && int(trigger) < len(jobRunTriggerNames) {
+		return jobRunTriggerNames[trigger]
+	}
+	return "unknown"
+}
+
+// ParseJobRunTrigger converts string to JobRunTrigger
+func ParseJobRunTrigger(trigger string) (JobRunTrigger, error) {
+	for i, name := range jobRunTriggerNames {
+		if name == trigger {
+			return JobRunTrigger(i), nil
+		}
+	}
+	return -1, fmt.Errorf("invalid job run trigger: %s", trigger)
+}
@@ -2,8 +2,6 @@ package enum
 
 import (
 	"fmt"
-
-	log "github.com/sirupsen/logrus"
 )
 
 type LoanStatus int
@@ -33,6 +31,5 @@ func ParseLoanStatus(status string) (LoanStatus, error) {
 			return LoanStatus(i), nil
 		}
 	}
-	log.WithField("status", status).Error("Failed to parse LoanStatus")
 	return -1, fmt.Errorf("invalid loan status: %s", status)
 }
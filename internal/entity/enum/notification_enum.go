@@ -0,0 +1,37 @@
+package enum
+
+import (
+	"fmt"
+)
+
+type NotificationStatus int
+
+const (
+	NotificationStatusPending NotificationStatus = iota
+	NotificationStatusSent
+	NotificationStatusFailed
+)
+
+var notificationStatusNames = []string{
+	"pending",
+	"sent",
+	"failed",
+}
+
+// String method to convert NotificationStatus to string
+func (status NotificationStatus) String() string {
+	if int(status) >= 0 && int(status) < len(notificationStatusNames) {
+		return notificationStatusNames[status]
+	}
+	return "unknown"
+}
+
+// ParseNotificationStatus converts string to NotificationStatus
+func ParseNotificationStatus(status string) (NotificationStatus, error) {
+	for i, name := range notificationStatusNames {
+		if name == status {
+			return NotificationStatus(i), nil
+		}
+	}
+	return -1, fmt.Errorf("invalid notification status: %s", status)
+}
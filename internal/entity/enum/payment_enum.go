@@ -2,8 +2,6 @@ package enum
 
 import (
 	"fmt"
-
-	log "github.com/sirupsen/logrus"
 )
 
 type PaymentStatus int
@@ -37,6 +35,5 @@ func ParsePaymentStatus(status string) (PaymentStatus, error) {
 			return PaymentStatus(i), nil
 		}
 	}
-	log.WithField("status", status).Error("Failed to parse PaymentStatus")
 	return -1, fmt.Errorf("invalid payment status: %s", status)
 }
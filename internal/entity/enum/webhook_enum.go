@@ -0,0 +1,37 @@
+package enum
+
+import (
+	"fmt"
+)
+
+type WebhookDeliveryStatus int
+
+const (
+	WebhookDeliveryStatusPending WebhookDeliveryStatus = iota
+	WebhookDeliveryStatusDelivered
+	WebhookDeliveryStatusFailed
+)
+
+var webhookDeliveryStatusNames = []string{
+	"pending",
+	"delivered",
+	"failed",
+}
+
+// String method to convert WebhookDeliveryStatus to string
+func (status WebhookDeliveryStatus) String() string {
+	if int(status) >= 0 && int(status) < len(webhookDeliveryStatusNames) {
+		return webhookDeliveryStatusNames[status]
+	}
+	return "unknown"
+}
+
+// ParseWebhookDeliveryStatus converts string to WebhookDeliveryStatus
+func ParseWebhookDeliveryStatus(status string) (WebhookDeliveryStatus, error) {
+	for i, name := range webhookDeliveryStatusNames {
+		if name == status {
+			return WebhookDeliveryStatus(i), nil
+		}
+	}
+	return -1, fmt.Errorf("invalid webhook delivery status: %s", status)
+}
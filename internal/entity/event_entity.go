@@ -0,0 +1,169 @@
+package entity
+
+import (
+	"billing_enginee/internal/entity/enum"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// eventEnvelope is the JSON representation of an event shared with consumers
+type eventEnvelope struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	TenantID   string                 `json:"tenant_id"`
+	LoanID     uint                   `json:"loan_id"`
+	OccurredAt string                 `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// Event is a domain event raised by a state change of a loan or one of its payments
+type Event struct {
+	id         string
+	eventType  enum.EventType
+	tenantID   string
+	loanID     uint
+	occurredAt time.Time
+	data       map[string]interface{}
+}
+
+// CreateEvent initializes a new event for the given loan
+func CreateEvent(eventType enum.EventType, loanID uint, data map[string]interface{}) *Event {
+
+	return &Event{
+		id:         uuid.New().String(),
+		eventType:  eventType,
+		loanID:     loanID,
+		occurredAt: time.Now(),
+		data:       data,
+	}
+}
+
+// NewLoanCreatedEvent builds the loan.created event
+func NewLoanCreatedEvent(loan *Loan) *Event {
+	return CreateEvent(enum.EventTypeLoanCreated, loan.GetID(), map[string]interface{}{
+		"loan_id":      loan.GetID(),
+		"customer_id":  customerIDOf(loan),
+		"amount":       loan.Amount(),
+		"total_amount": loan.TotalAmount(),
+		"term_weeks":   loan.TermWeeks(),
+		"rates":        loan.Rates(),
+	})
+}
+
+// NewPaymentReceivedEvent builds the payment.received event for the installments settled by a payment
+func NewPaymentReceivedEvent(loanID uint, amount float64, weeks []int) *Event {
+	return CreateEvent(enum.EventTypePaymentReceived, loanID, map[string]interface{}{
+		"loan_id": loanID,
+		"amount":  amount,
+		"weeks":   weeks,
+	})
+}
+
+// NewInstallmentOverdueEvent builds the installment.overdue event
+func NewInstallmentOverdueEvent(payment *Payment) *Event {
+	return CreateEvent(enum.EventTypeInstallmentOverdue, payment.LoanID(), map[string]interface{}{
+		"loan_id":    payment.LoanID(),
+		"payment_id": payment.GetID(),
+		"week":       payment.Week(),
+		"amount":     payment.Amount(),
+		"due_date":   payment.DueDate().Format("2006-01-02"),
+	})
+}
+
+// NewLoanClosedEvent builds the loan.closed event
+func NewLoanClosedEvent(loan *Loan) *Event {
+	return CreateEvent(enum.EventTypeLoanClosed, loan.GetID(), map[string]interface{}{
+		"loan_id":     loan.GetID(),
+		"customer_id": customerIDOf(loan),
+	})
+}
+
+// customerIDOf returns the customer ID the tenant gave the customer of the loan, consumers do not know our own.
+// The customer is loaded with the loans events are published for.
+func customerIDOf(loan *Loan) uint {
+	if loan.Customer() == nil {
+		return 0
+	}
+	return loan.Customer().ExternalID()
+}
+
+// GetID returns the unique ID of the event
+func (e *Event) GetID() string {
+	return e.id
+}
+
+// Type returns the type of the event
+func (e *Event) Type() enum.EventType {
+	return e.eventType
+}
+
+// TenantID returns the tenant of the loan the event belongs to
+func (e *Event) TenantID() string {
+	return e.tenantID
+}
+
+// SetTenantID records the tenant the event is published for
+func (e *Event) SetTenantID(tenantID string) {
+	e.tenantID = tenantID
+}
+
+// LoanID returns the loan the event belongs to
+func (e *Event) LoanID() uint {
+	return e.loanID
+}
+
+// OccurredAt returns when the event happened
+func (e *Event) OccurredAt() time.Time {
+	return e.occurredAt
+}
+
+// Data returns the event specific attributes
+func (e *Event) Data() map[string]interface{} {
+	return e.data
+}
+
+// MarshalPayload encodes the event in the JSON envelope sent to consumers
+func (e *Event) MarshalPayload() ([]byte, error) {
+	payload, err := json.Marshal(eventEnvelope{
+		ID:         e.id,
+		Type:       e.eventType.String(),
+		TenantID:   e.tenantID,
+		LoanID:     e.loanID,
+		OccurredAt: e.occurredAt.UTC().Format(time.RFC3339Nano),
+		Data:       e.data,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal event payload")
+	}
+	return payload, nil
+}
+
+// UnmarshalEvent rebuilds an event from the payload produced by MarshalPayload
+func UnmarshalEvent(payload []byte) (*Event, error) {
+	var envelope eventEnvelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal event payload")
+	}
+
+	eventType, err := enum.ParseEventType(envelope.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	occurredAt, err := time.Parse(time.RFC3339Nano, envelope.OccurredAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse event occurred_at")
+	}
+
+	return &Event{
+		id:         envelope.ID,
+		eventType:  eventType,
+		tenantID:   envelope.TenantID,
+		loanID:     envelope.LoanID,
+		occurredAt: occurredAt,
+		data:       envelope.Data,
+	}, nil
+}
@@ -0,0 +1,145 @@
+package entity
+
+import (
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/model"
+	"time"
+)
+
+// JobRun records one run of a daily job for the business date of a tenant
+type JobRun struct {
+	id             uint
+	job            string
+	businessDate   time.Time
+	trigger        enum.JobRunTrigger
+	status         enum.JobRunStatus
+	itemsProcessed int
+	itemsFailed    int
+	err            string
+	startedAt      time.Time
+	finishedAt     *time.Time
+}
+
+// StartJobRun initializes a running job run. The business date only keeps its calendar date.
+func StartJobRun(job string, businessDate time.Time, trigger enum.JobRunTrigger, startedAt time.Time) *JobRun {
+	return &JobRun{
+		job:          job,
+		businessDate: time.Date(businessDate.Year(), businessDate.Month(), businessDate.Day(), 0, 0, 0, 0, time.UTC),
+		trigger:      trigger,
+		status:       enum.JobRunStatusRunning,
+		startedAt:    startedAt,
+	}
+}
+
+// Finish ends the run, it failed when err is not nil, possibly after processing most of the items
+func (r *JobRun) Finish(itemsProcessed int, itemsFailed int, err error, finishedAt time.Time) {
+	r.itemsProcessed = itemsProcessed
+	r.itemsFailed = itemsFailed
+	r.status = enum.JobRunStatusSucceeded
+	if err != nil {
+		r.status = enum.JobRunStatusFailed
+		r.err = err.Error()
+	}
+	r.finishedAt = &finishedAt
+}
+
+// FinishPartially ends a run that carried on past the items that failed, err tells why they failed
+func (r *JobRun) FinishPartially(itemsProcessed int, itemsFailed int, err error, finishedAt time.Time) {
+	r.Finish(itemsProcessed, itemsFailed, err, finishedAt)
+	r.status = enum.JobRunStatusPartiallyFailed
+}
+
+// MakeJobRun converts a model.JobRun to an entity.JobRun
+func MakeJobRun(m *model.JobRun) (*JobRun, error) {
+	trigger, err := enum.ParseJobRunTrigger(m.TriggeredBy)
+	if err != nil {
+		return nil, err
+	}
+	status, err := enum.ParseJobRunStatus(m.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &JobRun{
+		id:             m.ID,
+		job:            m.Job,
+		businessDate:   time.Date(m.BusinessDate.Year(), m.BusinessDate.Month(), m.BusinessDate.Day(), 0, 0, 0, 0, time.UTC),
+		trigger:        trigger,
+		status:         status,
+		itemsProcessed: m.ItemsProcessed,
+		itemsFailed:    m.ItemsFailed,
+		startedAt:      m.StartedAt,
+		finishedAt:     m.FinishedAt,
+	}
+	if m.Error != nil {
+		run.err = *m.Error
+	}
+	return run, nil
+}
+
+// ToModel converts an entity.JobRun to a model.JobRun
+func (r *JobRun) ToModel() *model.JobRun {
+	runModel := &model.JobRun{
+		ID:             r.id,
+		Job:            r.job,
+		BusinessDate:   r.businessDate,
+		TriggeredBy:    r.trigger.String(),
+		Status:         r.status.String(),
+		ItemsProcessed: r.itemsProcessed,
+		ItemsFailed:    r.itemsFailed,
+		StartedAt:      r.startedAt,
+		FinishedAt:     r.finishedAt,
+	}
+	if r.err != "" {
+		runModel.Error = &r.err
+	}
+	return runModel
+}
+
+func (r *JobRun) SetID(id uint) {
+	r.id = id
+}
+
+func (r *JobRun) GetID() uint {
+	return r.id
+}
+
+func (r *JobRun) Job() string {
+	return r.job
+}
+
+// BusinessDate returns the calendar date the job ran for, at midnight UTC
+func (r *JobRun) BusinessDate() time.Time {
+	return r.businessDate
+}
+
+func (r *JobRun) Trigger() string {
+	return r.trigger.String()
+}
+
+func (r *JobRun) Status() string {
+	return r.status.String()
+}
+
+func (r *JobRun) ItemsProcessed() int {
+	return r.itemsProcessed
+}
+
+// ItemsFailed returns how many of the processed items were left unchanged by a failure
+func (r *JobRun) ItemsFailed() int {
+	return r.itemsFailed
+}
+
+// ErrorMessage returns the error the run or its failed items failed with, empty when nothing failed
+func (r *JobRun) ErrorMessage() string {
+	return r.err
+}
+
+func (r *JobRun) StartedAt() time.Time {
+	return r.startedAt
+}
+
+// FinishedAt returns when the run ended, nil while it runs
+func (r *JobRun) FinishedAt() *time.Time {
+	return r.finishedAt
+}
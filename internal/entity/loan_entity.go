@@ -3,11 +3,10 @@ package entity
 import (
 	"billing_enginee/internal/entity/enum"
 	"billing_enginee/internal/model"
-	"errors"
 	"math"
 	"time"
 
-	logrus "github.com/sirupsen/logrus"
+	"github.com/pkg/errors"
 )
 
 type Loan struct {
@@ -21,52 +20,35 @@ type Loan struct {
 	createdAt   time.Time
 	updatedAt   time.Time
 	payments    *[]Payment // Pointer to a slice of associated payments
+	customer    *Customer  // Associated customer, only set when it was loaded
 }
 
-// CreateLoan is used to initialize a new Loan entity
-func CreateLoan(customerID uint, amount float64, termWeeks int, rates float64) *Loan {
+// CreateLoan is used to initialize a new Loan entity of the saved customer, created at the business time given
+func CreateLoan(customer *Customer, amount float64, termWeeks int, rates float64, createdAt time.Time) *Loan {
 	totalAmount := amount + (amount * rates / 100)
-	logrus.WithFields(logrus.Fields{
-		"customerID":  customerID,
-		"amount":      amount,
-		"termWeeks":   termWeeks,
-		"rates":       rates,
-		"totalAmount": totalAmount,
-	}).Info("Creating new loan")
 
 	status, _ := enum.ParseLoanStatus("open")
 	return &Loan{
-		customerID:  customerID,
+		customerID:  customer.GetID(),
+		customer:    customer,
 		amount:      amount,
 		totalAmount: totalAmount,
 		status:      status,
 		termWeeks:   termWeeks,
 		rates:       rates,
-		createdAt:   time.Now(),
+		createdAt:   createdAt,
 	}
 }
 
 // MakeLoan converts a model.Loan to an entity.Loan
 func MakeLoan(m *model.Loan) (*Loan, error) {
 	if m.Amount <= 0 || m.Rates < 0 || m.TermWeeks <= 0 {
-		logrus.WithFields(logrus.Fields{
-			"ID":         m.ID,
-			"CustomerID": m.CustomerID,
-			"Amount":     m.Amount,
-			"Rates":      m.Rates,
-			"TermWeeks":  m.TermWeeks,
-			"Status":     m.Status,
-		}).Error("Invalid loan data for MakeLoan")
-		return nil, errors.New("invalid loan data: amount, rates, and termWeeks must be positive values")
+		return nil, errors.Errorf("invalid data of loan %d: amount, rates, and termWeeks must be positive values", m.ID)
 	}
 
 	status, err := enum.ParseLoanStatus(m.Status)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"Status": m.Status,
-			"Error":  err.Error(),
-		}).Error("Failed to parse loan status during MakeLoan")
-		return nil, err
+		return nil, errors.Wrapf(err, "invalid loan %d", m.ID)
 	}
 
 	loan := &Loan{
@@ -81,29 +63,26 @@ func MakeLoan(m *model.Loan) (*Loan, error) {
 		updatedAt:   m.UpdatedAt,
 	}
 
-	if m.Payments != nil && len(*m.Payments) > 0 {
-		logrus.WithFields(logrus.Fields{
-			"loanID":   m.ID,
-			"payments": len(*m.Payments),
-		}).Info("Converting model payments to entity payments")
+	if m.Customer.ID != 0 {
+		customer, err := MakeCustomer(&m.Customer)
+		if err != nil {
+			return nil, err
+		}
+		loan.customer = customer
+	}
 
+	if m.Payments != nil && len(*m.Payments) > 0 {
 		loan.payments = &[]Payment{}
 		for _, paymentModel := range *m.Payments {
 			paymentConvert, err := MakePayment(&paymentModel)
 			if err != nil {
-				logrus.WithFields(logrus.Fields{
-					"loanID":  m.ID,
-					"payment": paymentModel.ID,
-					"error":   err.Error(),
-				}).Error("Failed to convert model payment to entity during MakeLoan")
-				return nil, err
+				return nil, errors.Wrapf(err, "invalid payment %d of loan %d", paymentModel.ID, m.ID)
 			}
 			paymentEntity := paymentConvert
 			*loan.payments = append(*loan.payments, *paymentEntity)
 		}
 
 		if err := loan.HasOneOutstandingPayment(); err != nil {
-			logrus.WithField("loanID", m.ID).Error("Loan has more than one outstanding payment")
 			return nil, err
 		}
 	}
@@ -120,10 +99,6 @@ func (l *Loan) ToModel() *model.Loan {
 		}
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"loanID":   l.id,
-		"payments": len(paymentModels),
-	}).Info("Converting loan entity to model")
 	return &model.Loan{
 		ID:          l.id,
 		CustomerID:  l.customerID,
@@ -150,25 +125,19 @@ func (l *Loan) HasOneOutstandingPayment() error {
 	}
 
 	if outstandingCount > 1 {
-		logrus.WithField("loanID", l.id).Error("More than one outstanding payment found")
-		return errors.New("more than one outstanding payment found, this is a bug in the system")
+		return errors.Errorf("more than one outstanding payment found for loan %d, this is a bug in the system", l.id)
 	}
 	return nil
 }
 
 func (l *Loan) ValidateAmount(amount float64) error {
 	if l.GetTotalOutstandingAmount() == nil {
-		logrus.WithField("loanID", l.id).Error("No payments found for validation")
-		return errors.New("payment cannot be empty")
+		return ErrNoOutstandingPayment
 	}
 	const epsilon = 0.00001
 	totalOA := l.GetTotalOutstandingAmount()
 	if math.Abs(*totalOA-amount) > epsilon {
-		logrus.WithFields(logrus.Fields{
-			"expected": *totalOA,
-			"provided": amount,
-		}).Error("Payment amount does not match outstanding balance")
-		return errors.New("payment amount does not match outstanding balance")
+		return ErrPaymentAmountMismatch
 	}
 	return nil
 }
@@ -199,12 +168,6 @@ func (l *Loan) GetTotalOutstandingAmount() *float64 {
 			}
 			totalOutstanding += outstandingPayment.Amount()
 		}
-		logrus.WithFields(logrus.Fields{
-			"loanID":            l.id,
-			"totalOutstanding":  totalOutstanding,
-			"pendingPayments":   len(pendingPayments),
-			"outstandingExists": outstandingPayment != nil,
-		}).Info("Calculated total outstanding amount")
 		return &totalOutstanding
 	}
 	return nil
@@ -212,10 +175,6 @@ func (l *Loan) GetTotalOutstandingAmount() *float64 {
 
 // SetID sets the loan ID
 func (l *Loan) SetID(id uint) {
-	logrus.WithFields(logrus.Fields{
-		"oldID": l.id,
-		"newID": id,
-	}).Info("Setting loan ID")
 	l.id = id
 }
 
@@ -224,6 +183,26 @@ func (l *Loan) GetID() uint {
 	return l.id
 }
 
+// CustomerID returns the ID of the customer who owns the loan
+func (l *Loan) CustomerID() uint {
+	return l.customerID
+}
+
+// Amount returns the principal of the loan
+func (l *Loan) Amount() float64 {
+	return l.amount
+}
+
+// TermWeeks returns the number of weekly installments of the loan
+func (l *Loan) TermWeeks() int {
+	return l.termWeeks
+}
+
+// Rates returns the flat interest rate of the loan in percent
+func (l *Loan) Rates() float64 {
+	return l.rates
+}
+
 // TotalAmount returns the total amount of the loan
 func (l *Loan) TotalAmount() float64 {
 	return l.totalAmount
@@ -231,10 +210,6 @@ func (l *Loan) TotalAmount() float64 {
 
 // SetPayments sets the payments for the loan
 func (l *Loan) SetPayments(payments *[]Payment) {
-	logrus.WithFields(logrus.Fields{
-		"loanID":   l.id,
-		"payments": len(*payments),
-	}).Info("Setting payments for loan")
 	l.payments = payments
 }
 
@@ -243,19 +218,16 @@ func (l *Loan) GetPayments() *[]Payment {
 	return l.payments
 }
 
+// Customer returns the customer of the loan, nil when it was not loaded
+func (l *Loan) Customer() *Customer {
+	return l.customer
+}
+
 // SetStatus sets the status of the loan
 func (l *Loan) SetStatus(status string) error {
-	logrus.WithFields(logrus.Fields{
-		"loanID": l.id,
-		"status": status,
-	}).Info("Setting loan status")
 	statusEnum, err := enum.ParseLoanStatus(status)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"status": status,
-			"error":  err.Error(),
-		}).Error("Failed to parse and set loan status")
-		return err
+		return errors.Wrapf(err, "failed to set the status of loan %d", l.id)
 	}
 	l.status = statusEnum
 	return nil
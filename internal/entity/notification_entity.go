@@ -0,0 +1,166 @@
+package entity
+
+import (
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/model"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxNotificationAttempts is the number of send attempts before a notification is marked as failed
+	MaxNotificationAttempts = 6
+	notificationRetryBase   = time.Minute
+	notificationRetryMax    = 2 * time.Hour
+)
+
+// Notification is a message to the customer of an installment waiting to be sent
+type Notification struct {
+	id            uint
+	tenantID      string
+	paymentID     uint
+	template      string
+	daysUntilDue  int
+	status        enum.NotificationStatus
+	attempts      int
+	nextAttemptAt time.Time
+	lastError     *string
+	sentAt        *time.Time
+	createdAt     time.Time
+}
+
+// CreateNotification initializes a pending notification of the installment, daysUntilDue is rendered in reminders
+func CreateNotification(paymentID uint, template string, daysUntilDue int) *Notification {
+	return &Notification{
+		paymentID:     paymentID,
+		template:      template,
+		daysUntilDue:  daysUntilDue,
+		status:        enum.NotificationStatusPending,
+		nextAttemptAt: time.Now(),
+		createdAt:     time.Now(),
+	}
+}
+
+// MakeNotification converts a model.Notification to an entity.Notification
+func MakeNotification(m *model.Notification) (*Notification, error) {
+	status, err := enum.ParseNotificationStatus(m.Status)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid notification %d", m.ID)
+	}
+
+	return &Notification{
+		id:            m.ID,
+		tenantID:      m.TenantID,
+		paymentID:     m.PaymentID,
+		template:      m.Template,
+		daysUntilDue:  m.DaysUntilDue,
+		status:        status,
+		attempts:      m.Attempts,
+		nextAttemptAt: m.NextAttemptAt,
+		lastError:     m.LastError,
+		sentAt:        m.SentAt,
+		createdAt:     m.CreatedAt,
+	}, nil
+}
+
+// ToModel converts an entity.Notification to a model.Notification
+func (n *Notification) ToModel() *model.Notification {
+	return &model.Notification{
+		ID:            n.id,
+		TenantID:      n.tenantID,
+		PaymentID:     n.paymentID,
+		Template:      n.template,
+		DaysUntilDue:  n.daysUntilDue,
+		Status:        n.status.String(),
+		Attempts:      n.attempts,
+		NextAttemptAt: n.nextAttemptAt,
+		LastError:     n.lastError,
+		SentAt:        n.sentAt,
+		CreatedAt:     n.createdAt,
+	}
+}
+
+// MarkSent records a successful attempt
+func (n *Notification) MarkSent(now time.Time) {
+	n.attempts++
+	n.status = enum.NotificationStatusSent
+	n.lastError = nil
+	n.sentAt = &now
+}
+
+// MarkAttemptFailed records a failed attempt and schedules the next one with exponential backoff.
+// The notification is marked as failed once MaxNotificationAttempts attempts were made.
+func (n *Notification) MarkAttemptFailed(attemptErr error, now time.Time) {
+	n.attempts++
+	message := attemptErr.Error()
+	n.lastError = &message
+
+	if n.attempts >= MaxNotificationAttempts {
+		n.status = enum.NotificationStatusFailed
+		return
+	}
+
+	backoff := notificationRetryBase << (n.attempts - 1)
+	if backoff > notificationRetryMax {
+		backoff = notificationRetryMax
+	}
+	n.nextAttemptAt = now.Add(backoff)
+}
+
+// MarkFailed gives up on a notification that can never be sent, e.g. when the installment has no customer
+func (n *Notification) MarkFailed(reason error) {
+	n.attempts++
+	message := reason.Error()
+	n.lastError = &message
+	n.status = enum.NotificationStatusFailed
+}
+
+func (n *Notification) SetID(id uint) {
+	n.id = id
+}
+
+func (n *Notification) GetID() uint {
+	return n.id
+}
+
+// TenantID returns the tenant of the installment, set once the notification is stored
+func (n *Notification) TenantID() string {
+	return n.tenantID
+}
+
+func (n *Notification) PaymentID() uint {
+	return n.paymentID
+}
+
+func (n *Notification) Template() string {
+	return n.template
+}
+
+func (n *Notification) DaysUntilDue() int {
+	return n.daysUntilDue
+}
+
+func (n *Notification) Status() string {
+	return n.status.String()
+}
+
+func (n *Notification) Attempts() int {
+	return n.attempts
+}
+
+func (n *Notification) NextAttemptAt() time.Time {
+	return n.nextAttemptAt
+}
+
+func (n *Notification) LastError() *string {
+	return n.lastError
+}
+
+func (n *Notification) SentAt() *time.Time {
+	return n.sentAt
+}
+
+func (n *Notification) CreatedAt() time.Time {
+	return n.createdAt
+}
@@ -0,0 +1,170 @@
+package entity
+
+import (
+	"billing_enginee/internal/model"
+	"fmt"
+	"time"
+)
+
+const (
+	// OutboxAggregateLoan is the aggregate type of events that belong to a loan
+	OutboxAggregateLoan = "loan"
+	// MaxOutboxAttempts is the number of publish attempts before the relay gives up on a message
+	MaxOutboxAttempts = 12
+	outboxRetryBase   = 10 * time.Second
+	outboxRetryMax    = time.Hour
+)
+
+// OutboxMessage is an event waiting in the outbox table to be relayed to the broker
+type OutboxMessage struct {
+	id            uint
+	tenantID      string
+	eventID       string
+	aggregateType string
+	aggregateID   uint
+	eventType     string
+	payload       string
+	attempts      int
+	lastError     *string
+	nextAttemptAt *time.Time
+	publishedAt   *time.Time
+	failedAt      *time.Time
+	createdAt     time.Time
+}
+
+// CreateOutboxMessage wraps the event so it can be stored in the outbox
+func CreateOutboxMessage(event *Event) (*OutboxMessage, error) {
+	payload, err := event.MarshalPayload()
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxMessage{
+		tenantID:      event.TenantID(),
+		eventID:       event.GetID(),
+		aggregateType: OutboxAggregateLoan,
+		aggregateID:   event.LoanID(),
+		eventType:     event.Type().String(),
+		payload:       string(payload),
+		createdAt:     time.Now(),
+	}, nil
+}
+
+// MakeOutboxMessage converts a model.OutboxEvent to an entity.OutboxMessage
+func MakeOutboxMessage(m *model.OutboxEvent) *OutboxMessage {
+	return &OutboxMessage{
+		id:            m.ID,
+		tenantID:      m.TenantID,
+		eventID:       m.EventID,
+		aggregateType: m.AggregateType,
+		aggregateID:   m.AggregateID,
+		eventType:     m.EventType,
+		payload:       m.Payload,
+		attempts:      m.Attempts,
+		lastError:     m.LastError,
+		nextAttemptAt: m.NextAttemptAt,
+		publishedAt:   m.PublishedAt,
+		failedAt:      m.FailedAt,
+		createdAt:     m.CreatedAt,
+	}
+}
+
+// ToModel converts an entity.OutboxMessage to a model.OutboxEvent
+func (m *OutboxMessage) ToModel() *model.OutboxEvent {
+	return &model.OutboxEvent{
+		ID:            m.id,
+		TenantID:      m.tenantID,
+		EventID:       m.eventID,
+		AggregateType: m.aggregateType,
+		AggregateID:   m.aggregateID,
+		EventType:     m.eventType,
+		Payload:       m.payload,
+		Attempts:      m.attempts,
+		LastError:     m.lastError,
+		NextAttemptAt: m.nextAttemptAt,
+		PublishedAt:   m.publishedAt,
+		FailedAt:      m.failedAt,
+		CreatedAt:     m.createdAt,
+	}
+}
+
+// MarkPublished records that the broker accepted the message
+func (m *OutboxMessage) MarkPublished(now time.Time) {
+	m.attempts++
+	m.lastError = nil
+	m.publishedAt = &now
+}
+
+// MarkPublishFailed records a failed publish attempt and schedules the next one with exponential backoff.
+// The relay gives up on the message once MaxOutboxAttempts attempts were made, it stays in the outbox.
+func (m *OutboxMessage) MarkPublishFailed(err error, now time.Time) {
+	m.attempts++
+	message := err.Error()
+	m.lastError = &message
+
+	if m.attempts >= MaxOutboxAttempts {
+		m.failedAt = &now
+		return
+	}
+
+	backoff := outboxRetryBase << (m.attempts - 1)
+	if backoff > outboxRetryMax {
+		backoff = outboxRetryMax
+	}
+	nextAttemptAt := now.Add(backoff)
+	m.nextAttemptAt = &nextAttemptAt
+}
+
+// OrderingKey groups the messages that must be published in order, e.g. all events of one loan
+func (m *OutboxMessage) OrderingKey() string {
+	return fmt.Sprintf("%s:%d", m.aggregateType, m.aggregateID)
+}
+
+func (m *OutboxMessage) SetID(id uint) {
+	m.id = id
+}
+
+func (m *OutboxMessage) GetID() uint {
+	return m.id
+}
+
+// TenantID returns the tenant the event was published for
+func (m *OutboxMessage) TenantID() string {
+	return m.tenantID
+}
+
+func (m *OutboxMessage) EventID() string {
+	return m.eventID
+}
+
+func (m *OutboxMessage) EventType() string {
+	return m.eventType
+}
+
+func (m *OutboxMessage) Payload() string {
+	return m.payload
+}
+
+func (m *OutboxMessage) Attempts() int {
+	return m.attempts
+}
+
+func (m *OutboxMessage) LastError() *string {
+	return m.lastError
+}
+
+func (m *OutboxMessage) NextAttemptAt() *time.Time {
+	return m.nextAttemptAt
+}
+
+func (m *OutboxMessage) PublishedAt() *time.Time {
+	return m.publishedAt
+}
+
+func (m *OutboxMessage) FailedAt() *time.Time {
+	return m.failedAt
+}
+
+func (m *OutboxMessage) CreatedAt() time.Time {
+	return m.createdAt
+}
@@ -5,7 +5,7 @@ import (
 	"billing_enginee/internal/model"
 	"time"
 
-	logrus "github.com/sirupsen/logrus"
+	"github.com/pkg/errors"
 )
 
 type Payment struct {
@@ -16,20 +16,13 @@ type Payment struct {
 	amount  float64
 	dueDate time.Time
 	status  enum.PaymentStatus
+	paidAt  *time.Time
 }
 
 func CreatePayment(loanID uint, week int, amount float64, dueDate time.Time, status string) (*Payment, error) {
 	statusEnum, err := enum.ParsePaymentStatus(status)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"loanID":  loanID,
-			"week":    week,
-			"amount":  amount,
-			"dueDate": dueDate,
-			"status":  status,
-			"error":   err.Error(),
-		}).Error("Failed to parse payment status during CreatePayment")
-		return nil, err
+		return nil, errors.Wrapf(err, "invalid week %d payment of loan %d", week, loanID)
 	}
 
 	return &Payment{
@@ -44,26 +37,28 @@ func CreatePayment(loanID uint, week int, amount float64, dueDate time.Time, sta
 func MakePayment(m *model.Payment) (*Payment, error) {
 	statusEnum, err := enum.ParsePaymentStatus(m.Status)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"ID":      m.ID,
-			"LoanID":  m.LoanID,
-			"Week":    m.Week,
-			"Amount":  m.Amount,
-			"DueDate": m.DueDate,
-			"Status":  m.Status,
-			"Error":   err.Error(),
-		}).Error("Failed to parse payment status during MakePayment")
-		return nil, err
+		return nil, errors.Wrapf(err, "invalid payment %d", m.ID)
 	}
 
-	return &Payment{
+	payment := &Payment{
 		id:      m.ID,
 		loanID:  m.LoanID,
 		week:    m.Week,
 		amount:  m.Amount,
 		dueDate: m.DueDate,
 		status:  statusEnum,
-	}, nil
+		paidAt:  m.PaidAt,
+	}
+
+	if m.Loan.ID != 0 {
+		loan, err := MakeLoan(&m.Loan)
+		if err != nil {
+			return nil, err
+		}
+		payment.loan = loan
+	}
+
+	return payment, nil
 }
 
 func (p *Payment) ToModel() *model.Payment {
@@ -74,6 +69,7 @@ func (p *Payment) ToModel() *model.Payment {
 		Amount:  p.amount,
 		DueDate: p.dueDate,
 		Status:  p.status.String(),
+		PaidAt:  p.paidAt,
 	}
 }
 
@@ -111,16 +107,31 @@ func (p *Payment) Status() string {
 func (p *Payment) SetStatus(status string) error {
 	enum, err := enum.ParsePaymentStatus(status)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"currentStatus": status,
-			"error":         err.Error(),
-		}).Error("Failed to parse and set payment status")
-		return err
+		return errors.Wrapf(err, "failed to set the status of payment %d", p.id)
 	}
 	p.status = enum
 	return nil
 }
 
+// MarkPaid sets the status of the payment to paid, received at paidAt
+func (p *Payment) MarkPaid(paidAt time.Time) error {
+	if err := p.SetStatus("paid"); err != nil {
+		return err
+	}
+	p.paidAt = &paidAt
+	return nil
+}
+
+// PaidAt returns when the payment was received, nil when it is not paid
+func (p *Payment) PaidAt() *time.Time {
+	return p.paidAt
+}
+
+// LoanID returns the ID of the loan the payment belongs to
+func (p *Payment) LoanID() uint {
+	return p.loanID
+}
+
 func (p *Payment) Week() int {
 	return p.week
 }
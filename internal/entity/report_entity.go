@@ -0,0 +1,56 @@
+package entity
+
+import "time"
+
+// PortfolioSummary holds the aggregated figures of the whole loan book
+type PortfolioSummary struct {
+	LoanCount            int64
+	TotalDisbursed       float64
+	TotalRepayable       float64
+	OutstandingTotal     float64
+	OutstandingPrincipal float64
+}
+
+// OutstandingInterest returns the interest part of the outstanding balance
+func (s *PortfolioSummary) OutstandingInterest() float64 {
+	return s.OutstandingTotal - s.OutstandingPrincipal
+}
+
+// LoanStatusCount holds the number of loans for a single loan status
+type LoanStatusCount struct {
+	Status string
+	Count  int64
+}
+
+// PortfolioAtRisk holds the outstanding principal of loans that are late by more than 30 and 90 days
+type PortfolioAtRisk struct {
+	AsOf                 time.Time
+	OutstandingPrincipal float64
+	Par30Principal       float64
+	Par30Loans           int64
+	Par90Principal       float64
+	Par90Loans           int64
+}
+
+// Par30Ratio returns the share of the outstanding principal that is more than 30 days late
+func (p *PortfolioAtRisk) Par30Ratio() float64 {
+	if p.OutstandingPrincipal == 0 {
+		return 0
+	}
+	return p.Par30Principal / p.OutstandingPrincipal
+}
+
+// Par90Ratio returns the share of the outstanding principal that is more than 90 days late
+func (p *PortfolioAtRisk) Par90Ratio() float64 {
+	if p.OutstandingPrincipal == 0 {
+		return 0
+	}
+	return p.Par90Principal / p.OutstandingPrincipal
+}
+
+// CollectionPeriod holds the installments collected during one reporting period
+type CollectionPeriod struct {
+	PeriodStart       time.Time
+	InstallmentsCount int64
+	AmountCollected   float64
+}
@@ -0,0 +1,314 @@
+package entity
+
+import (
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/model"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxWebhookAttempts is the number of delivery attempts before a delivery is marked as failed
+	MaxWebhookAttempts = 8
+	webhookRetryBase   = 30 * time.Second
+	webhookRetryMax    = 6 * time.Hour
+)
+
+type WebhookSubscription struct {
+	id         uint
+	url        string
+	secret     string
+	eventTypes []enum.EventType
+	active     bool
+	createdAt  time.Time
+}
+
+// CreateWebhookSubscription validates and initializes a new webhook subscription
+func CreateWebhookSubscription(rawURL string, secret string, eventTypes []string) (*WebhookSubscription, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return nil, NewValidationError("webhook url must be an absolute http or https URL")
+	}
+	if secret == "" {
+		return nil, NewValidationError("webhook secret cannot be empty")
+	}
+
+	types, err := parseEventTypes(eventTypes)
+	if err != nil {
+		return nil, NewValidationError(err.Error())
+	}
+
+	return &WebhookSubscription{
+		url:        rawURL,
+		secret:     secret,
+		eventTypes: types,
+		active:     true,
+		createdAt:  time.Now(),
+	}, nil
+}
+
+// MakeWebhookSubscription converts a model.WebhookSubscription to an entity.WebhookSubscription
+func MakeWebhookSubscription(m *model.WebhookSubscription) (*WebhookSubscription, error) {
+	types, err := parseEventTypes(strings.Split(m.EventTypes, ","))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid webhook subscription %d", m.ID)
+	}
+
+	return &WebhookSubscription{
+		id:         m.ID,
+		url:        m.URL,
+		secret:     m.Secret,
+		eventTypes: types,
+		active:     m.Active,
+		createdAt:  m.CreatedAt,
+	}, nil
+}
+
+func parseEventTypes(eventTypes []string) ([]enum.EventType, error) {
+	if len(eventTypes) == 0 {
+		return nil, errors.New("at least one event type is required")
+	}
+
+	types := make([]enum.EventType, 0, len(eventTypes))
+	for _, name := range eventTypes {
+		eventType, err := enum.ParseEventType(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, eventType)
+	}
+	return types, nil
+}
+
+// ToModel converts an entity.WebhookSubscription to a model.WebhookSubscription
+func (s *WebhookSubscription) ToModel() *model.WebhookSubscription {
+	return &model.WebhookSubscription{
+		ID:         s.id,
+		URL:        s.url,
+		Secret:     s.secret,
+		EventTypes: strings.Join(s.EventTypeNames(), ","),
+		Active:     s.active,
+		CreatedAt:  s.createdAt,
+	}
+}
+
+// Subscribes reports whether the subscription wants to receive the event type
+func (s *WebhookSubscription) Subscribes(eventType enum.EventType) bool {
+	if !s.active {
+		return false
+	}
+	for _, subscribed := range s.eventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *WebhookSubscription) SetID(id uint) {
+	s.id = id
+}
+
+func (s *WebhookSubscription) GetID() uint {
+	return s.id
+}
+
+func (s *WebhookSubscription) URL() string {
+	return s.url
+}
+
+func (s *WebhookSubscription) Secret() string {
+	return s.secret
+}
+
+func (s *WebhookSubscription) Active() bool {
+	return s.active
+}
+
+func (s *WebhookSubscription) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+// EventTypeNames returns the subscribed event types as strings
+func (s *WebhookSubscription) EventTypeNames() []string {
+	names := make([]string, len(s.eventTypes))
+	for i, eventType := range s.eventTypes {
+		names[i] = eventType.String()
+	}
+	return names
+}
+
+type WebhookDelivery struct {
+	id             uint
+	subscriptionID uint
+	subscription   *WebhookSubscription
+	eventID        string
+	eventType      string
+	payload        string
+	status         enum.WebhookDeliveryStatus
+	attempts       int
+	nextAttemptAt  time.Time
+	responseStatus *int
+	lastError      *string
+	deliveredAt    *time.Time
+	createdAt      time.Time
+}
+
+// CreateWebhookDelivery initializes a pending delivery of the event to the subscription
+func CreateWebhookDelivery(subscription *WebhookSubscription, event *Event) (*WebhookDelivery, error) {
+	payload, err := event.MarshalPayload()
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookDelivery{
+		subscriptionID: subscription.GetID(),
+		subscription:   subscription,
+		eventID:        event.GetID(),
+		eventType:      event.Type().String(),
+		payload:        string(payload),
+		status:         enum.WebhookDeliveryStatusPending,
+		nextAttemptAt:  time.Now(),
+		createdAt:      time.Now(),
+	}, nil
+}
+
+// MakeWebhookDelivery converts a model.WebhookDelivery to an entity.WebhookDelivery
+func MakeWebhookDelivery(m *model.WebhookDelivery) (*WebhookDelivery, error) {
+	status, err := enum.ParseWebhookDeliveryStatus(m.Status)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid webhook delivery %d", m.ID)
+	}
+
+	delivery := &WebhookDelivery{
+		id:             m.ID,
+		subscriptionID: m.SubscriptionID,
+		eventID:        m.EventID,
+		eventType:      m.EventType,
+		payload:        m.Payload,
+		status:         status,
+		attempts:       m.Attempts,
+		nextAttemptAt:  m.NextAttemptAt,
+		responseStatus: m.ResponseStatus,
+		lastError:      m.LastError,
+		deliveredAt:    m.DeliveredAt,
+		createdAt:      m.CreatedAt,
+	}
+
+	if m.Subscription.ID != 0 {
+		subscription, err := MakeWebhookSubscription(&m.Subscription)
+		if err != nil {
+			return nil, err
+		}
+		delivery.subscription = subscription
+	}
+
+	return delivery, nil
+}
+
+// ToModel converts an entity.WebhookDelivery to a model.WebhookDelivery
+func (d *WebhookDelivery) ToModel() *model.WebhookDelivery {
+	return &model.WebhookDelivery{
+		ID:             d.id,
+		SubscriptionID: d.subscriptionID,
+		EventID:        d.eventID,
+		EventType:      d.eventType,
+		Payload:        d.payload,
+		Status:         d.status.String(),
+		Attempts:       d.attempts,
+		NextAttemptAt:  d.nextAttemptAt,
+		ResponseStatus: d.responseStatus,
+		LastError:      d.lastError,
+		DeliveredAt:    d.deliveredAt,
+		CreatedAt:      d.createdAt,
+	}
+}
+
+// MarkDelivered records a successful attempt
+func (d *WebhookDelivery) MarkDelivered(responseStatus int, now time.Time) {
+	d.attempts++
+	d.status = enum.WebhookDeliveryStatusDelivered
+	d.responseStatus = &responseStatus
+	d.lastError = nil
+	d.deliveredAt = &now
+}
+
+// MarkAttemptFailed records a failed attempt and schedules the next one with exponential backoff.
+// The delivery is marked as failed once MaxWebhookAttempts attempts were made.
+func (d *WebhookDelivery) MarkAttemptFailed(responseStatus *int, attemptErr error, now time.Time) {
+	d.attempts++
+	d.responseStatus = responseStatus
+	message := attemptErr.Error()
+	d.lastError = &message
+
+	if d.attempts >= MaxWebhookAttempts {
+		d.status = enum.WebhookDeliveryStatusFailed
+		return
+	}
+
+	backoff := webhookRetryBase << (d.attempts - 1)
+	if backoff > webhookRetryMax {
+		backoff = webhookRetryMax
+	}
+	d.nextAttemptAt = now.Add(backoff)
+}
+
+func (d *WebhookDelivery) SetID(id uint) {
+	d.id = id
+}
+
+func (d *WebhookDelivery) GetID() uint {
+	return d.id
+}
+
+func (d *WebhookDelivery) SubscriptionID() uint {
+	return d.subscriptionID
+}
+
+// Subscription returns the target subscription, nil when it was not loaded
+func (d *WebhookDelivery) Subscription() *WebhookSubscription {
+	return d.subscription
+}
+
+func (d *WebhookDelivery) EventID() string {
+	return d.eventID
+}
+
+func (d *WebhookDelivery) EventType() string {
+	return d.eventType
+}
+
+func (d *WebhookDelivery) Payload() string {
+	return d.payload
+}
+
+func (d *WebhookDelivery) Status() string {
+	return d.status.String()
+}
+
+func (d *WebhookDelivery) Attempts() int {
+	return d.attempts
+}
+
+func (d *WebhookDelivery) NextAttemptAt() time.Time {
+	return d.nextAttemptAt
+}
+
+func (d *WebhookDelivery) ResponseStatus() *int {
+	return d.responseStatus
+}
+
+func (d *WebhookDelivery) LastError() *string {
+	return d.lastError
+}
+
+func (d *WebhookDelivery) DeliveredAt() *time.Time {
+	return d.deliveredAt
+}
+
+func (d *WebhookDelivery) CreatedAt() time.Time {
+	return d.createdAt
+}
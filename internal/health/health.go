@@ -0,0 +1,129 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// checkTimeout bounds how long a single readiness check may take
+const checkTimeout = 2 * time.Second
+
+// Check reports why a dependency of the service is not ready, nil when it is
+type Check func(ctx context.Context) error
+
+// Report is the outcome of the readiness checks, by check name
+type Report struct {
+	Ready  bool
+	Checks map[string]error
+}
+
+// Checker decides whether the service is ready to receive traffic. It is ready when every registered check
+// passes and it is not shutting down.
+type Checker struct {
+	mu           sync.RWMutex
+	checks       map[string]Check
+	shuttingDown atomic.Bool
+}
+
+func NewChecker() *Checker {
+	return &Checker{
+		checks: make(map[string]Check),
+	}
+}
+
+// AddCheck registers a readiness check, replacing the check with the same name
+func (c *Checker) AddCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// SetShuttingDown makes the service report not ready from now on, so the orchestrator stops routing to it
+// while in-flight requests finish
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Check runs every check and reports whether the service is ready
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	checks := c.checks
+	c.mu.RUnlock()
+	sort.Strings(names)
+
+	report := Report{Ready: true, Checks: make(map[string]error, len(names)+1)}
+	if c.shuttingDown.Load() {
+		report.Ready = false
+		report.Checks["shutdown"] = errors.New("service is shutting down")
+	}
+
+	for _, name := range names {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := checks[name](checkCtx)
+		cancel()
+
+		report.Checks[name] = err
+		if err != nil {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+// DatabaseCheck passes when the database answers a ping
+func DatabaseCheck(sqlDB *sql.DB) Check {
+	return func(ctx context.Context) error {
+		if err := sqlDB.PingContext(ctx); err != nil {
+			return errors.Wrap(err, "database is not reachable")
+		}
+		return nil
+	}
+}
+
+// SchemaCheck passes when the newest migration applied to the database is the expected one
+func SchemaCheck(sqlDB *sql.DB, expectedVersion string) Check {
+	return func(ctx context.Context) error {
+		version, err := SchemaVersion(ctx, sqlDB)
+		if err != nil {
+			return err
+		}
+		if version != expectedVersion {
+			return fmt.Errorf("schema is at version %q, expected %q", version, expectedVersion)
+		}
+		return nil
+	}
+}
+
+// SchemaVersion returns the version of the newest migration applied to the database, from the schema_migration table
+func SchemaVersion(ctx context.Context, sqlDB *sql.DB) (string, error) {
+	var version sql.NullString
+	if err := sqlDB.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migration").Scan(&version); err != nil {
+		return "", errors.Wrap(err, "failed to read schema version")
+	}
+	return version.String, nil
+}
+
+// SchedulerCheck passes while the scheduler of the daily jobs is running and the catch-up of the business dates
+// missed before it started did not fail. The catch-up still running does not fail the check.
+func SchedulerCheck(running func() bool, catchUpErr func() error) Check {
+	return func(ctx context.Context) error {
+		if !running() {
+			return errors.New("scheduler is not running")
+		}
+		if err := catchUpErr(); err != nil {
+			return errors.Wrap(err, "catch-up of missed business dates failed")
+		}
+		return nil
+	}
+}
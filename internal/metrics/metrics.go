@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"billing_enginee/internal/tenant"
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// namespace prefixes every metric of the service
+const namespace = "billing"
+
+// JobUpdatePaymentStatus is the job label of the daily installment status update
+const JobUpdatePaymentStatus = "update_payment_status"
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the HTTP requests by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	jobRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_run_duration_seconds",
+		Help:      "Duration of the scheduled job runs by job, tenant and result.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 60, 300, 900},
+	}, []string{"job", "tenant", "result"})
+
+	jobItemsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_items_processed_total",
+		Help:      "Items processed by the scheduled jobs.",
+	}, []string{"job", "tenant"})
+
+	installmentsTurnedPending = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "installments_turned_pending",
+		Help:      "Installments that turned pending in the last run of the installment status update.",
+	}, []string{"tenant"})
+
+	paymentsReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payments_received_total",
+		Help:      "Loan payments received.",
+	}, []string{"tenant"})
+
+	paymentsReceivedAmount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payments_received_amount_total",
+		Help:      "Amount of the loan payments received.",
+	}, []string{"tenant"})
+)
+
+// NewRegistry returns a registry with the metrics of the service, the Go runtime, the pool of sqlDB
+// and the portfolio of every tenant, read from portfolio when the registry is scraped
+func NewRegistry(sqlDB *sql.DB, portfolio PortfolioSource, tenants tenant.Registry) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(sqlDB, namespace),
+		httpRequestDuration,
+		jobRunDuration,
+		jobItemsProcessed,
+		installmentsTurnedPending,
+		paymentsReceived,
+		paymentsReceivedAmount,
+		newPortfolioCollector(portfolio, tenants),
+	)
+	return registry
+}
+
+// ObserveHTTPRequest records the latency of an answered HTTP request
+func ObserveHTTPRequest(method string, route string, status string, duration time.Duration) {
+	httpRequestDuration.WithLabelValues(method, route, status).Observe(duration.Seconds())
+}
+
+// ObserveJobRun records the duration of a job run that started at start and ended with err
+func ObserveJobRun(job string, tenantID string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	jobRunDuration.WithLabelValues(job, tenantID, result).Observe(time.Since(start).Seconds())
+}
+
+// AddJobItemsProcessed counts the items a job run processed for the tenant
+func AddJobItemsProcessed(job string, tenantID string, items int) {
+	jobItemsProcessed.WithLabelValues(job, tenantID).Add(float64(items))
+}
+
+// SetInstallmentsTurnedPending records how many installments of the tenant turned pending in the last run
+func SetInstallmentsTurnedPending(tenantID string, installments int) {
+	installmentsTurnedPending.WithLabelValues(tenantID).Set(float64(installments))
+}
+
+// AddPaymentReceived counts a loan payment of the tenant and its amount
+func AddPaymentReceived(tenantID string, amount float64) {
+	paymentsReceived.WithLabelValues(tenantID).Inc()
+	paymentsReceivedAmount.WithLabelValues(tenantID).Add(amount)
+}
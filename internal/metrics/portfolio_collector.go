@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"billing_enginee/internal/tenant"
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// scrapeTimeout bounds how long reading the portfolio of all tenants may take on a scrape
+const scrapeTimeout = 5 * time.Second
+
+// Portfolio is the state of the loans of a tenant exposed as gauges
+type Portfolio struct {
+	OpenLoans   int64
+	Outstanding float64
+}
+
+// PortfolioSource reads the portfolio of a tenant
+type PortfolioSource func(ctx context.Context, tenantID string) (*Portfolio, error)
+
+// portfolioCollector reads the portfolio of every tenant when it is scraped, so the gauges always match the database
+type portfolioCollector struct {
+	source      PortfolioSource
+	tenants     tenant.Registry
+	openLoans   *prometheus.Desc
+	outstanding *prometheus.Desc
+}
+
+func newPortfolioCollector(source PortfolioSource, tenants tenant.Registry) prometheus.Collector {
+	return &portfolioCollector{
+		source:  source,
+		tenants: tenants,
+		openLoans: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "open_loans"),
+			"Loans that are still open.",
+			[]string{"tenant"}, nil,
+		),
+		outstanding: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "outstanding_amount"),
+			"Amount of the installments that are not paid yet.",
+			[]string{"tenant"}, nil,
+		),
+	}
+}
+
+func (c *portfolioCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.openLoans
+	ch <- c.outstanding
+}
+
+// Collect skips the tenants whose portfolio cannot be read, the other metrics of the scrape are still served
+func (c *portfolioCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	for _, t := range c.tenants.All() {
+		portfolio, err := c.source(ctx, t.ID)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"tenantID": t.ID,
+				"error":    err,
+			}).Error("Failed to read portfolio for metrics")
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.openLoans, prometheus.GaugeValue, float64(portfolio.OpenLoans), t.ID)
+		ch <- prometheus.MustNewConstMetric(c.outstanding, prometheus.GaugeValue, portfolio.Outstanding, t.ID)
+	}
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+)
+
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	TenantID   string    `gorm:"type:varchar(32);not null;default:'default';index"` // Set from the tenant of the session
+	EntityType string    `gorm:"type:varchar(32);not null;index:idx_audit_logs_entity"`
+	EntityID   uint      `gorm:"not null;index:idx_audit_logs_entity"`
+	Action     string    `gorm:"type:varchar(16);not null"`
+	Actor      string    `gorm:"type:varchar(128);not null"`
+	RequestID  *string   `gorm:"type:varchar(64)"`
+	Before     *string   `gorm:"type:text"` // JSON snapshot of the changed fields before the write
+	After      *string   `gorm:"type:text"` // JSON snapshot of the changed fields after the write
+	Reason     string    `gorm:"type:varchar(255);not null"`
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
@@ -3,10 +3,13 @@ package model
 import "time"
 
 type Customer struct {
-	ID        uint    `gorm:"primaryKey;autoIncrement"`
-	Name      string  `gorm:"type:varchar(100);not null"`
-	Email     string  `gorm:"type:varchar(100);unique;not null"`
-	Loans     *[]Loan `gorm:"foreignKey:CustomerID;constraint:OnDelete:CASCADE"` // Add the Loans field
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID         uint    `gorm:"primaryKey;autoIncrement"`
+	TenantID   string  `gorm:"type:varchar(32);not null;default:'default';uniqueIndex:idx_customers_tenant_email,priority:1;uniqueIndex:idx_customers_tenant_external_id,priority:1"` // Set from the tenant of the session
+	ExternalID uint    `gorm:"not null;uniqueIndex:idx_customers_tenant_external_id,priority:2"`                                                                                      // The customer ID given by the tenant, unique per tenant
+	Name       string  `gorm:"type:varchar(100);not null"`
+	Email      string  `gorm:"type:varchar(100);not null;uniqueIndex:idx_customers_tenant_email,priority:2"` // Unique per tenant
+	Phone      string  `gorm:"type:varchar(20)"`
+	Loans      *[]Loan `gorm:"foreignKey:CustomerID;constraint:OnDelete:CASCADE"` // Add the Loans field
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// JobRun records one run of a daily job for a tenant and business date
+type JobRun struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement"`
+	TenantID       string    `gorm:"type:varchar(32);not null;default:'default';index:idx_job_runs_tenant_job_date,priority:1"` // Set from the tenant of the session
+	Job            string    `gorm:"type:varchar(64);not null;index:idx_job_runs_tenant_job_date,priority:2"`
+	BusinessDate   time.Time `gorm:"type:date;not null;index:idx_job_runs_tenant_job_date,priority:3"` // Midnight UTC of the business date of the tenant
+	TriggeredBy    string    `gorm:"type:varchar(16);not null"`
+	Status         string    `gorm:"type:job_run_status;not null;default:'running'"` // Enum for status
+	ItemsProcessed int       `gorm:"not null;default:0"`
+	ItemsFailed    int       `gorm:"not null;default:0"`
+	Error          *string   `gorm:"type:text"`
+	StartedAt      time.Time `gorm:"not null"`
+	FinishedAt     *time.Time
+	CreatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
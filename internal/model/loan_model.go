@@ -6,6 +6,7 @@ import (
 
 type Loan struct {
 	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	TenantID    string    `gorm:"type:varchar(32);not null;default:'default';index"` // Set from the tenant of the session
 	CustomerID  uint      `gorm:"not null"`
 	Customer    Customer  `gorm:"foreignKey:CustomerID;references:ID"`
 	Amount      float64   `gorm:"type:numeric(12,2);not null"`
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// Notification is a message to a customer about an installment, queued with the change it is about and sent afterwards
+type Notification struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	TenantID      string    `gorm:"type:varchar(32);not null;default:'default';index"` // Set from the tenant of the session
+	PaymentID     uint      `gorm:"not null;uniqueIndex:idx_notifications_payment_template,priority:1"`
+	Template      string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_notifications_payment_template,priority:2"`
+	DaysUntilDue  int       `gorm:"not null;default:0"`
+	Status        string    `gorm:"type:notification_status;default:'pending';index:idx_notifications_due,priority:1"` // Enum for status
+	Attempts      int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"not null;index:idx_notifications_due,priority:2"`
+	LastError     *string   `gorm:"type:text"`
+	SentAt        *time.Time
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+}
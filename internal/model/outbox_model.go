@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+type OutboxEvent struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement"`
+	TenantID      string     `gorm:"type:varchar(32);not null;default:'default'"` // Set from the tenant of the session, the relay reads every tenant
+	EventID       string     `gorm:"type:varchar(36);not null;uniqueIndex"`
+	AggregateType string     `gorm:"type:varchar(32);not null"`
+	AggregateID   uint       `gorm:"not null"`
+	EventType     string     `gorm:"type:varchar(64);not null"`
+	Payload       string     `gorm:"type:text;not null"`
+	Attempts      int        `gorm:"not null;default:0"`
+	LastError     *string    `gorm:"type:text"`
+	NextAttemptAt *time.Time // Unset until a publish attempt failed
+	PublishedAt   *time.Time `gorm:"index"`
+	FailedAt      *time.Time // Set when the relay gave up on the message
+	CreatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP"`
+}
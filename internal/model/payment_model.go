@@ -5,13 +5,15 @@ import (
 )
 
 type Payment struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement"`
-	LoanID    uint      `gorm:"not null"`
-	Loan      Loan      `gorm:"foreignKey:LoanID;references:ID"` // Foreign key to Loan
-	Week      int       `gorm:"not null"`
-	Amount    float64   `gorm:"type:numeric(12,2);not null"`
-	DueDate   time.Time `gorm:"type:date;not null"`
-	Status    string    `gorm:"type:payment_status;default:'scheduled';index"` // Enum for status, with index
-	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	TenantID  string     `gorm:"type:varchar(32);not null;default:'default';index"` // Set from the tenant of the session
+	LoanID    uint       `gorm:"not null"`
+	Loan      Loan       `gorm:"foreignKey:LoanID;references:ID"` // Foreign key to Loan
+	Week      int        `gorm:"not null"`
+	Amount    float64    `gorm:"type:numeric(12,2);not null"`
+	DueDate   time.Time  `gorm:"type:date;not null"`
+	Status    string     `gorm:"type:payment_status;default:'scheduled';index"` // Enum for status, with index
+	PaidAt    *time.Time `gorm:"index"`                                         // Set when the installment is marked as paid
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime"`
 }
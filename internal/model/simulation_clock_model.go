@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+)
+
+// SimulationClock is the single row of the simulated clock, shared by the tenants
+type SimulationClock struct {
+	ID            uint  `gorm:"primaryKey;autoIncrement:false"`
+	OffsetSeconds int64 `gorm:"not null;default:0"`
+	UpdatedAt     time.Time
+}
+
+// TableName keeps the singular name of the table, it holds one clock
+func (SimulationClock) TableName() string {
+	return "simulation_clock"
+}
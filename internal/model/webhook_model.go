@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+)
+
+type WebhookSubscription struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	TenantID   string    `gorm:"type:varchar(32);not null;default:'default';index"` // Set from the tenant of the session
+	URL        string    `gorm:"type:varchar(2048);not null"`
+	Secret     string    `gorm:"type:varchar(255);not null"`
+	EventTypes string    `gorm:"type:text;not null"` // Comma separated list of event types
+	Active     bool      `gorm:"not null;default:true"`
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+}
+
+type WebhookDelivery struct {
+	ID             uint                `gorm:"primaryKey;autoIncrement"`
+	TenantID       string              `gorm:"type:varchar(32);not null;default:'default';index"` // Set from the tenant of the session
+	SubscriptionID uint                `gorm:"not null;index"`
+	Subscription   WebhookSubscription `gorm:"foreignKey:SubscriptionID;references:ID;constraint:OnDelete:CASCADE"`
+	EventID        string              `gorm:"type:varchar(36);not null;index"`
+	EventType      string              `gorm:"type:varchar(64);not null"`
+	Payload        string              `gorm:"type:text;not null"`
+	Status         string              `gorm:"type:webhook_delivery_status;default:'pending';index:idx_webhook_delivery_due,priority:1"` // Enum for status
+	Attempts       int                 `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time           `gorm:"not null;index:idx_webhook_delivery_due,priority:2"`
+	ResponseStatus *int
+	LastError      *string `gorm:"type:text"`
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,30 @@
+package notification
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoAddress is returned by a channel when the recipient has no address for it (e.g. no phone for SMS)
+var ErrNoAddress = errors.New("recipient has no address for this channel")
+
+// Recipient identifies who a message is sent to, each channel picks the address it needs
+type Recipient struct {
+	Name  string
+	Email string
+	Phone string
+}
+
+// Message is a rendered notification ready to be delivered
+type Message struct {
+	Recipient Recipient
+	Subject   string
+	Body      string
+}
+
+// Channel delivers messages through a single medium such as email or SMS. Send gives up when the context
+// is done or the provider does not answer in time.
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,69 @@
+package notification
+
+import (
+	"billing_enginee/pkg"
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MemoryChannel keeps sent messages in memory, it replaces real channels in tests
+type MemoryChannel struct {
+	name     string
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryChannel(name string) *MemoryChannel {
+	return &MemoryChannel{
+		name: name,
+	}
+}
+
+func (ch *MemoryChannel) Name() string {
+	return ch.name
+}
+
+func (ch *MemoryChannel) Send(ctx context.Context, msg Message) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.messages = append(ch.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of the messages sent so far
+func (ch *MemoryChannel) Messages() []Message {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	return append([]Message(nil), ch.messages...)
+}
+
+// Reset forgets all the messages sent so far
+func (ch *MemoryChannel) Reset() {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.messages = nil
+}
+
+type logChannel struct{}
+
+// NewLogChannel creates a channel that only writes messages to the log, for local runs without providers
+func NewLogChannel() Channel {
+	return &logChannel{}
+}
+
+func (ch *logChannel) Name() string {
+	return "log"
+}
+
+func (ch *logChannel) Send(ctx context.Context, msg Message) error {
+	pkg.Logger(ctx).WithFields(log.Fields{
+		"recipient": msg.Recipient.Name,
+		"email":     msg.Recipient.Email,
+		"phone":     msg.Recipient.Phone,
+		"subject":   msg.Subject,
+		"body":      msg.Body,
+	}).Info("Notification sent to log channel")
+	return nil
+}
@@ -0,0 +1,85 @@
+package notification
+
+import (
+	"billing_enginee/pkg"
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Notifier renders templates and delivers the result through every configured channel
+type Notifier interface {
+	Notify(ctx context.Context, name TemplateName, recipient Recipient, data interface{}) error
+}
+
+type notifier struct {
+	channels []Channel
+}
+
+func NewNotifier(channels ...Channel) Notifier {
+	return &notifier{
+		channels: channels,
+	}
+}
+
+// Notify sends the message through all channels, a failing channel does not prevent the others from sending
+func (n *notifier) Notify(ctx context.Context, name TemplateName, recipient Recipient, data interface{}) error {
+	msg, err := Render(name, recipient, data)
+	if err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"template": name,
+			"error":    err,
+		}).Error("Failed to render notification")
+		return err
+	}
+
+	var failed []string
+	for _, channel := range n.channels {
+		if err := channel.Send(ctx, msg); err != nil {
+			if errors.Is(err, ErrNoAddress) {
+				pkg.Logger(ctx).WithFields(log.Fields{
+					"channel":   channel.Name(),
+					"recipient": recipient.Name,
+				}).Debug("Recipient has no address for channel, skipping")
+				continue
+			}
+			pkg.Logger(ctx).WithFields(log.Fields{
+				"channel":  channel.Name(),
+				"template": name,
+				"error":    err,
+			}).Error("Failed to deliver notification")
+			failed = append(failed, channel.Name())
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.New("failed to deliver notification through: " + strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// NewChannels builds the named channels (email, sms, log).
+// The log channel is used when nothing is configured so local runs do not need any provider.
+func NewChannels(names []string, smtpConfig SMTPConfig, smsConfig SMSConfig) []Channel {
+	var channels []Channel
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case "email":
+			channels = append(channels, NewSMTPChannel(smtpConfig))
+		case "sms":
+			channels = append(channels, NewSMSChannel(smsConfig))
+		case "log":
+			channels = append(channels, NewLogChannel())
+		case "":
+		default:
+			log.WithField("channel", name).Warn("Unknown notification channel, ignoring")
+		}
+	}
+
+	if len(channels) == 0 {
+		channels = append(channels, NewLogChannel())
+	}
+	return channels
+}
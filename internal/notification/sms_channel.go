@@ -0,0 +1,81 @@
+package notification
+
+import (
+	"billing_enginee/pkg"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// SMSConfig holds the settings of the HTTP SMS provider
+type SMSConfig struct {
+	URL    string
+	APIKey string
+	Sender string
+}
+
+type smsChannel struct {
+	config SMSConfig
+	client *http.Client
+}
+
+// NewSMSChannel creates a channel that sends SMS through an HTTP provider.
+// The provider receives a JSON body with "from", "to" and "message" fields and a bearer API key.
+func NewSMSChannel(config SMSConfig) Channel {
+	return &smsChannel{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (ch *smsChannel) Name() string {
+	return "sms"
+}
+
+func (ch *smsChannel) Send(ctx context.Context, msg Message) error {
+	if msg.Recipient.Phone == "" {
+		return ErrNoAddress
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"from":    ch.config.Sender,
+		"to":      msg.Recipient.Phone,
+		"message": msg.Body,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode sms payload")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ch.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to build sms request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+ch.config.APIKey)
+
+	resp, err := ch.client.Do(req)
+	if err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"to":    msg.Recipient.Phone,
+			"error": err,
+		}).Error("Failed to send sms")
+		return errors.Wrap(err, "failed to send sms")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"to":     msg.Recipient.Phone,
+			"status": resp.StatusCode,
+		}).Error("SMS provider rejected the message")
+		return fmt.Errorf("sms provider responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
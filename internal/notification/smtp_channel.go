@@ -0,0 +1,129 @@
+package notification
+
+import (
+	"billing_enginee/pkg"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// SMTPConfig holds the settings of the SMTP server used to send emails
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	// Timeout bounds the whole exchange with the server, from dialing to the end of the message
+	Timeout time.Duration
+}
+
+type smtpChannel struct {
+	config SMTPConfig
+}
+
+// NewSMTPChannel creates a channel that sends emails through an SMTP server
+func NewSMTPChannel(config SMTPConfig) Channel {
+	return &smtpChannel{
+		config: config,
+	}
+}
+
+func (ch *smtpChannel) Name() string {
+	return "email"
+}
+
+func (ch *smtpChannel) Send(ctx context.Context, msg Message) error {
+	if msg.Recipient.Email == "" {
+		return ErrNoAddress
+	}
+
+	if err := ch.sendMail(ctx, msg.Recipient.Email, ch.buildMessage(msg)); err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"to":    msg.Recipient.Email,
+			"error": err,
+		}).Error("Failed to send email")
+		return errors.Wrap(err, "failed to send email")
+	}
+
+	return nil
+}
+
+// sendMail is smtp.SendMail on a connection that gives up once the timeout or the deadline of the context
+// is reached, so a server that stops answering cannot hold the caller
+func (ch *smtpChannel) sendMail(ctx context.Context, to string, body []byte) error {
+	deadline := time.Now().Add(ch.config.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ch.config.Host, ch.config.Port))
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to the SMTP server")
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return errors.Wrap(err, "failed to set the SMTP connection deadline")
+	}
+	// Canceling the context interrupts the exchange like the deadline does
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, ch.config.Host)
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "failed to greet the SMTP server")
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: ch.config.Host}); err != nil {
+			return errors.Wrap(err, "failed to start TLS")
+		}
+	}
+	if ch.config.Username != "" {
+		auth := smtp.PlainAuth("", ch.config.Username, ch.config.Password, ch.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return errors.Wrap(err, "failed to authenticate")
+		}
+	}
+
+	if err := client.Mail(ch.config.From); err != nil {
+		return errors.Wrap(err, "sender rejected")
+	}
+	if err := client.Rcpt(to); err != nil {
+		return errors.Wrap(err, "recipient rejected")
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return errors.Wrap(err, "message rejected")
+	}
+	if _, err := writer.Write(body); err != nil {
+		return errors.Wrap(err, "failed to write the message")
+	}
+	if err := writer.Close(); err != nil {
+		return errors.Wrap(err, "message rejected")
+	}
+	return client.Quit()
+}
+
+// buildMessage formats the message as a plain text RFC 5322 email
+func (ch *smtpChannel) buildMessage(msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", ch.config.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.Recipient.Email)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	return []byte(b.String())
+}
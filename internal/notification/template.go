@@ -0,0 +1,84 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TemplateName identifies a message template
+type TemplateName string
+
+const (
+	TemplateInstallmentReminder TemplateName = "installment_reminder"
+	TemplateInstallmentPending  TemplateName = "installment_pending"
+)
+
+// InstallmentNotice is the data rendered into installment templates
+type InstallmentNotice struct {
+	CustomerName string
+	LoanID       uint
+	Week         int
+	Amount       float64
+	DueDate      time.Time
+	DaysUntilDue int
+}
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"money": func(amount float64) string { return fmt.Sprintf("%.2f", amount) },
+	"date":  func(t time.Time) string { return t.Format("2006-01-02") },
+}
+
+var defaultTemplates = map[TemplateName]messageTemplate{
+	TemplateInstallmentReminder: mustParseTemplate(
+		"Installment {{.Week}} of loan {{.LoanID}} is due on {{date .DueDate}}",
+		"Hi {{.CustomerName}},\n\n"+
+			"This is a reminder that installment {{.Week}} of your loan {{.LoanID}} amounting to {{money .Amount}} "+
+			"is due in {{.DaysUntilDue}} day(s), on {{date .DueDate}}.\n\n"+
+			"Please make sure the payment is made on time.\n",
+	),
+	TemplateInstallmentPending: mustParseTemplate(
+		"Installment {{.Week}} of loan {{.LoanID}} is overdue",
+		"Hi {{.CustomerName}},\n\n"+
+			"Installment {{.Week}} of your loan {{.LoanID}} amounting to {{money .Amount}} was due on {{date .DueDate}} "+
+			"and has not been paid yet.\n\n"+
+			"Please pay the outstanding amount as soon as possible to avoid being marked as delinquent.\n",
+	),
+}
+
+func mustParseTemplate(subject string, body string) messageTemplate {
+	return messageTemplate{
+		subject: template.Must(template.New("subject").Funcs(templateFuncs).Parse(subject)),
+		body:    template.Must(template.New("body").Funcs(templateFuncs).Parse(body)),
+	}
+}
+
+// Render builds the message for the recipient from the named template
+func Render(name TemplateName, recipient Recipient, data interface{}) (Message, error) {
+	tmpl, ok := defaultTemplates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown notification template: %s", name)
+	}
+
+	var subject, body strings.Builder
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return Message{}, errors.Wrapf(err, "failed to render subject of template %s", name)
+	}
+	if err := tmpl.body.Execute(&body, data); err != nil {
+		return Message{}, errors.Wrapf(err, "failed to render body of template %s", name)
+	}
+
+	return Message{
+		Recipient: recipient,
+		Subject:   subject.String(),
+		Body:      body.String(),
+	}, nil
+}
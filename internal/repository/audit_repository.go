@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/model"
+	"billing_enginee/pkg"
+	"context"
+
+	"github.com/pkg/errors" // Use the correct errors package
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// AuditRepository reads the audit log. Entries are only ever written by the other repositories,
+// through recordAudit, in the same transaction as the write they describe.
+type AuditRepository interface {
+	GetEntries(ctx context.Context, entityType enum.AuditEntityType, entityID uint) ([]*entity.AuditEntry, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{
+		db: db,
+	}
+}
+
+// GetEntries returns the audit trail of an entity, oldest first
+func (r *auditRepository) GetEntries(ctx context.Context, entityType enum.AuditEntityType, entityID uint) ([]*entity.AuditEntry, error) {
+	var auditModels []model.AuditLog
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Where("entity_type = ? AND entity_id = ?", entityType.String(), entityID).
+		Order("id ASC").
+		Find(&auditModels).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"entityType": entityType.String(),
+			"entityID":   entityID,
+			"error":      err,
+		}).Error("Failed to retrieve audit entries")
+		return nil, errors.Wrap(err, "failed to retrieve audit entries")
+	}
+
+	entries := make([]*entity.AuditEntry, len(auditModels))
+	for i := range auditModels {
+		entry, err := entity.MakeAuditEntry(&auditModels[i])
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = entry
+	}
+	return entries, nil
+}
+
+// auditChange describes one write to be recorded in the audit log
+type auditChange struct {
+	entityType enum.AuditEntityType
+	entityID   uint
+	action     enum.AuditAction
+	before     map[string]interface{}
+	after      map[string]interface{}
+	reason     string
+}
+
+// recordAudit appends the changes to the audit log with the actor and request ID of the request
+func recordAudit(ctx context.Context, tx *gorm.DB, changes ...auditChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	actor := pkg.GetActor(ctx)
+	requestID := pkg.GetRequestID(ctx)
+	auditModels := make([]model.AuditLog, len(changes))
+	for i, change := range changes {
+		entry := entity.CreateAuditEntry(change.entityType, change.entityID, change.action, actor, requestID, change.before, change.after, change.reason)
+		auditModel, err := entry.ToModel()
+		if err != nil {
+			return err
+		}
+		auditModels[i] = *auditModel
+	}
+
+	if err := tx.Create(&auditModels).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"actor":     actor,
+			"requestID": requestID,
+			"error":     err,
+		}).Error("Failed to write audit log")
+		return errors.Wrap(err, "failed to write audit log")
+	}
+	return nil
+}
+
+func customerSnapshot(m *model.Customer) map[string]interface{} {
+	return map[string]interface{}{
+		"external_id": m.ExternalID,
+		"name":        m.Name,
+		"email":       m.Email,
+		"phone":       m.Phone,
+	}
+}
+
+func loanSnapshot(m *model.Loan) map[string]interface{} {
+	return map[string]interface{}{
+		"customer_id":  m.CustomerID,
+		"amount":       m.Amount,
+		"total_amount": m.TotalAmount,
+		"status":       m.Status,
+		"term_weeks":   m.TermWeeks,
+		"rates":        m.Rates,
+	}
+}
+
+func paymentSnapshot(m *model.Payment) map[string]interface{} {
+	return map[string]interface{}{
+		"loan_id":  m.LoanID,
+		"week":     m.Week,
+		"amount":   m.Amount,
+		"due_date": m.DueDate.Format("2006-01-02"),
+		"status":   m.Status,
+	}
+}
+
+// webhookSubscriptionSnapshot leaves out the secret, the audit log must not leak it
+func webhookSubscriptionSnapshot(m *model.WebhookSubscription) map[string]interface{} {
+	return map[string]interface{}{
+		"url":         m.URL,
+		"event_types": m.EventTypes,
+		"active":      m.Active,
+	}
+}
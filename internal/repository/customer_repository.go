@@ -2,17 +2,20 @@ package repository
 
 import (
 	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
 	"billing_enginee/internal/model"
+	"billing_enginee/pkg"
+	"context"
 	"errors"
 
-	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type CustomerRepository interface {
-	SaveCustomer(c *gin.Context, customer *entity.Customer) error
-	GetCustomerByID(c *gin.Context, customerID uint) (*entity.Customer, error)
+	SaveCustomer(ctx context.Context, customer *entity.Customer) error
+	// GetCustomerByExternalID returns the customer the tenant of the context gave the ID, with its loans and their installments
+	GetCustomerByExternalID(ctx context.Context, externalID uint) (*entity.Customer, error)
 }
 
 type customerRepository struct {
@@ -25,34 +28,44 @@ func NewCustomerRepository(db *gorm.DB) CustomerRepository {
 	}
 }
 
-func (r *customerRepository) SaveCustomer(c *gin.Context, customer *entity.Customer) error {
+func (r *customerRepository) SaveCustomer(ctx context.Context, customer *entity.Customer) error {
 	customerModel := customer.ToModel()
-	tx := GetDB(c, r.db)
+	tx := GetDB(ctx, r.db)
 
 	if err := tx.Create(&customerModel).Error; err != nil {
-		log.WithFields(log.Fields{
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"customer": customerModel,
 			"error":    err,
 		}).Error("Failed to save customer")
 		return errors.New("failed to save customer: " + err.Error())
 	}
 
+	if err := recordAudit(ctx, tx, auditChange{
+		entityType: enum.AuditEntityCustomer,
+		entityID:   customerModel.ID,
+		action:     enum.AuditActionCreate,
+		after:      customerSnapshot(customerModel),
+		reason:     "customer registered",
+	}); err != nil {
+		return err
+	}
+
 	customer.SetID(customerModel.ID)
 	return nil
 }
 
-func (r *customerRepository) GetCustomerByID(c *gin.Context, customerID uint) (*entity.Customer, error) {
-	tx := GetDB(c, r.db)
+func (r *customerRepository) GetCustomerByExternalID(ctx context.Context, externalID uint) (*entity.Customer, error) {
+	tx := GetDB(ctx, r.db)
 
 	var customerModel model.Customer
-	if err := tx.Preload("Loans.Payments").First(&customerModel, customerID).Error; err != nil {
+	if err := tx.Preload("Loans.Payments").Where("external_id = ?", externalID).First(&customerModel).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			log.WithField("customerID", customerID).Info("Customer not found")
-			return nil, gorm.ErrRecordNotFound
+			pkg.Logger(ctx).WithField("customerExternalID", externalID).Info("Customer not found")
+			return nil, entity.ErrCustomerNotFound
 		}
-		log.WithFields(log.Fields{
-			"customerID": customerID,
-			"error":      err,
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"customerExternalID": externalID,
+			"error":              err,
 		}).Error("Failed to retrieve customer")
 		return nil, errors.New("failed to retrieve customer: " + err.Error())
 	}
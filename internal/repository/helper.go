@@ -1,18 +1,37 @@
 package repository
 
 import (
-	"github.com/gin-gonic/gin"
+	"billing_enginee/pkg"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 )
 
-// Utility function to get transaction from context
-func GetDB(c *gin.Context, db *gorm.DB) *gorm.DB {
-	if c == nil {
-		return db
+// pgLockNotAvailable is the Postgres error code of a NOWAIT lock held by another transaction
+const pgLockNotAvailable = "55P03"
+
+// Utility function to get the session of a context: the transaction of its unit of work when there is one.
+// The returned session only sees and creates rows of the tenant of the context. Without a tenant, statements on
+// tenant rows fail with pkg.ErrNoTenant unless the context opted in to every tenant with pkg.WithAllTenants.
+func GetDB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	tx := db // Fallback to main db if no transaction found (for non-transactional operations)
+	if ctxTx, ok := pkg.TxFromContext(ctx); ok {
+		tx = ctxTx
 	}
-	tx, exists := c.Get("db_tx")
-	if exists {
-		return tx.(*gorm.DB)
+	tx = tx.WithContext(ctx)
+
+	tenantID := pkg.GetTenantID(ctx)
+	if tenantID == "" && pkg.AllTenants(ctx) {
+		return tx
 	}
-	return db // Fallback to main db if no transaction found (for non-transactional operations)
+	// A new session keeps the setting without leaking conditions between the queries made with it
+	return tx.Set(pkg.TenantSettingKey, tenantID).Session(&gorm.Session{})
+}
+
+// isLockNotAvailable reports whether the error comes from a row lock that could not be acquired without waiting
+func isLockNotAvailable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgLockNotAvailable
 }
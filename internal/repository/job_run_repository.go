@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/model"
+	"billing_enginee/pkg"
+	"context"
+	"time"
+
+	"github.com/pkg/errors" // Use the correct errors package
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// JobRunRepository stores the run history of the daily jobs, scoped to the tenant of the context
+type JobRunRepository interface {
+	SaveJobRun(ctx context.Context, run *entity.JobRun) error
+	UpdateJobRun(ctx context.Context, run *entity.JobRun) error
+	GetLastCompletedBusinessDate(ctx context.Context, job string) (*time.Time, error)
+	GetJobRuns(ctx context.Context, job string, limit int) ([]*entity.JobRun, error)
+}
+
+type jobRunRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRunRepository(db *gorm.DB) JobRunRepository {
+	return &jobRunRepository{
+		db: db,
+	}
+}
+
+func (r *jobRunRepository) SaveJobRun(ctx context.Context, run *entity.JobRun) error {
+	runModel := run.ToModel()
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Create(runModel).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"job":          runModel.Job,
+			"businessDate": runModel.BusinessDate.Format("2006-01-02"),
+			"error":        err,
+		}).Error("Failed to save job run")
+		return errors.Wrap(err, "failed to save job run")
+	}
+
+	run.SetID(runModel.ID)
+	return nil
+}
+
+// UpdateJobRun stores the outcome of the run
+func (r *jobRunRepository) UpdateJobRun(ctx context.Context, run *entity.JobRun) error {
+	runModel := run.ToModel()
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Model(&model.JobRun{}).Where("id = ?", runModel.ID).Updates(map[string]interface{}{
+		"status":          runModel.Status,
+		"items_processed": runModel.ItemsProcessed,
+		"items_failed":    runModel.ItemsFailed,
+		"error":           runModel.Error,
+		"finished_at":     runModel.FinishedAt,
+	}).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"jobRunID": runModel.ID,
+			"status":   runModel.Status,
+			"error":    err,
+		}).Error("Failed to update job run")
+		return errors.Wrap(err, "failed to update job run")
+	}
+	return nil
+}
+
+// GetLastCompletedBusinessDate returns the latest business date the job ran to its end for, even when some of its
+// items failed, nil when it never did
+func (r *jobRunRepository) GetLastCompletedBusinessDate(ctx context.Context, job string) (*time.Time, error) {
+	var runModel model.JobRun
+	tx := GetDB(ctx, r.db)
+
+	err := tx.Where("job = ? AND status IN ?", job, []string{enum.JobRunStatusSucceeded.String(), enum.JobRunStatusPartiallyFailed.String()}).
+		Order("business_date DESC").
+		First(&runModel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"job":   job,
+			"error": err,
+		}).Error("Failed to retrieve last completed job run")
+		return nil, errors.Wrap(err, "failed to retrieve last completed job run")
+	}
+
+	run, err := entity.MakeJobRun(&runModel)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert model to entity")
+	}
+	businessDate := run.BusinessDate()
+	return &businessDate, nil
+}
+
+// GetJobRuns returns the latest runs of the job, newest first
+func (r *jobRunRepository) GetJobRuns(ctx context.Context, job string, limit int) ([]*entity.JobRun, error) {
+	var runModels []model.JobRun
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Where("job = ?", job).
+		Order("id DESC").
+		Limit(limit).
+		Find(&runModels).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"job":   job,
+			"error": err,
+		}).Error("Failed to retrieve job runs")
+		return nil, errors.Wrap(err, "failed to retrieve job runs")
+	}
+
+	runs := make([]*entity.JobRun, len(runModels))
+	for i := range runModels {
+		run, err := entity.MakeJobRun(&runModels[i])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to convert model to entity")
+		}
+		runs[i] = run
+	}
+	return runs, nil
+}
@@ -2,20 +2,24 @@ package repository
 
 import (
 	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
 	"billing_enginee/internal/model"
+	"billing_enginee/pkg"
+	"context"
 
-	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors" // Use the correct errors package
 
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type LoanRepository interface {
-	SaveLoan(c *gin.Context, loan *entity.Loan) error
-	GetLoanByID(c *gin.Context, loanID uint) (*entity.Loan, error)
-	GetOutstandingPayments(c *gin.Context, loanID uint) (*entity.Loan, error)
-	UpdateLoanStatus(c *gin.Context, loan *entity.Loan) error
+	SaveLoan(ctx context.Context, loan *entity.Loan) error
+	GetLoanByID(ctx context.Context, loanID uint) (*entity.Loan, error)
+	GetOutstandingPayments(ctx context.Context, loanID uint) (*entity.Loan, error)
+	LockLoan(ctx context.Context, loanID uint) error
+	UpdateLoanStatus(ctx context.Context, loan *entity.Loan, reason string) error
 }
 
 type loanRepository struct {
@@ -28,33 +32,43 @@ func NewLoanRepository(db *gorm.DB) LoanRepository {
 	}
 }
 
-func (r *loanRepository) SaveLoan(c *gin.Context, loan *entity.Loan) error {
+func (r *loanRepository) SaveLoan(ctx context.Context, loan *entity.Loan) error {
 	loanModel := loan.ToModel()
 
-	tx := GetDB(c, r.db)
+	tx := GetDB(ctx, r.db)
 
 	if err := tx.Create(&loanModel).Error; err != nil {
-		log.WithFields(log.Fields{
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"loan":  loanModel,
 			"error": err,
 		}).Error("Failed to save loan")
 		return errors.Wrap(err, "failed to save loan")
 	}
 
+	if err := recordAudit(ctx, tx, auditChange{
+		entityType: enum.AuditEntityLoan,
+		entityID:   loanModel.ID,
+		action:     enum.AuditActionCreate,
+		after:      loanSnapshot(loanModel),
+		reason:     "loan created",
+	}); err != nil {
+		return err
+	}
+
 	loan.SetID(loanModel.ID)
 	return nil
 }
 
-func (r *loanRepository) GetLoanByID(c *gin.Context, loanID uint) (*entity.Loan, error) {
+func (r *loanRepository) GetLoanByID(ctx context.Context, loanID uint) (*entity.Loan, error) {
 	var loanModel model.Loan
-	tx := GetDB(c, r.db)
+	tx := GetDB(ctx, r.db)
 
 	if err := tx.First(&loanModel, loanID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			log.WithField("loanID", loanID).Info("Loan not found")
-			return nil, err
+			pkg.Logger(ctx).WithField("loanID", loanID).Info("Loan not found")
+			return nil, entity.ErrLoanNotFound
 		}
-		log.WithFields(log.Fields{
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"loanID": loanID,
 			"error":  err,
 		}).Error("Failed to retrieve loan")
@@ -69,18 +83,19 @@ func (r *loanRepository) GetLoanByID(c *gin.Context, loanID uint) (*entity.Loan,
 	return loanEntity, nil
 }
 
-func (r *loanRepository) GetOutstandingPayments(c *gin.Context, loanID uint) (*entity.Loan, error) {
+func (r *loanRepository) GetOutstandingPayments(ctx context.Context, loanID uint) (*entity.Loan, error) {
 	var loanModel model.Loan
-	tx := GetDB(c, r.db)
+	tx := GetDB(ctx, r.db)
 
-	if err := tx.Preload("Payments", func(db *gorm.DB) *gorm.DB {
+	// The customer is loaded for the events of the loan
+	if err := tx.Preload("Customer").Preload("Payments", func(db *gorm.DB) *gorm.DB {
 		return db.Where("status IN ?", []string{"pending", "outstanding"}).Order("week ASC")
 	}).First(&loanModel, loanID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			log.WithField("loanID", loanID).Info("Outstanding payments not found")
-			return nil, err
+			pkg.Logger(ctx).WithField("loanID", loanID).Info("Outstanding payments not found")
+			return nil, entity.ErrLoanNotFound
 		}
-		log.WithFields(log.Fields{
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"loanID": loanID,
 			"error":  err,
 		}).Error("Failed to retrieve outstanding payments")
@@ -95,12 +110,47 @@ func (r *loanRepository) GetOutstandingPayments(c *gin.Context, loanID uint) (*e
 	return loanEntity, nil
 }
 
-func (r *loanRepository) UpdateLoanStatus(c *gin.Context, loan *entity.Loan) error {
+// LockLoan locks the loan until the transaction of the context ends, so changes to its installments are made one at a time.
+// It does not wait for the lock of another transaction and returns entity.ErrLoanLocked instead.
+func (r *loanRepository) LockLoan(ctx context.Context, loanID uint) error {
+	var loanModel model.Loan
+	tx := GetDB(ctx, r.db)
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).Select("id").First(&loanModel, loanID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			pkg.Logger(ctx).WithField("loanID", loanID).Info("Loan to lock not found")
+			return entity.ErrLoanNotFound
+		}
+		if isLockNotAvailable(err) {
+			pkg.Logger(ctx).WithField("loanID", loanID).Info("Loan is locked by another transaction")
+			return entity.ErrLoanLocked
+		}
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"loanID": loanID,
+			"error":  err,
+		}).Error("Failed to lock loan")
+		return errors.Wrap(err, "failed to lock loan")
+	}
+	return nil
+}
+
+// UpdateLoanStatus stores the status of the loan, recording the change and its reason in the audit log
+func (r *loanRepository) UpdateLoanStatus(ctx context.Context, loan *entity.Loan, reason string) error {
 	loanModel := loan.ToModel()
-	tx := GetDB(c, r.db)
+	tx := GetDB(ctx, r.db)
+
+	var previous model.Loan
+	if err := tx.Select("id", "status").First(&previous, loanModel.ID).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"loanID": loanModel.ID,
+			"error":  err,
+		}).Error("Failed to read loan status before update")
+		return errors.Wrap(err, "failed to read loan status before update")
+	}
 
 	if err := tx.Model(&model.Loan{}).Where("id = ?", loanModel.ID).Update("status", loanModel.Status).Error; err != nil {
-		log.WithFields(log.Fields{
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"loanID": loanModel.ID,
 			"status": loanModel.Status,
 			"error":  err,
@@ -108,5 +158,15 @@ func (r *loanRepository) UpdateLoanStatus(c *gin.Context, loan *entity.Loan) err
 		return errors.Wrap(err, "failed to update loan status")
 	}
 
-	return nil
+	if previous.Status == loanModel.Status {
+		return nil
+	}
+	return recordAudit(ctx, tx, auditChange{
+		entityType: enum.AuditEntityLoan,
+		entityID:   loanModel.ID,
+		action:     enum.AuditActionUpdate,
+		before:     map[string]interface{}{"status": previous.Status},
+		after:      map[string]interface{}{"status": loanModel.Status},
+		reason:     reason,
+	})
 }
@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/model"
+	"billing_enginee/internal/repository"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type customerRepository struct {
+	store *Store
+}
+
+func NewCustomerRepository(store *Store) repository.CustomerRepository {
+	return &customerRepository{
+		store: store,
+	}
+}
+
+// SaveCustomer stores the customer under a new ID, external IDs and emails are unique per tenant
+func (r *customerRepository) SaveCustomer(ctx context.Context, customer *entity.Customer) error {
+	customerModel := customer.ToModel()
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	customerModel.TenantID = tenantID
+	customerModel.Loans = nil
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.customers {
+		if existing.TenantID != customerModel.TenantID {
+			continue
+		}
+		if existing.ExternalID == customerModel.ExternalID {
+			return fmt.Errorf("failed to save customer: duplicate customer ID %d in tenant %s", customerModel.ExternalID, customerModel.TenantID)
+		}
+		if existing.Email == customerModel.Email {
+			return fmt.Errorf("failed to save customer: duplicate email %q in tenant %s", customerModel.Email, customerModel.TenantID)
+		}
+	}
+
+	s.lastCustomerID++
+	customerModel.ID = s.lastCustomerID
+	customerModel.CreatedAt = time.Now()
+	customerModel.UpdatedAt = customerModel.CreatedAt
+	s.customers[customerModel.ID] = *customerModel
+
+	customer.SetID(customerModel.ID)
+	return nil
+}
+
+// GetCustomerByExternalID returns the customer with its loans and their installments
+func (r *customerRepository) GetCustomerByExternalID(ctx context.Context, externalID uint) (*entity.Customer, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var customerModel model.Customer
+	found := false
+	for _, customer := range s.customers {
+		if customer.ExternalID == externalID && visible(ctx, customer.TenantID) {
+			customerModel, found = customer, true
+			break
+		}
+	}
+	if !found {
+		return nil, entity.ErrCustomerNotFound
+	}
+
+	var loans []model.Loan
+	for _, loan := range s.loans {
+		if loan.CustomerID == customerModel.ID && visible(ctx, loan.TenantID) {
+			payments := s.paymentsOf(ctx, loan.ID, nil)
+			loan.Payments = &payments
+			loans = append(loans, loan)
+		}
+	}
+	sort.Slice(loans, func(i, j int) bool { return loans[i].ID < loans[j].ID })
+	customerModel.Loans = &loans
+
+	return entity.MakeCustomer(&customerModel)
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/model"
+	"billing_enginee/internal/repository"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type jobRunRepository struct {
+	store *Store
+}
+
+func NewJobRunRepository(store *Store) repository.JobRunRepository {
+	return &jobRunRepository{
+		store: store,
+	}
+}
+
+func (r *jobRunRepository) SaveJobRun(ctx context.Context, run *entity.JobRun) error {
+	runModel := run.ToModel()
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	runModel.TenantID = tenantID
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastJobRunID++
+	runModel.ID = s.lastJobRunID
+	runModel.CreatedAt = time.Now()
+	s.jobRuns[runModel.ID] = *runModel
+
+	run.SetID(runModel.ID)
+	return nil
+}
+
+// UpdateJobRun stores the outcome of the run
+func (r *jobRunRepository) UpdateJobRun(ctx context.Context, run *entity.JobRun) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runModel, ok := s.jobRuns[run.GetID()]
+	if !ok || !visible(ctx, runModel.TenantID) {
+		return errors.Wrap(errors.Errorf("job run %d not found", run.GetID()), "failed to update job run")
+	}
+
+	updated := run.ToModel()
+	runModel.Status = updated.Status
+	runModel.ItemsProcessed = updated.ItemsProcessed
+	runModel.ItemsFailed = updated.ItemsFailed
+	runModel.Error = updated.Error
+	runModel.FinishedAt = updated.FinishedAt
+	s.jobRuns[runModel.ID] = runModel
+	return nil
+}
+
+// GetLastCompletedBusinessDate returns the latest business date the job ran to its end for, even when some of its
+// items failed, nil when it never did
+func (r *jobRunRepository) GetLastCompletedBusinessDate(ctx context.Context, job string) (*time.Time, error) {
+	var last *time.Time
+	for _, runModel := range r.find(ctx, job) {
+		completed := runModel.Status == enum.JobRunStatusSucceeded.String() || runModel.Status == enum.JobRunStatusPartiallyFailed.String()
+		if completed && (last == nil || runModel.BusinessDate.After(*last)) {
+			businessDate := runModel.BusinessDate
+			last = &businessDate
+		}
+	}
+	return last, nil
+}
+
+// GetJobRuns returns the latest runs of the job, newest first
+func (r *jobRunRepository) GetJobRuns(ctx context.Context, job string, limit int) ([]*entity.JobRun, error) {
+	runModels := r.find(ctx, job)
+	sort.Slice(runModels, func(i, j int) bool { return runModels[i].ID > runModels[j].ID })
+	if len(runModels) > limit {
+		runModels = runModels[:limit]
+	}
+
+	runs := make([]*entity.JobRun, len(runModels))
+	for i := range runModels {
+		run, err := entity.MakeJobRun(&runModels[i])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to convert model to entity")
+		}
+		runs[i] = run
+	}
+	return runs, nil
+}
+
+// find returns the visible runs of the job
+func (r *jobRunRepository) find(ctx context.Context, job string) []model.JobRun {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var runModels []model.JobRun
+	for _, runModel := range s.jobRuns {
+		if runModel.Job == job && visible(ctx, runModel.TenantID) {
+			runModels = append(runModels, runModel)
+		}
+	}
+	return runModels
+}
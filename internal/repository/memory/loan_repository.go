@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/repository"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type loanRepository struct {
+	store *Store
+}
+
+func NewLoanRepository(store *Store) repository.LoanRepository {
+	return &loanRepository{
+		store: store,
+	}
+}
+
+// SaveLoan stores the loan of an existing customer, without its installments
+func (r *loanRepository) SaveLoan(ctx context.Context, loan *entity.Loan) error {
+	loanModel := loan.ToModel()
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	loanModel.TenantID = tenantID
+	loanModel.Payments = nil
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.customers[loanModel.CustomerID]; !ok {
+		return errors.Wrap(errors.Errorf("customer %d of the loan does not exist", loanModel.CustomerID), "failed to save loan")
+	}
+
+	s.lastLoanID++
+	loanModel.ID = s.lastLoanID
+	// Like GORM, the creation time of the entity is kept and the current time used when it has none
+	if loanModel.CreatedAt.IsZero() {
+		loanModel.CreatedAt = time.Now()
+	}
+	loanModel.UpdatedAt = loanModel.CreatedAt
+	s.loans[loanModel.ID] = *loanModel
+
+	loan.SetID(loanModel.ID)
+	return nil
+}
+
+func (r *loanRepository) GetLoanByID(ctx context.Context, loanID uint) (*entity.Loan, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loanModel, ok := s.loans[loanID]
+	if !ok || !visible(ctx, loanModel.TenantID) {
+		return nil, entity.ErrLoanNotFound
+	}
+
+	loanEntity, err := entity.MakeLoan(&loanModel)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert model to entity")
+	}
+	return loanEntity, nil
+}
+
+// GetOutstandingPayments returns the loan with its customer and its pending and outstanding installments, by week
+func (r *loanRepository) GetOutstandingPayments(ctx context.Context, loanID uint) (*entity.Loan, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loanModel, ok := s.loans[loanID]
+	if !ok || !visible(ctx, loanModel.TenantID) {
+		return nil, entity.ErrLoanNotFound
+	}
+	payments := s.paymentsOf(ctx, loanID, []string{"pending", "outstanding"})
+	loanModel.Payments = &payments
+	loanModel.Customer = s.customers[loanModel.CustomerID]
+
+	loanEntity, err := entity.MakeLoan(&loanModel)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert model to entity")
+	}
+	return loanEntity, nil
+}
+
+// LockLoan only checks that the loan exists, units of work of the store already run one at a time
+func (r *loanRepository) LockLoan(ctx context.Context, loanID uint) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loanModel, ok := s.loans[loanID]
+	if !ok || !visible(ctx, loanModel.TenantID) {
+		return entity.ErrLoanNotFound
+	}
+	return nil
+}
+
+// UpdateLoanStatus stores the status of the loan
+func (r *loanRepository) UpdateLoanStatus(ctx context.Context, loan *entity.Loan, reason string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loanModel, ok := s.loans[loan.GetID()]
+	if !ok || !visible(ctx, loanModel.TenantID) {
+		return errors.Wrap(errors.Errorf("loan %d not found", loan.GetID()), "failed to read loan status before update")
+	}
+
+	loanModel.Status = loan.GetStatus()
+	loanModel.UpdatedAt = time.Now()
+	s.loans[loanModel.ID] = loanModel
+	return nil
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/model"
+	"billing_enginee/internal/repository"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type notificationRepository struct {
+	store *Store
+}
+
+func NewNotificationRepository(store *Store) repository.NotificationRepository {
+	return &notificationRepository{
+		store: store,
+	}
+}
+
+// QueueNotifications stores the notifications of existing payments, skipping the ones an installment already has
+func (r *notificationRepository) QueueNotifications(ctx context.Context, notifications []*entity.Notification) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, notification := range notifications {
+		if _, ok := s.payments[notification.PaymentID()]; !ok {
+			return errors.Wrap(errors.Errorf("payment %d of the notification does not exist", notification.PaymentID()), "failed to queue notifications")
+		}
+	}
+
+	now := time.Now()
+	for _, notification := range notifications {
+		notificationModel := notification.ToModel()
+		if s.hasNotification(notificationModel.PaymentID, notificationModel.Template) {
+			continue
+		}
+
+		s.lastNotificationID++
+		notificationModel.ID = s.lastNotificationID
+		notificationModel.TenantID = tenantID
+		notificationModel.CreatedAt = now
+		notificationModel.UpdatedAt = now
+		s.notifications[notificationModel.ID] = *notificationModel
+		notification.SetID(notificationModel.ID)
+	}
+	return nil
+}
+
+// GetDueNotifications returns the visible pending notifications whose next attempt is due, oldest first
+func (r *notificationRepository) GetDueNotifications(ctx context.Context, now time.Time, limit int) ([]*entity.Notification, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var notificationModels []model.Notification
+	for _, notificationModel := range s.notifications {
+		if visible(ctx, notificationModel.TenantID) && notificationModel.Status == "pending" && !notificationModel.NextAttemptAt.After(now) {
+			notificationModels = append(notificationModels, notificationModel)
+		}
+	}
+	sort.Slice(notificationModels, func(i, j int) bool { return notificationModels[i].ID < notificationModels[j].ID })
+	if len(notificationModels) > limit {
+		notificationModels = notificationModels[:limit]
+	}
+
+	notifications := make([]*entity.Notification, len(notificationModels))
+	for i := range notificationModels {
+		notification, err := entity.MakeNotification(&notificationModels[i])
+		if err != nil {
+			return nil, err
+		}
+		notifications[i] = notification
+	}
+	return notifications, nil
+}
+
+// UpdateNotification stores the outcome of a send attempt
+func (r *notificationRepository) UpdateNotification(ctx context.Context, notification *entity.Notification) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notificationModel, ok := s.notifications[notification.GetID()]
+	if !ok || !visible(ctx, notificationModel.TenantID) {
+		return errors.Wrap(errors.Errorf("notification %d not found", notification.GetID()), "failed to update notification")
+	}
+
+	updated := notification.ToModel()
+	notificationModel.Status = updated.Status
+	notificationModel.Attempts = updated.Attempts
+	notificationModel.NextAttemptAt = updated.NextAttemptAt
+	notificationModel.LastError = updated.LastError
+	notificationModel.SentAt = updated.SentAt
+	notificationModel.UpdatedAt = time.Now()
+	s.notifications[notificationModel.ID] = notificationModel
+	return nil
+}
+
+// hasNotification reports whether the installment already has a notification of the template, like the unique
+// index of the table. The caller holds the lock of the rows.
+func (s *Store) hasNotification(paymentID uint, template string) bool {
+	for _, notificationModel := range s.notifications {
+		if notificationModel.PaymentID == paymentID && notificationModel.Template == template {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package memory
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/model"
+	"billing_enginee/internal/repository"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type paymentRepository struct {
+	store *Store
+}
+
+func NewPaymentRepository(store *Store) repository.PaymentRepository {
+	return &paymentRepository{
+		store: store,
+	}
+}
+
+// GetLoanIDsWithPaymentsDueBeforeDate returns the first loanLimit loans after afterLoanID with scheduled or
+// outstanding installments due before nextWeek, in order
+func (r *paymentRepository) GetLoanIDsWithPaymentsDueBeforeDate(ctx context.Context, nextWeek time.Time, afterLoanID uint, loanLimit int) ([]uint, error) {
+	before := dateOf(nextWeek)
+	payments, err := r.find(ctx, false, func(payment model.Payment) bool {
+		return payment.LoanID > afterLoanID && payment.DueDate.Before(before) && hasStatus(payment, []string{"scheduled", "outstanding"})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	due := make(map[uint]bool)
+	var loanIDs []uint
+	for _, payment := range payments {
+		if !due[payment.LoanID()] {
+			due[payment.LoanID()] = true
+			loanIDs = append(loanIDs, payment.LoanID())
+		}
+	}
+	sort.Slice(loanIDs, func(i, j int) bool { return loanIDs[i] < loanIDs[j] })
+	if len(loanIDs) > loanLimit {
+		loanIDs = loanIDs[:loanLimit]
+	}
+	return loanIDs, nil
+}
+
+// LockPaymentsDueBeforeDate returns the scheduled and outstanding installments of the loan due before nextWeek,
+// in installment order. Units of work of the store already run one at a time.
+func (r *paymentRepository) LockPaymentsDueBeforeDate(ctx context.Context, loanID uint, nextWeek time.Time) ([]*entity.Payment, error) {
+	before := dateOf(nextWeek)
+	return r.find(ctx, false, func(payment model.Payment) bool {
+		return payment.LoanID == loanID && payment.DueDate.Before(before) && hasStatus(payment, []string{"scheduled", "outstanding"})
+	})
+}
+
+// UpdatePaymentStatus stores the status of the payment, and when it was paid
+func (r *paymentRepository) UpdatePaymentStatus(ctx context.Context, payment *entity.Payment, reason string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paymentModel, ok := s.payments[payment.GetID()]
+	if !ok || !visible(ctx, paymentModel.TenantID) {
+		return errors.Wrap(errors.Errorf("payment %d not found", payment.GetID()), "failed to read payment status before update")
+	}
+
+	paymentModel.Status = payment.Status()
+	paymentModel.UpdatedAt = time.Now()
+	if payment.Status() == "paid" {
+		if payment.PaidAt() == nil {
+			return errors.Errorf("paid payment %d has no paid time", payment.GetID())
+		}
+		paidAt := *payment.PaidAt()
+		paymentModel.PaidAt = &paidAt
+	}
+	s.payments[paymentModel.ID] = paymentModel
+	return nil
+}
+
+// GetNextPayment returns the first installment of the loan still to be paid, nil when there is none
+func (r *paymentRepository) GetNextPayment(ctx context.Context, loanID uint) (*entity.Payment, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payments := s.paymentsOf(ctx, loanID, []string{"scheduled", "outstanding"})
+	if len(payments) == 0 {
+		return nil, nil
+	}
+	return entity.MakePayment(&payments[0])
+}
+
+// SavePayments stores the installments, which must belong to existing loans
+func (r *paymentRepository) SavePayments(ctx context.Context, payments []*entity.Payment) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paymentModels := make([]model.Payment, len(payments))
+	for i, payment := range payments {
+		paymentModel := payment.ToModel()
+		if _, ok := s.loans[paymentModel.LoanID]; !ok {
+			return errors.Wrap(errors.Errorf("loan %d of the payment does not exist", paymentModel.LoanID), "failed to save payments")
+		}
+		paymentModels[i] = *paymentModel
+	}
+
+	// Every installment is stored or none, like the single insert of the GORM repository
+	now := time.Now()
+	for i := range paymentModels {
+		s.lastPaymentID++
+		paymentModels[i].ID = s.lastPaymentID
+		paymentModels[i].TenantID = tenantID
+		paymentModels[i].DueDate = dateOf(paymentModels[i].DueDate)
+		paymentModels[i].CreatedAt = now
+		paymentModels[i].UpdatedAt = now
+		s.payments[paymentModels[i].ID] = paymentModels[i]
+		payments[i].SetID(paymentModels[i].ID)
+	}
+	return nil
+}
+
+// GetPaymentsDueOnDateWithStatus returns the payments due on the given date, with their loan and customer loaded
+func (r *paymentRepository) GetPaymentsDueOnDateWithStatus(ctx context.Context, dueDate time.Time, statuses []string) ([]*entity.Payment, error) {
+	day := dateOf(dueDate)
+	return r.find(ctx, true, func(payment model.Payment) bool {
+		return payment.DueDate.Equal(day) && hasStatus(payment, statuses)
+	})
+}
+
+// GetPaymentsByIDs returns the requested payments, with their loan and customer loaded
+func (r *paymentRepository) GetPaymentsByIDs(ctx context.Context, paymentIDs []uint) ([]*entity.Payment, error) {
+	requested := make(map[uint]bool, len(paymentIDs))
+	for _, id := range paymentIDs {
+		requested[id] = true
+	}
+	return r.find(ctx, true, func(payment model.Payment) bool {
+		return requested[payment.ID]
+	})
+}
+
+// find returns the visible payments matching the filter in the order they were created,
+// with their loan and its customer when withLoan is set
+func (r *paymentRepository) find(ctx context.Context, withLoan bool, match func(payment model.Payment) bool) ([]*entity.Payment, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var paymentModels []model.Payment
+	for _, payment := range s.payments {
+		if visible(ctx, payment.TenantID) && match(payment) {
+			if withLoan {
+				payment.Loan = s.loans[payment.LoanID]
+				payment.Loan.Customer = s.customers[payment.Loan.CustomerID]
+			}
+			paymentModels = append(paymentModels, payment)
+		}
+	}
+	sort.Slice(paymentModels, func(i, j int) bool { return paymentModels[i].ID < paymentModels[j].ID })
+
+	payments := make([]*entity.Payment, len(paymentModels))
+	for i := range paymentModels {
+		payment, err := entity.MakePayment(&paymentModels[i])
+		if err != nil {
+			return nil, err
+		}
+		payments[i] = payment
+	}
+	return payments, nil
+}
+
+// paymentsOf returns the visible installments of the loan with one of the statuses, any status when there are
+// none, by week. The caller holds the lock of the rows.
+func (s *Store) paymentsOf(ctx context.Context, loanID uint, statuses []string) []model.Payment {
+	var payments []model.Payment
+	for _, payment := range s.payments {
+		if payment.LoanID == loanID && visible(ctx, payment.TenantID) && (len(statuses) == 0 || hasStatus(payment, statuses)) {
+			payments = append(payments, payment)
+		}
+	}
+	sort.Slice(payments, func(i, j int) bool { return payments[i].Week < payments[j].Week })
+	return payments
+}
+
+func hasStatus(payment model.Payment, statuses []string) bool {
+	for _, status := range statuses {
+		if payment.Status == status {
+			return true
+		}
+	}
+	return false
+}
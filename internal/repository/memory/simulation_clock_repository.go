@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"billing_enginee/internal/repository"
+	"context"
+	"time"
+)
+
+type simulationClockRepository struct {
+	store *Store
+}
+
+func NewSimulationClockRepository(store *Store) repository.SimulationClockRepository {
+	return &simulationClockRepository{
+		store: store,
+	}
+}
+
+func (r *simulationClockRepository) GetOffset(ctx context.Context) (time.Duration, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.simulationOffset, nil
+}
+
+func (r *simulationClockRepository) SaveOffset(ctx context.Context, offset time.Duration) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.simulationOffset = offset.Truncate(time.Second)
+	return nil
+}
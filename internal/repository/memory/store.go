@@ -0,0 +1,143 @@
+// Package memory implements the loan, payment, customer, job run, notification and simulation clock
+// repositories in memory, for unit tests of the usecases that need neither a database nor its setup. They keep
+// the semantics of the GORM repositories: rows are scoped to the tenant of the context, keys and references are
+// checked, and the writes of a unit of work are undone when it fails. They do not write the audit log.
+package memory
+
+import (
+	"billing_enginee/internal/model"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/pkg"
+	"context"
+	"sync"
+	"time"
+)
+
+// Store holds the rows of the in-memory repositories and is their unit of work. Units of work run one at a
+// time, like the writers of SQLite, so a loan locked by LockLoan is never found locked by another one.
+type Store struct {
+	mu   sync.Mutex // guards the rows
+	work sync.Mutex // held by the running unit of work
+
+	customers     map[uint]model.Customer
+	loans         map[uint]model.Loan
+	payments      map[uint]model.Payment
+	jobRuns       map[uint]model.JobRun
+	notifications map[uint]model.Notification
+
+	simulationOffset time.Duration
+
+	// Like the sequences of Postgres, IDs are not given again after a rollback
+	lastCustomerID     uint
+	lastLoanID         uint
+	lastPaymentID      uint
+	lastJobRunID       uint
+	lastNotificationID uint
+}
+
+var _ pkg.UnitOfWork = (*Store)(nil)
+
+func NewStore() *Store {
+	return &Store{
+		customers:     make(map[uint]model.Customer),
+		loans:         make(map[uint]model.Loan),
+		payments:      make(map[uint]model.Payment),
+		jobRuns:       make(map[uint]model.JobRun),
+		notifications: make(map[uint]model.Notification),
+	}
+}
+
+type txContextKey struct{}
+
+// Do runs fn and restores the rows it started with when fn returns an error or panics. A unit of work started
+// with the context of another one behaves like a savepoint, its failure only undoes its own writes.
+func (s *Store) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, nested := ctx.Value(txContextKey{}).(bool); !nested {
+		s.work.Lock()
+		defer s.work.Unlock()
+		ctx = context.WithValue(ctx, txContextKey{}, true)
+	}
+
+	saved := s.snapshot()
+	defer func() {
+		if r := recover(); r != nil {
+			s.restore(saved)
+			panic(r)
+		}
+		if err != nil {
+			s.restore(saved)
+		}
+	}()
+	return fn(ctx)
+}
+
+type snapshot struct {
+	customers     map[uint]model.Customer
+	loans         map[uint]model.Loan
+	payments      map[uint]model.Payment
+	jobRuns       map[uint]model.JobRun
+	notifications map[uint]model.Notification
+
+	simulationOffset time.Duration
+}
+
+// snapshot copies the rows. They are stored without their associations and replaced rather than changed,
+// so copying the maps is enough.
+func (s *Store) snapshot() snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return snapshot{
+		customers:     copyRows(s.customers),
+		loans:         copyRows(s.loans),
+		payments:      copyRows(s.payments),
+		jobRuns:       copyRows(s.jobRuns),
+		notifications: copyRows(s.notifications),
+
+		simulationOffset: s.simulationOffset,
+	}
+}
+
+func (s *Store) restore(saved snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.customers = saved.customers
+	s.loans = saved.loans
+	s.payments = saved.payments
+	s.jobRuns = saved.jobRuns
+	s.notifications = saved.notifications
+	s.simulationOffset = saved.simulationOffset
+}
+
+func copyRows[T any](rows map[uint]T) map[uint]T {
+	copied := make(map[uint]T, len(rows))
+	for id, row := range rows {
+		copied[id] = row
+	}
+	return copied
+}
+
+// visible reports whether a row of the tenant can be seen with the context. A context without a tenant sees
+// no rows, unless it opted in to the rows of every tenant.
+func visible(ctx context.Context, rowTenantID string) bool {
+	if tenantID := pkg.GetTenantID(ctx); tenantID != "" {
+		return tenantID == rowTenantID
+	}
+	return pkg.AllTenants(ctx)
+}
+
+// tenantOf returns the tenant new rows are created for, the default one of the columns when the context opted
+// in to every tenant. A context without a tenant creates nothing, like a scoped GORM session.
+func tenantOf(ctx context.Context) (string, error) {
+	if tenantID := pkg.GetTenantID(ctx); tenantID != "" {
+		return tenantID, nil
+	}
+	if pkg.AllTenants(ctx) {
+		return tenant.DefaultTenantID, nil
+	}
+	return "", pkg.ErrNoTenant
+}
+
+// dateOf keeps the calendar day of the time, like a date column does
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/model"
+	"billing_enginee/pkg"
+	"context"
+	"time"
+
+	"github.com/pkg/errors" // Use the correct errors package
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	QueueNotifications(ctx context.Context, notifications []*entity.Notification) error
+	GetDueNotifications(ctx context.Context, now time.Time, limit int) ([]*entity.Notification, error)
+	UpdateNotification(ctx context.Context, notification *entity.Notification) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{
+		db: db,
+	}
+}
+
+// QueueNotifications stores the notifications through the transaction of the context, so they are only sent
+// if the change they are about is committed. A notification the installment already has is not queued again.
+func (r *notificationRepository) QueueNotifications(ctx context.Context, notifications []*entity.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	tx := GetDB(ctx, r.db)
+	notificationModels := make([]model.Notification, len(notifications))
+	for i, notification := range notifications {
+		notificationModels[i] = *notification.ToModel()
+	}
+
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "payment_id"}, {Name: "template"}},
+		DoNothing: true,
+	}).Create(&notificationModels).Error; err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to queue notifications")
+		return errors.Wrap(err, "failed to queue notifications")
+	}
+
+	for i, notificationModel := range notificationModels {
+		notifications[i].SetID(notificationModel.ID)
+	}
+	return nil
+}
+
+// GetDueNotifications returns the pending notifications whose next attempt is due, oldest first
+func (r *notificationRepository) GetDueNotifications(ctx context.Context, now time.Time, limit int) ([]*entity.Notification, error) {
+	var notificationModels []model.Notification
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Where("status = ? AND next_attempt_at <= ?", "pending", now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&notificationModels).Error; err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to retrieve due notifications")
+		return nil, errors.Wrap(err, "failed to retrieve due notifications")
+	}
+
+	notifications := make([]*entity.Notification, len(notificationModels))
+	for i := range notificationModels {
+		notification, err := entity.MakeNotification(&notificationModels[i])
+		if err != nil {
+			return nil, err
+		}
+		notifications[i] = notification
+	}
+	return notifications, nil
+}
+
+func (r *notificationRepository) UpdateNotification(ctx context.Context, notification *entity.Notification) error {
+	notificationModel := notification.ToModel()
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Model(&model.Notification{}).Where("id = ?", notificationModel.ID).Updates(map[string]interface{}{
+		"status":          notificationModel.Status,
+		"attempts":        notificationModel.Attempts,
+		"next_attempt_at": notificationModel.NextAttemptAt,
+		"last_error":      notificationModel.LastError,
+		"sent_at":         notificationModel.SentAt,
+	}).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"notificationID": notificationModel.ID,
+			"error":          err,
+		}).Error("Failed to update notification")
+		return errors.Wrap(err, "failed to update notification")
+	}
+
+	return nil
+}
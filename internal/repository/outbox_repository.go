@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/model"
+	"billing_enginee/pkg"
+	"context"
+	"time"
+
+	"github.com/pkg/errors" // Use the correct errors package
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	SaveMessage(ctx context.Context, message *entity.OutboxMessage) error
+	GetUnpublishedMessages(ctx context.Context, now time.Time, limit int) ([]*entity.OutboxMessage, error)
+	UpdateMessage(ctx context.Context, message *entity.OutboxMessage) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+// SaveMessage stores the message through the request transaction, so it is only kept if the change is committed
+func (r *outboxRepository) SaveMessage(ctx context.Context, message *entity.OutboxMessage) error {
+	messageModel := message.ToModel()
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Create(&messageModel).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"eventID":   messageModel.EventID,
+			"eventType": messageModel.EventType,
+			"error":     err,
+		}).Error("Failed to save outbox message")
+		return errors.Wrap(err, "failed to save outbox message")
+	}
+
+	message.SetID(messageModel.ID)
+	return nil
+}
+
+// GetUnpublishedMessages returns the oldest messages whose next attempt is due, in insertion order, leaving out
+// the messages the relay gave up on. A message is also left out while an earlier one of its key waits for its
+// next attempt, so the messages of a key are never published out of order.
+func (r *outboxRepository) GetUnpublishedMessages(ctx context.Context, now time.Time, limit int) ([]*entity.OutboxMessage, error) {
+	var messageModels []model.OutboxEvent
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Where("published_at IS NULL AND failed_at IS NULL AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", now).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM outbox_events earlier
+			WHERE earlier.aggregate_type = outbox_events.aggregate_type AND earlier.aggregate_id = outbox_events.aggregate_id
+				AND earlier.id < outbox_events.id AND earlier.published_at IS NULL AND earlier.failed_at IS NULL
+				AND earlier.next_attempt_at > ?
+		)`, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&messageModels).Error; err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to retrieve unpublished outbox messages")
+		return nil, errors.Wrap(err, "failed to retrieve unpublished outbox messages")
+	}
+
+	messages := make([]*entity.OutboxMessage, len(messageModels))
+	for i := range messageModels {
+		messages[i] = entity.MakeOutboxMessage(&messageModels[i])
+	}
+	return messages, nil
+}
+
+func (r *outboxRepository) UpdateMessage(ctx context.Context, message *entity.OutboxMessage) error {
+	messageModel := message.ToModel()
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Model(&model.OutboxEvent{}).Where("id = ?", messageModel.ID).Updates(map[string]interface{}{
+		"attempts":        messageModel.Attempts,
+		"last_error":      messageModel.LastError,
+		"next_attempt_at": messageModel.NextAttemptAt,
+		"published_at":    messageModel.PublishedAt,
+		"failed_at":       messageModel.FailedAt,
+	}).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"messageID": messageModel.ID,
+			"error":     err,
+		}).Error("Failed to update outbox message")
+		return errors.Wrap(err, "failed to update outbox message")
+	}
+
+	return nil
+}
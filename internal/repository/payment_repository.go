@@ -2,21 +2,28 @@ package repository
 
 import (
 	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
 	"billing_enginee/internal/model"
+	"billing_enginee/pkg"
+	"context"
+	"slices"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors" // Use the correct errors package
 
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaymentRepository interface {
-	GetPaymentsDueBeforeDateWithStatus(c *gin.Context, nextWeek time.Time) ([]*entity.Payment, error)
-	UpdatePaymentStatus(c *gin.Context, payment *entity.Payment) error
-	GetNextPayment(c *gin.Context, loanID uint) (*entity.Payment, error)
-	SavePayments(c *gin.Context, payments []*entity.Payment) error
+	GetLoanIDsWithPaymentsDueBeforeDate(ctx context.Context, nextWeek time.Time, afterLoanID uint, loanLimit int) ([]uint, error)
+	LockPaymentsDueBeforeDate(ctx context.Context, loanID uint, nextWeek time.Time) ([]*entity.Payment, error)
+	UpdatePaymentStatus(ctx context.Context, payment *entity.Payment, reason string) error
+	GetNextPayment(ctx context.Context, loanID uint) (*entity.Payment, error)
+	SavePayments(ctx context.Context, payments []*entity.Payment) error
+	GetPaymentsDueOnDateWithStatus(ctx context.Context, dueDate time.Time, statuses []string) ([]*entity.Payment, error)
+	GetPaymentsByIDs(ctx context.Context, paymentIDs []uint) ([]*entity.Payment, error)
 }
 
 type paymentRepository struct {
@@ -29,32 +36,76 @@ func NewPaymentRepository(db *gorm.DB) PaymentRepository {
 	}
 }
 
-func (r *paymentRepository) GetPaymentsDueBeforeDateWithStatus(c *gin.Context, nextWeek time.Time) ([]*entity.Payment, error) {
+// GetLoanIDsWithPaymentsDueBeforeDate returns the first loanLimit loans after afterLoanID with scheduled or
+// outstanding installments due before nextWeek, in order. Paging on the loan ID does not slow down with the
+// pages already read.
+func (r *paymentRepository) GetLoanIDsWithPaymentsDueBeforeDate(ctx context.Context, nextWeek time.Time, afterLoanID uint, loanLimit int) ([]uint, error) {
+	var loanIDs []uint
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Model(&model.Payment{}).
+		Where("due_date < ? AND status IN ? AND loan_id > ?", nextWeek.Format("2006-01-02"), []string{"scheduled", "outstanding"}, afterLoanID).
+		Distinct("loan_id").
+		Order("loan_id").
+		Limit(loanLimit).
+		Pluck("loan_id", &loanIDs).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"afterLoanID": afterLoanID,
+			"error":       err,
+		}).Error("Failed to retrieve loans with payments due before date")
+		return nil, errors.Wrap(err, "failed to retrieve loans with payments due before date")
+	}
+	return loanIDs, nil
+}
+
+// LockPaymentsDueBeforeDate returns the scheduled and outstanding installments of the loan due before nextWeek,
+// in installment order, and locks them until the transaction of the context ends. It waits for a payment of the
+// loan being made, whose installment is then read as paid.
+func (r *paymentRepository) LockPaymentsDueBeforeDate(ctx context.Context, loanID uint, nextWeek time.Time) ([]*entity.Payment, error) {
 	var paymentModels []model.Payment
-	tx := GetDB(c, r.db)
+	tx := GetDB(ctx, r.db)
 
-	if err := tx.Where("DATE(due_date) < ? AND status IN ?", nextWeek.Format("2006-01-02"), []string{"scheduled", "outstanding"}).
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("loan_id = ? AND due_date < ? AND status IN ?", loanID, nextWeek.Format("2006-01-02"), []string{"scheduled", "outstanding"}).
+		Order("id").
 		Find(&paymentModels).Error; err != nil {
-		log.WithError(err).Error("Failed to retrieve payments due before date")
-		return nil, errors.Wrap(err, "failed to retrieve payments due before date")
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"loanID": loanID,
+			"error":  err,
+		}).Error("Failed to lock payments due before date")
+		return nil, errors.Wrap(err, "failed to lock payments due before date")
 	}
 
-	payments := make([]*entity.Payment, len(paymentModels))
-	for i, model := range paymentModels {
-		entityConvert, err := entity.MakePayment(&model)
-		if err != nil {
-			return nil, err
-		}
-		payments[i] = entityConvert
+	return makePayments(paymentModels)
+}
+
+// UpdatePaymentStatus stores the status of the payment, recording the change and its reason in the audit log.
+// Writing an unchanged status is not recorded.
+func (r *paymentRepository) UpdatePaymentStatus(ctx context.Context, payment *entity.Payment, reason string) error {
+	tx := GetDB(ctx, r.db)
+
+	var previous model.Payment
+	if err := tx.Select("id", "status", "paid_at").First(&previous, payment.GetID()).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"paymentID": payment.GetID(),
+			"error":     err,
+		}).Error("Failed to read payment status before update")
+		return errors.Wrap(err, "failed to read payment status before update")
 	}
 
-	return payments, nil
-}
+	updates := map[string]interface{}{"status": payment.Status()}
+	after := map[string]interface{}{"status": payment.Status()}
+	if payment.Status() == "paid" {
+		if payment.PaidAt() == nil {
+			return errors.Errorf("paid payment %d has no paid time", payment.GetID())
+		}
+		paidAt := *payment.PaidAt()
+		updates["paid_at"] = paidAt
+		after["paid_at"] = paidAt.Format(time.RFC3339)
+	}
 
-func (r *paymentRepository) UpdatePaymentStatus(c *gin.Context, payment *entity.Payment) error {
-	tx := GetDB(c, r.db)
-	if err := tx.Model(&model.Payment{}).Where("id = ?", payment.GetID()).Update("status", payment.Status()).Error; err != nil {
-		log.WithFields(log.Fields{
+	if err := tx.Model(&model.Payment{}).Where("id = ?", payment.GetID()).Updates(updates).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"paymentID": payment.GetID(),
 			"status":    payment.Status(),
 			"error":     err,
@@ -62,20 +113,34 @@ func (r *paymentRepository) UpdatePaymentStatus(c *gin.Context, payment *entity.
 		return errors.Wrap(err, "failed to update payment status")
 	}
 
-	return nil
+	if previous.Status == payment.Status() {
+		return nil
+	}
+	before := map[string]interface{}{"status": previous.Status}
+	if previous.PaidAt != nil {
+		before["paid_at"] = previous.PaidAt.Format(time.RFC3339)
+	}
+	return recordAudit(ctx, tx, auditChange{
+		entityType: enum.AuditEntityPayment,
+		entityID:   payment.GetID(),
+		action:     enum.AuditActionUpdate,
+		before:     before,
+		after:      after,
+		reason:     reason,
+	})
 }
 
-func (r *paymentRepository) GetNextPayment(c *gin.Context, loanID uint) (*entity.Payment, error) {
+func (r *paymentRepository) GetNextPayment(ctx context.Context, loanID uint) (*entity.Payment, error) {
 	var paymentModel model.Payment
-	tx := GetDB(c, r.db)
+	tx := GetDB(ctx, r.db)
 	err := tx.Where("loan_id = ? AND status IN ?", loanID, []string{"scheduled", "outstanding"}).Order("week asc").First(&paymentModel).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			log.WithField("loanID", loanID).Info("No next payment found")
+			pkg.Logger(ctx).WithField("loanID", loanID).Info("No next payment found")
 			return nil, nil
 		}
-		log.WithFields(log.Fields{
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"loanID": loanID,
 			"error":  err,
 		}).Error("Failed to retrieve next payment")
@@ -85,21 +150,91 @@ func (r *paymentRepository) GetNextPayment(c *gin.Context, loanID uint) (*entity
 	return entity.MakePayment(&paymentModel)
 }
 
-func (r *paymentRepository) SavePayments(c *gin.Context, payments []*entity.Payment) error {
-	tx := GetDB(c, r.db)
+func (r *paymentRepository) SavePayments(ctx context.Context, payments []*entity.Payment) error {
+	tx := GetDB(ctx, r.db)
 	paymentModels := make([]model.Payment, len(payments))
 	for i, payment := range payments {
 		paymentModels[i] = *payment.ToModel()
 	}
 
 	if err := tx.Create(&paymentModels).Error; err != nil {
-		log.WithError(err).Error("Failed to save payments")
+		pkg.Logger(ctx).WithError(err).Error("Failed to save payments")
 		return errors.Wrap(err, "failed to save payments")
 	}
 
-	for i, paymentModel := range paymentModels {
-		payments[i].SetID(paymentModel.ID)
+	changes := make([]auditChange, len(paymentModels))
+	for i := range paymentModels {
+		payments[i].SetID(paymentModels[i].ID)
+		changes[i] = auditChange{
+			entityType: enum.AuditEntityPayment,
+			entityID:   paymentModels[i].ID,
+			action:     enum.AuditActionCreate,
+			after:      paymentSnapshot(&paymentModels[i]),
+			reason:     "installment scheduled",
+		}
+	}
+
+	return recordAudit(ctx, tx, changes...)
+}
+
+// GetPaymentsDueOnDateWithStatus returns the payments due on the given date, with their loan and customer loaded
+func (r *paymentRepository) GetPaymentsDueOnDateWithStatus(ctx context.Context, dueDate time.Time, statuses []string) ([]*entity.Payment, error) {
+	var paymentModels []model.Payment
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Preload("Loan.Customer").
+		Where("due_date >= ? AND due_date < ? AND status IN ?", dueDate.Format("2006-01-02"), dueDate.AddDate(0, 0, 1).Format("2006-01-02"), statuses).
+		Order("id ASC").
+		Find(&paymentModels).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"dueDate": dueDate,
+			"error":   err,
+		}).Error("Failed to retrieve payments due on date")
+		return nil, errors.Wrap(err, "failed to retrieve payments due on date")
+	}
+
+	return makePayments(paymentModels)
+}
+
+// paymentIDsChunkSize caps the IDs bound in one query, far below the 65535 bind parameters of Postgres
+const paymentIDsChunkSize = 1000
+
+// GetPaymentsByIDs returns the requested payments ordered by ID, with their loan and customer loaded.
+// The IDs are read paymentIDsChunkSize at a time.
+func (r *paymentRepository) GetPaymentsByIDs(ctx context.Context, paymentIDs []uint) ([]*entity.Payment, error) {
+	sortedIDs := slices.Clone(paymentIDs)
+	slices.Sort(sortedIDs)
+
+	var paymentModels []model.Payment
+	tx := GetDB(ctx, r.db)
+	for start := 0; start < len(sortedIDs); start += paymentIDsChunkSize {
+		chunk := sortedIDs[start:min(start+paymentIDsChunkSize, len(sortedIDs))]
+
+		var chunkModels []model.Payment
+		if err := tx.Preload("Loan.Customer").
+			Where("id IN ?", chunk).
+			Order("id ASC").
+			Find(&chunkModels).Error; err != nil {
+			pkg.Logger(ctx).WithFields(log.Fields{
+				"payments": len(paymentIDs),
+				"error":    err,
+			}).Error("Failed to retrieve payments by IDs")
+			return nil, errors.Wrap(err, "failed to retrieve payments by IDs")
+		}
+		paymentModels = append(paymentModels, chunkModels...)
 	}
 
-	return nil
+	return makePayments(paymentModels)
+}
+
+func makePayments(paymentModels []model.Payment) ([]*entity.Payment, error) {
+	payments := make([]*entity.Payment, len(paymentModels))
+	for i := range paymentModels {
+		payment, err := entity.MakePayment(&paymentModels[i])
+		if err != nil {
+			return nil, err
+		}
+		payments[i] = payment
+	}
+	return payments, nil
 }
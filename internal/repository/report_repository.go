@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/pkg"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors" // Use the correct errors package
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type ReportRepository interface {
+	GetPortfolioSummary(ctx context.Context) (*entity.PortfolioSummary, error)
+	CountLoansByStatus(ctx context.Context) ([]entity.LoanStatusCount, error)
+	GetPortfolioAtRisk(ctx context.Context, asOf time.Time) (*entity.PortfolioAtRisk, error)
+	GetCollections(ctx context.Context, period string, from time.Time, to time.Time) ([]entity.CollectionPeriod, error)
+}
+
+type reportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{
+		db: db,
+	}
+}
+
+// The principal part of an installment is proportional to the loan's principal over its total repayable amount.
+const installmentPrincipalSQL = "p.amount * l.amount / l.total_amount"
+
+// Raw SQL is not scoped by GetDB, so every report filters on the tenant of the request itself
+const tenantFilterSQL = "%s.tenant_id = ?"
+
+func tenantFilter(alias string) string {
+	return fmt.Sprintf(tenantFilterSQL, alias)
+}
+
+// reportTenant returns the tenant the reports of the context are filtered on, reports are never made across
+// tenants
+func reportTenant(ctx context.Context) (string, error) {
+	tenantID := pkg.GetTenantID(ctx)
+	if tenantID == "" {
+		return "", errors.Wrap(pkg.ErrNoTenant, "failed to scope report")
+	}
+	return tenantID, nil
+}
+
+func (r *reportRepository) GetPortfolioSummary(ctx context.Context) (*entity.PortfolioSummary, error) {
+	tx := GetDB(ctx, r.db)
+	tenantID, err := reportTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary entity.PortfolioSummary
+	if err := tx.Raw(`
+		SELECT COUNT(*) AS loan_count,
+			COALESCE(SUM(l.amount), 0) AS total_disbursed,
+			COALESCE(SUM(l.total_amount), 0) AS total_repayable
+		FROM loans l
+		WHERE `+tenantFilter("l"), tenantID).Scan(&summary).Error; err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to aggregate loan totals")
+		return nil, errors.Wrap(err, "failed to aggregate loan totals")
+	}
+
+	var outstanding struct {
+		OutstandingTotal     float64
+		OutstandingPrincipal float64
+	}
+	if err := tx.Raw(`
+		SELECT COALESCE(SUM(p.amount), 0) AS outstanding_total,
+			COALESCE(SUM(`+installmentPrincipalSQL+`), 0) AS outstanding_principal
+		FROM payments p
+		JOIN loans l ON l.id = p.loan_id
+		WHERE p.status <> ? AND `+tenantFilter("l"), "paid", tenantID).Scan(&outstanding).Error; err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to aggregate outstanding balance")
+		return nil, errors.Wrap(err, "failed to aggregate outstanding balance")
+	}
+
+	summary.OutstandingTotal = outstanding.OutstandingTotal
+	summary.OutstandingPrincipal = outstanding.OutstandingPrincipal
+	return &summary, nil
+}
+
+func (r *reportRepository) CountLoansByStatus(ctx context.Context) ([]entity.LoanStatusCount, error) {
+	tx := GetDB(ctx, r.db)
+	tenantID, err := reportTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var counts []entity.LoanStatusCount
+	if err := tx.Raw(`
+		SELECT l.status, COUNT(*) AS count
+		FROM loans l
+		WHERE `+tenantFilter("l")+`
+		GROUP BY l.status
+		ORDER BY l.status`, tenantID).Scan(&counts).Error; err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to count loans by status")
+		return nil, errors.Wrap(err, "failed to count loans by status")
+	}
+
+	return counts, nil
+}
+
+func (r *reportRepository) GetPortfolioAtRisk(ctx context.Context, asOf time.Time) (*entity.PortfolioAtRisk, error) {
+	tx := GetDB(ctx, r.db)
+	tenantID, err := reportTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// A loan is at risk when its oldest unpaid installment is due before the cutoff date
+	par30Cutoff := asOf.AddDate(0, 0, -30).Format("2006-01-02")
+	par90Cutoff := asOf.AddDate(0, 0, -90).Format("2006-01-02")
+
+	var par entity.PortfolioAtRisk
+	if err := tx.Raw(`
+		SELECT COALESCE(SUM(o.principal), 0) AS outstanding_principal,
+			COALESCE(SUM(CASE WHEN o.oldest_due_date < ? THEN o.principal ELSE 0 END), 0) AS par30_principal,
+			COUNT(CASE WHEN o.oldest_due_date < ? THEN 1 END) AS par30_loans,
+			COALESCE(SUM(CASE WHEN o.oldest_due_date < ? THEN o.principal ELSE 0 END), 0) AS par90_principal,
+			COUNT(CASE WHEN o.oldest_due_date < ? THEN 1 END) AS par90_loans
+		FROM (
+			SELECT p.loan_id,
+				SUM(`+installmentPrincipalSQL+`) AS principal,
+				MIN(p.due_date) AS oldest_due_date
+			FROM payments p
+			JOIN loans l ON l.id = p.loan_id
+			WHERE p.status <> ? AND `+tenantFilter("l")+`
+			GROUP BY p.loan_id
+		) o`, par30Cutoff, par30Cutoff, par90Cutoff, par90Cutoff, "paid", tenantID).Scan(&par).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"asOf":  asOf,
+			"error": err,
+		}).Error("Failed to aggregate portfolio at risk")
+		return nil, errors.Wrap(err, "failed to aggregate portfolio at risk")
+	}
+
+	// Scanning resets the fields the query does not return
+	par.AsOf = asOf
+	return &par, nil
+}
+
+// periodStartSQL truncates the paid_at of a payment to the start of its day, week or month. SQLite has no
+// date_trunc, its times are text starting with the local date, which the date functions would turn to UTC.
+func periodStartSQL(dialect string) string {
+	if dialect != "sqlite" {
+		return "date_trunc(?, p.paid_at)"
+	}
+	return `CASE ?
+			WHEN 'month' THEN substr(p.paid_at, 1, 7) || '-01'
+			WHEN 'week' THEN date(substr(p.paid_at, 1, 10), 'weekday 0', '-6 days')
+			ELSE substr(p.paid_at, 1, 10)
+		END`
+}
+
+func (r *reportRepository) GetCollections(ctx context.Context, period string, from time.Time, to time.Time) ([]entity.CollectionPeriod, error) {
+	tx := GetDB(ctx, r.db)
+	tenantID, err := reportTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		PeriodStart       periodStart
+		InstallmentsCount int64
+		AmountCollected   float64
+	}
+	if err := tx.Raw(`
+		SELECT `+periodStartSQL(tx.Dialector.Name())+` AS period_start,
+			COUNT(*) AS installments_count,
+			COALESCE(SUM(p.amount), 0) AS amount_collected
+		FROM payments p
+		WHERE p.status = ? AND p.paid_at >= ? AND p.paid_at < ? AND `+tenantFilter("p")+`
+		GROUP BY 1
+		ORDER BY 1`, period, "paid", from, to, tenantID).Scan(&rows).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"period": period,
+			"from":   from,
+			"to":     to,
+			"error":  err,
+		}).Error("Failed to aggregate collections")
+		return nil, errors.Wrap(err, "failed to aggregate collections")
+	}
+
+	collections := make([]entity.CollectionPeriod, len(rows))
+	for i, row := range rows {
+		collections[i] = entity.CollectionPeriod{
+			PeriodStart:       time.Time(row.PeriodStart),
+			InstallmentsCount: row.InstallmentsCount,
+			AmountCollected:   row.AmountCollected,
+		}
+	}
+	return collections, nil
+}
+
+// periodStart scans the start of a collection period, a timestamp on Postgres and a date string on SQLite
+type periodStart time.Time
+
+func (p *periodStart) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time:
+		*p = periodStart(v)
+	case string:
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse period start %q", v)
+		}
+		*p = periodStart(t)
+	default:
+		return errors.Errorf("unsupported period start %T", value)
+	}
+	return nil
+}
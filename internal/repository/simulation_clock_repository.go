@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"billing_enginee/internal/model"
+	"billing_enginee/pkg"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// simulationClockID is the ID of the single row of the simulated clock
+const simulationClockID = 1
+
+// SimulationClockRepository stores how far the simulated clock is ahead of the system clock. The clock is shared
+// by the tenants, its row is not scoped.
+type SimulationClockRepository interface {
+	// GetOffset returns the stored offset, zero when the clock was never advanced
+	GetOffset(ctx context.Context) (time.Duration, error)
+	SaveOffset(ctx context.Context, offset time.Duration) error
+}
+
+type simulationClockRepository struct {
+	db *gorm.DB
+}
+
+func NewSimulationClockRepository(db *gorm.DB) SimulationClockRepository {
+	return &simulationClockRepository{
+		db: db,
+	}
+}
+
+func (r *simulationClockRepository) GetOffset(ctx context.Context) (time.Duration, error) {
+	var clockModel model.SimulationClock
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Where("id = ?", simulationClockID).Limit(1).Find(&clockModel).Error; err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to get simulation clock offset")
+		return 0, errors.Wrap(err, "failed to get simulation clock offset")
+	}
+	return time.Duration(clockModel.OffsetSeconds) * time.Second, nil
+}
+
+func (r *simulationClockRepository) SaveOffset(ctx context.Context, offset time.Duration) error {
+	clockModel := &model.SimulationClock{
+		ID:            simulationClockID,
+		OffsetSeconds: int64(offset / time.Second),
+		UpdatedAt:     time.Now(),
+	}
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"offset_seconds", "updated_at"}),
+	}).Create(clockModel).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"offset": offset.String(),
+			"error":  err,
+		}).Error("Failed to save simulation clock offset")
+		return errors.Wrap(err, "failed to save simulation clock offset")
+	}
+	return nil
+}
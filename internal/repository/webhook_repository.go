@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/model"
+	"billing_enginee/pkg"
+	"context"
+	"time"
+
+	"github.com/pkg/errors" // Use the correct errors package
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	SaveSubscription(ctx context.Context, subscription *entity.WebhookSubscription) error
+	GetSubscriptionByID(ctx context.Context, subscriptionID uint) (*entity.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context) ([]*entity.WebhookSubscription, error)
+	GetActiveSubscriptions(ctx context.Context) ([]*entity.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, subscriptionID uint) error
+	SaveDeliveries(ctx context.Context, deliveries []*entity.WebhookDelivery) error
+	HasDeliveriesForEvent(ctx context.Context, eventID string) (bool, error)
+	GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*entity.WebhookDelivery, error)
+	GetDeliveriesBySubscription(ctx context.Context, subscriptionID uint, limit int) ([]*entity.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{
+		db: db,
+	}
+}
+
+func (r *webhookRepository) SaveSubscription(ctx context.Context, subscription *entity.WebhookSubscription) error {
+	subscriptionModel := subscription.ToModel()
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Create(&subscriptionModel).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"url":   subscriptionModel.URL,
+			"error": err,
+		}).Error("Failed to save webhook subscription")
+		return errors.Wrap(err, "failed to save webhook subscription")
+	}
+
+	if err := recordAudit(ctx, tx, auditChange{
+		entityType: enum.AuditEntityWebhookSubscription,
+		entityID:   subscriptionModel.ID,
+		action:     enum.AuditActionCreate,
+		after:      webhookSubscriptionSnapshot(subscriptionModel),
+		reason:     "webhook subscription created",
+	}); err != nil {
+		return err
+	}
+
+	subscription.SetID(subscriptionModel.ID)
+	return nil
+}
+
+func (r *webhookRepository) GetSubscriptionByID(ctx context.Context, subscriptionID uint) (*entity.WebhookSubscription, error) {
+	var subscriptionModel model.WebhookSubscription
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.First(&subscriptionModel, subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			pkg.Logger(ctx).WithField("subscriptionID", subscriptionID).Info("Webhook subscription not found")
+			return nil, entity.ErrWebhookSubscriptionNotFound
+		}
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"subscriptionID": subscriptionID,
+			"error":          err,
+		}).Error("Failed to retrieve webhook subscription")
+		return nil, errors.Wrap(err, "failed to retrieve webhook subscription")
+	}
+
+	return entity.MakeWebhookSubscription(&subscriptionModel)
+}
+
+func (r *webhookRepository) GetSubscriptions(ctx context.Context) ([]*entity.WebhookSubscription, error) {
+	return r.findSubscriptions(GetDB(ctx, r.db))
+}
+
+func (r *webhookRepository) GetActiveSubscriptions(ctx context.Context) ([]*entity.WebhookSubscription, error) {
+	return r.findSubscriptions(GetDB(ctx, r.db).Where("active = ?", true))
+}
+
+func (r *webhookRepository) findSubscriptions(tx *gorm.DB) ([]*entity.WebhookSubscription, error) {
+	var subscriptionModels []model.WebhookSubscription
+	if err := tx.Order("id ASC").Find(&subscriptionModels).Error; err != nil {
+		pkg.Logger(tx.Statement.Context).WithError(err).Error("Failed to retrieve webhook subscriptions")
+		return nil, errors.Wrap(err, "failed to retrieve webhook subscriptions")
+	}
+
+	subscriptions := make([]*entity.WebhookSubscription, len(subscriptionModels))
+	for i := range subscriptionModels {
+		subscription, err := entity.MakeWebhookSubscription(&subscriptionModels[i])
+		if err != nil {
+			return nil, err
+		}
+		subscriptions[i] = subscription
+	}
+	return subscriptions, nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, subscriptionID uint) error {
+	tx := GetDB(ctx, r.db)
+
+	var subscriptionModel model.WebhookSubscription
+	if err := tx.First(&subscriptionModel, subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			pkg.Logger(ctx).WithField("subscriptionID", subscriptionID).Info("Webhook subscription not found")
+			return entity.ErrWebhookSubscriptionNotFound
+		}
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"subscriptionID": subscriptionID,
+			"error":          err,
+		}).Error("Failed to retrieve webhook subscription before delete")
+		return errors.Wrap(err, "failed to retrieve webhook subscription before delete")
+	}
+
+	if err := tx.Delete(&model.WebhookSubscription{}, subscriptionID).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"subscriptionID": subscriptionID,
+			"error":          err,
+		}).Error("Failed to delete webhook subscription")
+		return errors.Wrap(err, "failed to delete webhook subscription")
+	}
+
+	return recordAudit(ctx, tx, auditChange{
+		entityType: enum.AuditEntityWebhookSubscription,
+		entityID:   subscriptionID,
+		action:     enum.AuditActionDelete,
+		before:     webhookSubscriptionSnapshot(&subscriptionModel),
+		reason:     "webhook subscription deleted",
+	})
+}
+
+func (r *webhookRepository) SaveDeliveries(ctx context.Context, deliveries []*entity.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	tx := GetDB(ctx, r.db)
+	deliveryModels := make([]model.WebhookDelivery, len(deliveries))
+	for i, delivery := range deliveries {
+		deliveryModels[i] = *delivery.ToModel()
+	}
+
+	if err := tx.Omit("Subscription").Create(&deliveryModels).Error; err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to save webhook deliveries")
+		return errors.Wrap(err, "failed to save webhook deliveries")
+	}
+
+	for i, deliveryModel := range deliveryModels {
+		deliveries[i].SetID(deliveryModel.ID)
+	}
+	return nil
+}
+
+func (r *webhookRepository) HasDeliveriesForEvent(ctx context.Context, eventID string) (bool, error) {
+	var count int64
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Model(&model.WebhookDelivery{}).Where("event_id = ?", eventID).Count(&count).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"eventID": eventID,
+			"error":   err,
+		}).Error("Failed to count webhook deliveries of event")
+		return false, errors.Wrap(err, "failed to count webhook deliveries of event")
+	}
+
+	return count > 0, nil
+}
+
+// GetDueDeliveries returns the pending deliveries whose next attempt is due, oldest first
+func (r *webhookRepository) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*entity.WebhookDelivery, error) {
+	var deliveryModels []model.WebhookDelivery
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Preload("Subscription").
+		Where("status = ? AND next_attempt_at <= ?", "pending", now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&deliveryModels).Error; err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to retrieve due webhook deliveries")
+		return nil, errors.Wrap(err, "failed to retrieve due webhook deliveries")
+	}
+
+	return makeWebhookDeliveries(deliveryModels)
+}
+
+// GetDeliveriesBySubscription returns the delivery log of a subscription, newest first
+func (r *webhookRepository) GetDeliveriesBySubscription(ctx context.Context, subscriptionID uint, limit int) ([]*entity.WebhookDelivery, error) {
+	var deliveryModels []model.WebhookDelivery
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Where("subscription_id = ?", subscriptionID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&deliveryModels).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"subscriptionID": subscriptionID,
+			"error":          err,
+		}).Error("Failed to retrieve webhook deliveries")
+		return nil, errors.Wrap(err, "failed to retrieve webhook deliveries")
+	}
+
+	return makeWebhookDeliveries(deliveryModels)
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	deliveryModel := delivery.ToModel()
+	tx := GetDB(ctx, r.db)
+
+	if err := tx.Model(&model.WebhookDelivery{}).Where("id = ?", deliveryModel.ID).Updates(map[string]interface{}{
+		"status":          deliveryModel.Status,
+		"attempts":        deliveryModel.Attempts,
+		"next_attempt_at": deliveryModel.NextAttemptAt,
+		"response_status": deliveryModel.ResponseStatus,
+		"last_error":      deliveryModel.LastError,
+		"delivered_at":    deliveryModel.DeliveredAt,
+	}).Error; err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"deliveryID": deliveryModel.ID,
+			"error":      err,
+		}).Error("Failed to update webhook delivery")
+		return errors.Wrap(err, "failed to update webhook delivery")
+	}
+
+	return nil
+}
+
+func makeWebhookDeliveries(deliveryModels []model.WebhookDelivery) ([]*entity.WebhookDelivery, error) {
+	deliveries := make([]*entity.WebhookDelivery, len(deliveryModels))
+	for i := range deliveryModels {
+		delivery, err := entity.MakeWebhookDelivery(&deliveryModels[i])
+		if err != nil {
+			return nil, err
+		}
+		deliveries[i] = delivery
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,29 @@
+package runner
+
+import (
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"context"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+// RegisterNotificationDeliveryScheduler schedules a task on spec, every minute by default, to send the queued notifications.
+// Runs never overlap, a slow provider delays the next run instead of sending the same notifications twice.
+func RegisterNotificationDeliveryScheduler(scheduler *cron.Cron, spec string, notificationUsecase usecase.NotificationUsecase) {
+	job := cron.NewChain(cron.SkipIfStillRunning(cron.DefaultLogger)).Then(cron.FuncJob(func() {
+		ctx, span := pkg.StartSpan(pkg.WithAllTenants(context.Background()), "job deliver_notifications")
+		err := notificationUsecase.DeliverPending(ctx, time.Now())
+		pkg.EndSpan(span, err)
+		if err != nil {
+			log.WithError(err).Error("Error running notification delivery task")
+		}
+	}))
+
+	_, err := scheduler.AddJob(spec, job)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to schedule notification delivery task")
+	}
+}
@@ -0,0 +1,26 @@
+package runner
+
+import (
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"context"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+// RegisterWebhookDeliveryScheduler schedules a task on spec, every minute by default, to send due webhook deliveries.
+func RegisterWebhookDeliveryScheduler(scheduler *cron.Cron, spec string, webhookUsecase usecase.WebhookUsecase) {
+	_, err := scheduler.AddFunc(spec, func() {
+		ctx, span := pkg.StartSpan(pkg.WithAllTenants(context.Background()), "job deliver_webhooks")
+		err := webhookUsecase.DeliverPending(ctx, time.Now())
+		pkg.EndSpan(span, err)
+		if err != nil {
+			log.WithError(err).Error("Error running webhook delivery task")
+		}
+	})
+	if err != nil {
+		log.WithError(err).Fatal("Failed to schedule webhook delivery task")
+	}
+}
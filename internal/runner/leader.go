@@ -0,0 +1,23 @@
+package runner
+
+import (
+	"billing_enginee/pkg"
+	"context"
+
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+// OnLeader wraps the scheduled jobs so only the leader among the replicas runs them, the others skip their turn.
+// Leadership is checked on every run, so another replica takes over the next runs when the leader goes away.
+func OnLeader(leader pkg.Leader) cron.JobWrapper {
+	return func(job cron.Job) cron.Job {
+		return cron.FuncJob(func() {
+			if !leader.IsLeader(context.Background()) {
+				log.Debug("Skipping scheduled job, another instance is the scheduler leader")
+				return
+			}
+			job.Run()
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package runner
+
+import (
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"context"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+// outboxRelayBatchSize caps how many outbox messages a single relay run publishes
+const outboxRelayBatchSize = 500
+
+// RegisterOutboxRelayScheduler schedules a task on spec, every 5 seconds by default, that relays outbox messages to the broker.
+// Runs never overlap, otherwise two runs could publish the messages of one loan out of order.
+func RegisterOutboxRelayScheduler(scheduler *cron.Cron, spec string, outboxUsecase usecase.OutboxUsecase) {
+	job := cron.NewChain(cron.SkipIfStillRunning(cron.DefaultLogger)).Then(cron.FuncJob(func() {
+		ctx, span := pkg.StartSpan(pkg.WithAllTenants(context.Background()), "job relay_outbox")
+		_, err := outboxUsecase.RelayPending(ctx, time.Now(), outboxRelayBatchSize)
+		pkg.EndSpan(span, err)
+		if err != nil {
+			log.WithError(err).Error("Error running outbox relay task")
+		}
+	}))
+
+	_, err := scheduler.AddJob(spec, job)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to schedule outbox relay task")
+	}
+}
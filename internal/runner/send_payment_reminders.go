@@ -0,0 +1,34 @@
+package runner
+
+import (
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RegisterPaymentReminderScheduler schedules a task on spec, daily at 08:00 by default, in the timezone of every tenant
+// to queue the reminders of the installments falling due after the business date of the clock.
+func RegisterPaymentReminderScheduler(scheduler *cron.Cron, spec string, notificationUsecase usecase.NotificationUsecase, tenants tenant.Registry, clock pkg.Clock) {
+	for _, t := range tenants.All() {
+		t := t
+		_, err := scheduler.AddFunc("CRON_TZ="+t.Timezone+" "+spec, func() {
+			log.WithField("tenantID", t.ID).Info("Running daily payment reminder task...")
+			ctx, span := pkg.StartSpan(pkg.NewTenantContext(t.ID), "job send_payment_reminders", attribute.String("tenant.id", t.ID))
+			err := notificationUsecase.QueueUpcomingReminders(ctx, t.Now(clock))
+			pkg.EndSpan(span, err)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"tenantID": t.ID,
+					"error":    err,
+				}).Error("Error running daily payment reminder task")
+			}
+		})
+		if err != nil {
+			log.WithField("tenantID", t.ID).WithError(err).Fatal("Failed to schedule daily payment reminder task")
+		}
+	}
+}
@@ -2,23 +2,100 @@
 package runner
 
 import (
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/metrics"
+	"billing_enginee/internal/tenant"
 	"billing_enginee/internal/usecase"
-	"time"
+	"billing_enginee/pkg"
+	"strings"
+	"sync"
 
+	"github.com/pkg/errors"
 	"github.com/robfig/cron/v3"
 	log "github.com/sirupsen/logrus"
-	"gorm.io/gorm"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// RegisterUpdatePaymentStatusScheduler schedules a daily task at midnight UTC+7 to run the payment use case.
-func RegisterUpdatePaymentStatusScheduler(scheduler *cron.Cron, paymentUsecase usecase.PaymentUsecase, db *gorm.DB) {
-	_, err := scheduler.AddFunc("0 0 * * *", func() {
-		log.Info("Running daily payment task...")
-		if err := paymentUsecase.UpdatePaymentStatus(db, time.Now()); err != nil {
-			log.WithError(err).Error("Error running daily payment task")
+// RegisterUpdatePaymentStatusScheduler schedules a task on spec, daily at midnight by default, in the timezone of every tenant
+// to run the payment use case for the business date of the clock. Every run is recorded in the job runs.
+func RegisterUpdatePaymentStatusScheduler(scheduler *cron.Cron, spec string, jobRunUsecase usecase.JobRunUsecase, tenants tenant.Registry, clock pkg.Clock) {
+	for _, t := range tenants.All() {
+		t := t
+		_, err := scheduler.AddFunc("CRON_TZ="+t.Timezone+" "+spec, func() {
+			log.WithField("tenantID", t.ID).Info("Running daily payment task...")
+			ctx, span := pkg.StartSpan(pkg.NewTenantContext(t.ID), "job "+metrics.JobUpdatePaymentStatus, attribute.String("tenant.id", t.ID))
+			_, err := jobRunUsecase.RunUpdatePaymentStatus(ctx, t.Now(clock), enum.JobRunTriggerSchedule)
+			pkg.EndSpan(span, err)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"tenantID": t.ID,
+					"error":    err,
+				}).Error("Error running daily payment task")
+			}
+		})
+		if err != nil {
+			log.WithField("tenantID", t.ID).WithError(err).Fatal("Failed to schedule daily payment task")
 		}
-	})
-	if err != nil {
-		log.WithError(err).Fatal("Failed to schedule daily payment task")
 	}
 }
+
+// CatchUpStatus reports how the catch-up of the daily payment task went, it runs in the background
+type CatchUpStatus struct {
+	mu  sync.Mutex
+	err error
+}
+
+// Err returns why the catch-up failed, nil while it runs, once it completed and when it never ran
+func (s *CatchUpStatus) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *CatchUpStatus) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// StartCatchUpUpdatePaymentStatus runs CatchUpUpdatePaymentStatus in the background on the leader only, so the
+// service serves and answers its probes meanwhile. Its outcome is recorded in the status.
+func StartCatchUpUpdatePaymentStatus(status *CatchUpStatus, leader pkg.Leader, jobRunUsecase usecase.JobRunUsecase, tenants tenant.Registry, maxDays int) {
+	job := OnLeader(leader)(cron.FuncJob(func() {
+		status.setErr(CatchUpUpdatePaymentStatus(jobRunUsecase, tenants, maxDays))
+	}))
+	go job.Run()
+}
+
+// CatchUpUpdatePaymentStatus runs the daily payment task of every tenant for the business dates it missed while
+// the service was down, at most maxDays of them per tenant. A tenant that fails does not stop the others, the
+// returned error names every tenant that failed. A tenant whose job already runs elsewhere is skipped.
+func CatchUpUpdatePaymentStatus(jobRunUsecase usecase.JobRunUsecase, tenants tenant.Registry, maxDays int) error {
+	var failed []string
+	for _, t := range tenants.All() {
+		ctx, span := pkg.StartSpan(pkg.NewTenantContext(t.ID), "job "+metrics.JobUpdatePaymentStatus+" catch-up", attribute.String("tenant.id", t.ID))
+		runs, err := jobRunUsecase.CatchUpUpdatePaymentStatus(ctx, maxDays)
+		pkg.EndSpan(span, err)
+		if errors.Is(err, usecase.ErrJobRunning) {
+			log.WithField("tenantID", t.ID).Warn("Daily payment task already running, not caught up")
+			continue
+		}
+		if err != nil {
+			log.WithFields(log.Fields{
+				"tenantID": t.ID,
+				"error":    err,
+			}).Error("Error catching up daily payment task")
+			failed = append(failed, t.ID)
+			continue
+		}
+		log.WithFields(log.Fields{
+			"tenantID": t.ID,
+			"runs":     len(runs),
+		}).Info("Daily payment task caught up")
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("failed to catch up the daily payment task of tenants %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
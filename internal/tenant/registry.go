@@ -0,0 +1,104 @@
+package tenant
+
+import (
+	"billing_enginee/pkg"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Registry holds the configuration of every tenant of the deployment
+type Registry interface {
+	Get(tenantID string) (*Tenant, error)
+	// ForContext returns the tenant of the request, the default tenant when the context has none
+	ForContext(ctx context.Context) (*Tenant, error)
+	All() []*Tenant
+}
+
+type registry struct {
+	tenants []*Tenant
+	byID    map[string]*Tenant
+}
+
+// NewRegistry validates the tenants and fills in their defaults
+func NewRegistry(tenants []*Tenant) (Registry, error) {
+	if len(tenants) == 0 {
+		return nil, fmt.Errorf("at least one tenant is required")
+	}
+
+	byID := make(map[string]*Tenant, len(tenants))
+	for _, t := range tenants {
+		if err := t.init(); err != nil {
+			return nil, err
+		}
+		if _, exists := byID[t.ID]; exists {
+			return nil, fmt.Errorf("duplicate tenant %q", t.ID)
+		}
+		byID[t.ID] = t
+	}
+
+	return &registry{
+		tenants: tenants,
+		byID:    byID,
+	}, nil
+}
+
+// DefaultRegistry serves a single tenant with the default configuration
+func DefaultRegistry() Registry {
+	r, err := NewRegistry([]*Tenant{{ID: DefaultTenantID, Name: "Default"}})
+	if err != nil {
+		log.WithError(err).Fatal("Failed to build the default tenant registry")
+	}
+	return r
+}
+
+// NewRegistryFromFile reads the tenants from the JSON file at path, serving a single default tenant when path
+// is empty. Tenants that do not configure a timezone get defaultTimezone.
+func NewRegistryFromFile(path string, defaultTimezone string) (Registry, error) {
+	if path == "" {
+		return NewRegistry([]*Tenant{{ID: DefaultTenantID, Name: "Default", Timezone: defaultTimezone}})
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenants file: %w", err)
+	}
+
+	var tenants []*Tenant
+	if err := json.Unmarshal(content, &tenants); err != nil {
+		return nil, fmt.Errorf("failed to parse tenants file: %w", err)
+	}
+
+	for _, t := range tenants {
+		if t.Timezone == "" {
+			t.Timezone = defaultTimezone
+		}
+	}
+
+	log.WithField("count", len(tenants)).Info("Loaded tenants configuration")
+	return NewRegistry(tenants)
+}
+
+func (r *registry) Get(tenantID string) (*Tenant, error) {
+	t, exists := r.byID[tenantID]
+	if !exists {
+		log.WithField("tenantID", tenantID).Warn("Unknown tenant")
+		return nil, ErrUnknownTenant
+	}
+	return t, nil
+}
+
+func (r *registry) ForContext(ctx context.Context) (*Tenant, error) {
+	tenantID := pkg.GetTenantID(ctx)
+	if tenantID == "" {
+		tenantID = DefaultTenantID
+	}
+	return r.Get(tenantID)
+}
+
+func (r *registry) All() []*Tenant {
+	return r.tenants
+}
@@ -0,0 +1,104 @@
+package tenant
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/pkg"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultTenantID is used by single brand deployments and by credentials that do not name a tenant
+	DefaultTenantID = "default"
+	// DefaultTimezone is the business timezone of tenants that do not configure one
+	DefaultTimezone = "Asia/Jakarta"
+	// DefaultMinPendingInstallments is the number of missed installments that makes a customer delinquent
+	DefaultMinPendingInstallments = 2
+)
+
+var (
+	ErrUnknownTenant     = errors.New("unknown tenant")
+	ErrNoMatchingProduct = entity.NewValidationError("loan terms do not match any product of the tenant")
+)
+
+// Product is a loan offering of a tenant, loans must match the term and rates of one of its products
+type Product struct {
+	Code      string  `json:"code"`
+	TermWeeks int     `json:"term_weeks"`
+	Rates     float64 `json:"rates"`
+	MinAmount float64 `json:"min_amount"`
+	MaxAmount float64 `json:"max_amount"` // 0 means no upper limit
+}
+
+// DelinquencyRules decide when a customer of the tenant is delinquent
+type DelinquencyRules struct {
+	MinPendingInstallments int `json:"min_pending_installments"`
+}
+
+// Tenant is a lending brand served by the deployment, with its own data and configuration
+type Tenant struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Timezone    string           `json:"timezone"`
+	Products    []Product        `json:"products"`
+	Delinquency DelinquencyRules `json:"delinquency"`
+
+	location *time.Location
+}
+
+// init validates the configuration and fills in the defaults
+func (t *Tenant) init() error {
+	if t.ID == "" {
+		return errors.New("tenant id cannot be empty")
+	}
+	if len(t.ID) > 32 {
+		return fmt.Errorf("tenant id %q is longer than 32 characters", t.ID)
+	}
+	if t.Timezone == "" {
+		t.Timezone = DefaultTimezone
+	}
+	location, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone of tenant %q: %w", t.ID, err)
+	}
+	t.location = location
+
+	if t.Delinquency.MinPendingInstallments <= 0 {
+		t.Delinquency.MinPendingInstallments = DefaultMinPendingInstallments
+	}
+	for _, product := range t.Products {
+		if product.TermWeeks <= 0 {
+			return fmt.Errorf("product %q of tenant %q must have a positive term", product.Code, t.ID)
+		}
+	}
+	return nil
+}
+
+// Location returns the business timezone of the tenant
+func (t *Tenant) Location() *time.Location {
+	return t.location
+}
+
+// Now returns the current time of the clock in the business timezone of the tenant
+func (t *Tenant) Now(clock pkg.Clock) time.Time {
+	return clock.Now().In(t.location)
+}
+
+// MatchProduct returns the product the loan terms belong to. Tenants without products accept any terms.
+func (t *Tenant) MatchProduct(amount float64, termWeeks int, rates float64) (*Product, error) {
+	if len(t.Products) == 0 {
+		return nil, nil
+	}
+
+	for i, product := range t.Products {
+		if product.TermWeeks != termWeeks || product.Rates != rates {
+			continue
+		}
+		if amount < product.MinAmount || (product.MaxAmount > 0 && amount > product.MaxAmount) {
+			continue
+		}
+		return &t.Products[i], nil
+	}
+	return nil, ErrNoMatchingProduct
+}
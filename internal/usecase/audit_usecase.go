@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/repository"
+	"billing_enginee/pkg"
+	"context"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var ErrInvalidAuditEntity = entity.NewValidationError("entity must be one of customer, loan, payment or webhook_subscription")
+
+type AuditUsecase interface {
+	GetEntries(ctx context.Context, entityType string, entityID uint) ([]*entity.AuditEntry, error)
+}
+
+type auditUsecase struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditUsecase(auditRepo repository.AuditRepository) AuditUsecase {
+	return &auditUsecase{
+		auditRepo: auditRepo,
+	}
+}
+
+// GetEntries returns the audit trail of an entity, oldest first
+func (u *auditUsecase) GetEntries(ctx context.Context, entityType string, entityID uint) (_ []*entity.AuditEntry, err error) {
+	ctx, span := pkg.StartSpan(ctx, "AuditUsecase.GetEntries")
+	defer func() { pkg.EndSpan(span, err) }()
+
+	auditEntityType, err := enum.ParseAuditEntityType(entityType)
+	if err != nil {
+		return nil, ErrInvalidAuditEntity
+	}
+
+	entries, err := u.auditRepo.GetEntries(ctx, auditEntityType, entityID)
+	if err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"entityType": entityType,
+			"entityID":   entityID,
+			"error":      err,
+		}).Error("Failed to get audit entries")
+		return nil, errors.Wrap(err, "failed to get audit entries")
+	}
+	return entries, nil
+}
@@ -1,41 +1,55 @@
 package usecase
 
 import (
+	"billing_enginee/internal/entity"
 	"billing_enginee/internal/repository"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/pkg"
+	"context"
 
-	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors" // Use the correct package for error wrapping
 	log "github.com/sirupsen/logrus"
-	"gorm.io/gorm"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type CustomerUsecase interface {
-	IsDelinquent(c *gin.Context, customerID uint) (bool, error)
+	IsDelinquent(ctx context.Context, customerID uint) (bool, error)
 }
 
 type customerUsecase struct {
 	customerRepo repository.CustomerRepository
+	tenants      tenant.Registry
 }
 
-func NewCustomerUsecase(customerRepo repository.CustomerRepository) CustomerUsecase {
+func NewCustomerUsecase(customerRepo repository.CustomerRepository, tenants tenant.Registry) CustomerUsecase {
 	return &customerUsecase{
 		customerRepo: customerRepo,
+		tenants:      tenants,
 	}
 }
 
-func (u *customerUsecase) IsDelinquent(c *gin.Context, customerID uint) (bool, error) {
-	customer, err := u.customerRepo.GetCustomerByID(c, customerID)
+// IsDelinquent applies the delinquency rules of the tenant of the request
+func (u *customerUsecase) IsDelinquent(ctx context.Context, customerID uint) (_ bool, err error) {
+	ctx, span := pkg.StartSpan(ctx, "CustomerUsecase.IsDelinquent", attribute.Int64("customer.id", int64(customerID)))
+	defer func() { pkg.EndSpan(span, err) }()
+
+	customerTenant, err := u.tenants.ForContext(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to resolve tenant of customer")
+	}
+
+	customer, err := u.customerRepo.GetCustomerByExternalID(ctx, customerID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			log.WithField("customerID", customerID).Info("Customer not found")
+		if errors.Is(err, entity.ErrCustomerNotFound) {
+			pkg.Logger(ctx).WithField("customerID", customerID).Info("Customer not found")
 			return false, nil
 		}
-		log.WithFields(log.Fields{
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"customerID": customerID,
 			"error":      err,
 		}).Error("Failed to retrieve customer for delinquency check")
 		return false, errors.Wrap(err, "failed to retrieve customer for delinquency check")
 	}
 
-	return customer.IsDelinquent(), nil
+	return customer.IsDelinquent(customerTenant.Delinquency.MinPendingInstallments), nil
 }
@@ -0,0 +1,12 @@
+package usecase
+
+import (
+	"billing_enginee/internal/entity"
+	"context"
+)
+
+// EventPublisher publishes domain events raised by the usecases.
+// Implementations must write through the request transaction so events are only kept when the change is committed.
+type EventPublisher interface {
+	Publish(ctx context.Context, event *entity.Event) error
+}
@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/metrics"
+	"billing_enginee/internal/repository"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/pkg"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// MaxJobRuns bounds how many runs GetRuns returns
+const MaxJobRuns = 100
+
+var ErrInvalidJobRunLimit = entity.NewValidationError("limit must be between 1 and 100")
+var ErrFutureBusinessDate = entity.NewValidationError("as_of must not be after the current business date")
+var ErrJobRunning = entity.NewConflictError("the job is already running for the tenant, retry later")
+
+// JobRunUsecase runs the daily payment status update for the tenant of the context and records every run,
+// so missed business dates can be found and caught up.
+type JobRunUsecase interface {
+	RunUpdatePaymentStatus(ctx context.Context, businessDate time.Time, trigger enum.JobRunTrigger) (*entity.JobRun, error)
+	RerunUpdatePaymentStatus(ctx context.Context, businessDate time.Time) (*entity.JobRun, error)
+	CatchUpUpdatePaymentStatus(ctx context.Context, maxDays int) ([]*entity.JobRun, error)
+	GetRuns(ctx context.Context, limit int) ([]*entity.JobRun, error)
+}
+
+type jobRunUsecase struct {
+	jobRunRepo     repository.JobRunRepository
+	paymentUsecase PaymentUsecase
+	tenants        tenant.Registry
+	clock          pkg.Clock
+	jobLock        pkg.JobLock
+}
+
+// NewJobRunUsecase returns the job run use case. The job lock lets the job run once at a time per tenant, for
+// the scheduled, manual and simulated runs of every replica.
+func NewJobRunUsecase(jobRunRepo repository.JobRunRepository, paymentUsecase PaymentUsecase, tenants tenant.Registry, clock pkg.Clock, jobLock pkg.JobLock) JobRunUsecase {
+	return &jobRunUsecase{
+		jobRunRepo:     jobRunRepo,
+		paymentUsecase: paymentUsecase,
+		tenants:        tenants,
+		clock:          clock,
+		jobLock:        jobLock,
+	}
+}
+
+// RunUpdatePaymentStatus runs the payment status update as of the business date and records the run, failed
+// or not. A run where some loans failed is recorded as partially failed with the number of installments left
+// unchanged, and does not return an error. The error of an update that failed is returned with the run.
+// ErrJobRunning is returned without recording a run while another run of the tenant goes on. Runs joining a
+// transaction are only recorded when it commits.
+func (u *jobRunUsecase) RunUpdatePaymentStatus(ctx context.Context, businessDate time.Time, trigger enum.JobRunTrigger) (_ *entity.JobRun, err error) {
+	ctx, span := pkg.StartSpan(ctx, "JobRunUsecase.RunUpdatePaymentStatus",
+		attribute.String("job.business_date", businessDate.Format(time.DateOnly)),
+		attribute.String("job.trigger", trigger.String()))
+	defer func() { pkg.EndSpan(span, err) }()
+
+	unlock, err := u.lockJob(ctx)
+	if err != nil {
+		if errors.Is(err, ErrJobRunning) {
+			pkg.Logger(ctx).WithField("businessDate", businessDate.Format(time.DateOnly)).Warn("Payment status update already running, not run")
+		}
+		return nil, err
+	}
+	defer unlock()
+
+	return u.runUpdatePaymentStatus(ctx, businessDate, trigger)
+}
+
+// lockJob takes the job lock of the tenant of the context, it returns ErrJobRunning when another run holds it
+func (u *jobRunUsecase) lockJob(ctx context.Context) (func(), error) {
+	unlock, acquired, err := u.jobLock.TryLock(ctx, metrics.JobUpdatePaymentStatus+":"+pkg.GetTenantID(ctx))
+	if err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to take the job lock")
+		return nil, errors.Wrap(err, "failed to take the job lock")
+	}
+	if !acquired {
+		return nil, ErrJobRunning
+	}
+	return unlock, nil
+}
+
+// runUpdatePaymentStatus is RunUpdatePaymentStatus once the job lock is held
+func (u *jobRunUsecase) runUpdatePaymentStatus(ctx context.Context, businessDate time.Time, trigger enum.JobRunTrigger) (*entity.JobRun, error) {
+	tenantID := pkg.GetTenantID(ctx)
+	start := time.Now()
+	run := entity.StartJobRun(metrics.JobUpdatePaymentStatus, businessDate, trigger, start)
+	if err := u.jobRunRepo.SaveJobRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	update, jobErr := u.paymentUsecase.RunPaymentStatusUpdate(ctx, businessDate)
+	itemsProcessed, itemsFailed := 0, 0
+	runErr := jobErr
+	if update != nil {
+		itemsProcessed, itemsFailed = update.ItemsProcessed, update.ItemsFailed
+		if runErr == nil {
+			runErr = update.Err()
+		}
+	}
+	if jobErr == nil && runErr != nil {
+		run.FinishPartially(itemsProcessed, itemsFailed, runErr, time.Now())
+	} else {
+		run.Finish(itemsProcessed, itemsFailed, jobErr, time.Now())
+	}
+	metrics.ObserveJobRun(metrics.JobUpdatePaymentStatus, tenantID, start, runErr)
+
+	if err := u.jobRunRepo.UpdateJobRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	logger := pkg.Logger(ctx).WithFields(log.Fields{
+		"jobRunID":       run.GetID(),
+		"businessDate":   run.BusinessDate().Format(time.DateOnly),
+		"trigger":        trigger.String(),
+		"status":         run.Status(),
+		"itemsProcessed": itemsProcessed,
+		"itemsFailed":    itemsFailed,
+	})
+	switch {
+	case jobErr != nil:
+		logger.WithError(jobErr).Error("Payment status update failed")
+	case runErr != nil:
+		logger.WithError(runErr).Warn("Payment status update finished, some loans failed")
+	default:
+		logger.Info("Payment status update finished")
+	}
+	return run, jobErr
+}
+
+// RerunUpdatePaymentStatus runs the payment status update again as of a past or the current business date of the
+// tenant, on request of an operator
+func (u *jobRunUsecase) RerunUpdatePaymentStatus(ctx context.Context, businessDate time.Time) (*entity.JobRun, error) {
+	runTenant, err := u.tenants.ForContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve tenant of job")
+	}
+
+	now := runTenant.Now(u.clock)
+	asOf := time.Date(businessDate.Year(), businessDate.Month(), businessDate.Day(), 0, 0, 0, 0, runTenant.Location())
+	if asOf.After(now) {
+		return nil, ErrFutureBusinessDate
+	}
+	return u.RunUpdatePaymentStatus(ctx, asOf, enum.JobRunTriggerManual)
+}
+
+// CatchUpUpdatePaymentStatus runs the payment status update for every business date of the tenant since its
+// last completed run up to today, oldest first. Only the last maxDays dates are run when more were missed,
+// and only today when the job never completed. Dates where some loans failed are run to their end and caught
+// up past, it stops at the first date whose run fails. The job lock is held from finding the missed dates to
+// the end of the last run, ErrJobRunning is returned when another run of the tenant goes on.
+func (u *jobRunUsecase) CatchUpUpdatePaymentStatus(ctx context.Context, maxDays int) (_ []*entity.JobRun, err error) {
+	ctx, span := pkg.StartSpan(ctx, "JobRunUsecase.CatchUpUpdatePaymentStatus")
+	defer func() { pkg.EndSpan(span, err) }()
+
+	unlock, err := u.lockJob(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	runTenant, err := u.tenants.ForContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve tenant of job")
+	}
+
+	now := runTenant.Now(u.clock)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, runTenant.Location())
+	from := today
+	last, err := u.jobRunRepo.GetLastCompletedBusinessDate(ctx, metrics.JobUpdatePaymentStatus)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		from = time.Date(last.Year(), last.Month(), last.Day()+1, 0, 0, 0, 0, runTenant.Location())
+	}
+	if earliest := today.AddDate(0, 0, 1-maxDays); from.Before(earliest) {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"from":     from.Format(time.DateOnly),
+			"earliest": earliest.Format(time.DateOnly),
+		}).Warn("Too many missed business dates, only catching up the latest ones")
+		from = earliest
+	}
+
+	var runs []*entity.JobRun
+	for businessDate := from; !businessDate.After(today); businessDate = businessDate.AddDate(0, 0, 1) {
+		run, err := u.runUpdatePaymentStatus(ctx, businessDate, enum.JobRunTriggerCatchUp)
+		if run != nil {
+			runs = append(runs, run)
+		}
+		if err != nil {
+			return runs, errors.Wrapf(err, "failed to catch up payment status update of %s", businessDate.Format(time.DateOnly))
+		}
+	}
+	return runs, nil
+}
+
+// GetRuns returns the latest runs of the payment status update of the tenant, newest first
+func (u *jobRunUsecase) GetRuns(ctx context.Context, limit int) (_ []*entity.JobRun, err error) {
+	ctx, span := pkg.StartSpan(ctx, "JobRunUsecase.GetRuns")
+	defer func() { pkg.EndSpan(span, err) }()
+
+	if limit < 1 || limit > MaxJobRuns {
+		return nil, ErrInvalidJobRunLimit
+	}
+
+	runs, err := u.jobRunRepo.GetJobRuns(ctx, metrics.JobUpdatePaymentStatus, limit)
+	if err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to get job runs")
+		return nil, errors.Wrap(err, "failed to get job runs")
+	}
+	return runs, nil
+}
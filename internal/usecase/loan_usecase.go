@@ -2,19 +2,22 @@ package usecase
 
 import (
 	"billing_enginee/internal/entity"
+	"billing_enginee/internal/metrics"
 	"billing_enginee/internal/repository"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/pkg"
+	"context"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors" // Import for error wrapping
 	log "github.com/sirupsen/logrus"
-	"gorm.io/gorm"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type LoanUsecase interface {
-	CreateLoan(c *gin.Context, customerID uint, name string, email string, amount float64, termWeeks int, rates float64) (*LoanResponse, error)
-	GetOutstanding(c *gin.Context, loanID uint) (*OutstandingResponse, error)
-	MakePayment(c *gin.Context, loanID uint, amount float64) error
+	CreateLoan(ctx context.Context, customerID uint, name string, email string, phone string, amount float64, termWeeks int, rates float64) (*LoanResponse, error)
+	GetOutstanding(ctx context.Context, loanID uint) (*OutstandingResponse, error)
+	MakePayment(ctx context.Context, loanID uint, amount float64) error
 }
 
 type OutstandingResponse struct {
@@ -26,20 +29,32 @@ type OutstandingResponse struct {
 }
 
 type loanUsecase struct {
-	loanRepo     repository.LoanRepository
-	customerRepo repository.CustomerRepository
-	paymentRepo  repository.PaymentRepository
+	uow            pkg.UnitOfWork
+	loanRepo       repository.LoanRepository
+	customerRepo   repository.CustomerRepository
+	paymentRepo    repository.PaymentRepository
+	eventPublisher EventPublisher
+	tenants        tenant.Registry
+	clock          pkg.Clock
 }
 
 func NewLoanUsecase(
+	uow pkg.UnitOfWork,
 	loanRepo repository.LoanRepository,
 	customerRepo repository.CustomerRepository,
 	paymentrepo repository.PaymentRepository,
+	eventPublisher EventPublisher,
+	tenants tenant.Registry,
+	clock pkg.Clock,
 ) LoanUsecase {
 	return &loanUsecase{
-		loanRepo:     loanRepo,
-		customerRepo: customerRepo,
-		paymentRepo:  paymentrepo,
+		uow:            uow,
+		loanRepo:       loanRepo,
+		customerRepo:   customerRepo,
+		paymentRepo:    paymentrepo,
+		eventPublisher: eventPublisher,
+		tenants:        tenants,
+		clock:          clock,
 	}
 }
 
@@ -51,20 +66,53 @@ type LoanResponse struct {
 	DueDate           time.Time
 }
 
-func (u *loanUsecase) CreateLoan(c *gin.Context, customerID uint, name string, email string, amount float64, termWeeks int, rates float64) (*LoanResponse, error) {
-	customer, err := u.customerRepo.GetCustomerByID(c, customerID)
+// CreateLoan saves the customer, the loan, its installments and the loan created event in one unit of work
+func (u *loanUsecase) CreateLoan(ctx context.Context, customerID uint, name string, email string, phone string, amount float64, termWeeks int, rates float64) (_ *LoanResponse, err error) {
+	ctx, span := pkg.StartSpan(ctx, "LoanUsecase.CreateLoan", attribute.Int64("customer.id", int64(customerID)))
+	defer func() { pkg.EndSpan(span, err) }()
+
+	var response *LoanResponse
+	err = u.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		response, err = u.createLoan(ctx, customerID, name, email, phone, amount, termWeeks, rates)
+		return err
+	})
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			customer = entity.CreateCustomer(customerID, name, email)
-			if saveErr := u.customerRepo.SaveCustomer(c, customer); saveErr != nil {
-				log.WithFields(log.Fields{
+		return nil, err
+	}
+	return response, nil
+}
+
+func (u *loanUsecase) createLoan(ctx context.Context, customerID uint, name string, email string, phone string, amount float64, termWeeks int, rates float64) (*LoanResponse, error) {
+	loanTenant, err := u.tenants.ForContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve tenant of loan")
+	}
+
+	// Tenants that configure products only lend on the terms of one of them
+	if _, err := loanTenant.MatchProduct(amount, termWeeks, rates); err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"tenantID":  loanTenant.ID,
+			"amount":    amount,
+			"termWeeks": termWeeks,
+			"rates":     rates,
+		}).Info("Loan terms do not match any product")
+		return nil, err
+	}
+
+	customer, err := u.customerRepo.GetCustomerByExternalID(ctx, customerID)
+	if err != nil {
+		if errors.Is(err, entity.ErrCustomerNotFound) {
+			customer = entity.CreateCustomer(customerID, name, email, phone)
+			if saveErr := u.customerRepo.SaveCustomer(ctx, customer); saveErr != nil {
+				pkg.Logger(ctx).WithFields(log.Fields{
 					"customer": customer,
 					"error":    saveErr,
 				}).Error("Failed to save customer during loan creation")
 				return nil, errors.Wrap(saveErr, "failed to save customer during loan creation")
 			}
 		} else {
-			log.WithFields(log.Fields{
+			pkg.Logger(ctx).WithFields(log.Fields{
 				"customerID": customerID,
 				"error":      err,
 			}).Error("Failed to retrieve customer during loan creation")
@@ -72,10 +120,12 @@ func (u *loanUsecase) CreateLoan(c *gin.Context, customerID uint, name string, e
 		}
 	}
 
-	loan := entity.CreateLoan(customer.GetID(), amount, termWeeks, rates)
+	// The loan and its due dates follow the business date of the tenant
+	now := loanTenant.Now(u.clock)
+	loan := entity.CreateLoan(customer, amount, termWeeks, rates, now)
 
-	if err := u.loanRepo.SaveLoan(c, loan); err != nil {
-		log.WithFields(log.Fields{
+	if err := u.loanRepo.SaveLoan(ctx, loan); err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"loan":  loan,
 			"error": err,
 		}).Error("Failed to save loan")
@@ -89,7 +139,7 @@ func (u *loanUsecase) CreateLoan(c *gin.Context, customerID uint, name string, e
 		if week == 1 {
 			status = "outstanding"
 		}
-		dueDate := time.Now().AddDate(0, 0, 7*week)
+		dueDate := now.AddDate(0, 0, 7*week)
 		x, err := entity.CreatePayment(loan.GetID(), week, paymentAmount, dueDate, status)
 		if err != nil {
 			return nil, err
@@ -97,14 +147,22 @@ func (u *loanUsecase) CreateLoan(c *gin.Context, customerID uint, name string, e
 		payments = append(payments, x)
 	}
 
-	if err := u.paymentRepo.SavePayments(c, payments); err != nil {
-		log.WithFields(log.Fields{
+	if err := u.paymentRepo.SavePayments(ctx, payments); err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"payments": payments,
 			"error":    err,
 		}).Error("Failed to save payments")
 		return nil, errors.Wrap(err, "failed to save payments")
 	}
 
+	if err := u.eventPublisher.Publish(ctx, entity.NewLoanCreatedEvent(loan)); err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"loanID": loan.GetID(),
+			"error":  err,
+		}).Error("Failed to publish loan created event")
+		return nil, errors.Wrap(err, "failed to publish loan created event")
+	}
+
 	response := &LoanResponse{
 		LoanID:            loan.GetID(),
 		TotalAmount:       loan.TotalAmount(),
@@ -116,10 +174,13 @@ func (u *loanUsecase) CreateLoan(c *gin.Context, customerID uint, name string, e
 	return response, nil
 }
 
-func (u *loanUsecase) GetOutstanding(c *gin.Context, loanID uint) (*OutstandingResponse, error) {
-	loan, err := u.loanRepo.GetOutstandingPayments(c, loanID)
+func (u *loanUsecase) GetOutstanding(ctx context.Context, loanID uint) (_ *OutstandingResponse, err error) {
+	ctx, span := pkg.StartSpan(ctx, "LoanUsecase.GetOutstanding", attribute.Int64("loan.id", int64(loanID)))
+	defer func() { pkg.EndSpan(span, err) }()
+
+	loan, err := u.loanRepo.GetOutstandingPayments(ctx, loanID)
 	if err != nil {
-		log.WithFields(log.Fields{
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"loanID": loanID,
 			"error":  err,
 		}).Error("Failed to get outstanding payments for loan")
@@ -128,9 +189,10 @@ func (u *loanUsecase) GetOutstanding(c *gin.Context, loanID uint) (*OutstandingR
 
 	payments := loan.GetPayments()
 
-	if len(*payments) == 0 {
-		log.WithField("loanID", loanID).Info("No outstanding payments found")
-		return nil, nil
+	// A loan without pending or outstanding installments is loaded without payments
+	if payments == nil || len(*payments) == 0 {
+		pkg.Logger(ctx).WithField("loanID", loanID).Info("No outstanding payments found")
+		return nil, entity.ErrNoOutstandingPayment
 	}
 
 	var pendingPayments []entity.Payment
@@ -178,10 +240,35 @@ func (u *loanUsecase) GetOutstanding(c *gin.Context, loanID uint) (*OutstandingR
 	return response, nil
 }
 
-func (u *loanUsecase) MakePayment(c *gin.Context, loanID uint, amount float64) error {
-	loan, err := u.loanRepo.GetOutstandingPayments(c, loanID)
+// MakePayment marks the installments paid, publishes the payment received event and moves the loan on in one unit of work
+func (u *loanUsecase) MakePayment(ctx context.Context, loanID uint, amount float64) (err error) {
+	ctx, span := pkg.StartSpan(ctx, "LoanUsecase.MakePayment", attribute.Int64("loan.id", int64(loanID)))
+	defer func() { pkg.EndSpan(span, err) }()
+
+	if err := u.uow.Do(ctx, func(ctx context.Context) error {
+		return u.makePayment(ctx, loanID, amount)
+	}); err != nil {
+		return err
+	}
+
+	metrics.AddPaymentReceived(pkg.GetTenantID(ctx), amount)
+	return nil
+}
+
+func (u *loanUsecase) makePayment(ctx context.Context, loanID uint, amount float64) error {
+	paymentTenant, err := u.tenants.ForContext(ctx)
 	if err != nil {
-		log.WithFields(log.Fields{
+		return errors.Wrap(err, "failed to resolve tenant of payment")
+	}
+
+	// Concurrent payments of the loan would read the same outstanding installments
+	if err := u.loanRepo.LockLoan(ctx, loanID); err != nil {
+		return err
+	}
+
+	loan, err := u.loanRepo.GetOutstandingPayments(ctx, loanID)
+	if err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"loanID": loanID,
 			"error":  err,
 		}).Error("Failed to retrieve loan for making payment")
@@ -191,7 +278,7 @@ func (u *loanUsecase) MakePayment(c *gin.Context, loanID uint, amount float64) e
 	payments := loan.GetPayments()
 
 	if err := loan.ValidateAmount(amount); err != nil {
-		log.WithFields(log.Fields{
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"loanID": loanID,
 			"amount": amount,
 			"error":  err,
@@ -199,21 +286,31 @@ func (u *loanUsecase) MakePayment(c *gin.Context, loanID uint, amount float64) e
 		return errors.Wrap(err, "payment amount does not match outstanding balance")
 	}
 
-	if err := u.updatePaid(c, payments, amount); err != nil {
+	// The installments are paid at the business time of the tenant
+	paidWeeks, err := u.updatePaid(ctx, payments, amount, paymentTenant.Now(u.clock))
+	if err != nil {
 		return errors.Wrap(err, "failed to update payments to 'paid'")
 	}
 
-	if err := u.updateNextPayment(c, loan); err != nil {
+	if err := u.eventPublisher.Publish(ctx, entity.NewPaymentReceivedEvent(loanID, amount, paidWeeks)); err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"loanID": loanID,
+			"error":  err,
+		}).Error("Failed to publish payment received event")
+		return errors.Wrap(err, "failed to publish payment received event")
+	}
+
+	if err := u.updateNextPayment(ctx, loan); err != nil {
 		return errors.Wrap(err, "failed to update next payment or close loan")
 	}
 
 	return nil
 }
 
-func (u *loanUsecase) updateNextPayment(c *gin.Context, loan *entity.Loan) error {
-	nextPayment, err := u.paymentRepo.GetNextPayment(c, loan.GetID())
+func (u *loanUsecase) updateNextPayment(ctx context.Context, loan *entity.Loan) error {
+	nextPayment, err := u.paymentRepo.GetNextPayment(ctx, loan.GetID())
 	if err != nil {
-		log.WithFields(log.Fields{
+		pkg.Logger(ctx).WithFields(log.Fields{
 			"loanID": loan.GetID(),
 			"error":  err,
 		}).Error("Failed to retrieve next payment for updating")
@@ -222,7 +319,7 @@ func (u *loanUsecase) updateNextPayment(c *gin.Context, loan *entity.Loan) error
 
 	if nextPayment == nil {
 		if err := loan.SetStatus("close"); err != nil {
-			log.WithFields(log.Fields{
+			pkg.Logger(ctx).WithFields(log.Fields{
 				"loanID": loan.GetID(),
 				"status": "close",
 				"error":  err,
@@ -230,19 +327,27 @@ func (u *loanUsecase) updateNextPayment(c *gin.Context, loan *entity.Loan) error
 			return errors.Wrap(err, "failed to set loan status to closed")
 		}
 
-		if err := u.loanRepo.UpdateLoanStatus(c, loan); err != nil {
-			log.WithFields(log.Fields{
+		if err := u.loanRepo.UpdateLoanStatus(ctx, loan, "all installments paid"); err != nil {
+			pkg.Logger(ctx).WithFields(log.Fields{
 				"loanID": loan.GetID(),
 				"error":  err,
 			}).Error("Failed to update loan status to closed")
 			return errors.Wrap(err, "failed to update loan status to closed")
 		}
+
+		if err := u.eventPublisher.Publish(ctx, entity.NewLoanClosedEvent(loan)); err != nil {
+			pkg.Logger(ctx).WithFields(log.Fields{
+				"loanID": loan.GetID(),
+				"error":  err,
+			}).Error("Failed to publish loan closed event")
+			return errors.Wrap(err, "failed to publish loan closed event")
+		}
 		return nil
 	}
 
 	if nextPayment.Status() == "scheduled" {
 		if err := nextPayment.SetStatus("outstanding"); err != nil {
-			log.WithFields(log.Fields{
+			pkg.Logger(ctx).WithFields(log.Fields{
 				"paymentID": nextPayment.GetID(),
 				"status":    "outstanding",
 				"error":     err,
@@ -250,8 +355,8 @@ func (u *loanUsecase) updateNextPayment(c *gin.Context, loan *entity.Loan) error
 			return errors.Wrap(err, "failed to set payment status to outstanding")
 		}
 
-		if err := u.paymentRepo.UpdatePaymentStatus(c, nextPayment); err != nil {
-			log.WithFields(log.Fields{
+		if err := u.paymentRepo.UpdatePaymentStatus(ctx, nextPayment, "previous installment paid"); err != nil {
+			pkg.Logger(ctx).WithFields(log.Fields{
 				"paymentID": nextPayment.GetID(),
 				"loanID":    loan.GetID(),
 				"error":     err,
@@ -262,30 +367,33 @@ func (u *loanUsecase) updateNextPayment(c *gin.Context, loan *entity.Loan) error
 	return nil
 }
 
-func (u *loanUsecase) updatePaid(c *gin.Context, payments *[]entity.Payment, amount float64) error {
+// updatePaid marks the installments covered by the amount as paid and returns their weeks
+func (u *loanUsecase) updatePaid(ctx context.Context, payments *[]entity.Payment, amount float64, paidAt time.Time) ([]int, error) {
+	var paidWeeks []int
 	for _, payment := range *payments {
 		if amount >= payment.Amount() {
-			if err := payment.SetStatus("paid"); err != nil {
-				log.WithFields(log.Fields{
+			if err := payment.MarkPaid(paidAt); err != nil {
+				pkg.Logger(ctx).WithFields(log.Fields{
 					"paymentID": payment.GetID(),
 					"status":    "paid",
 					"error":     err,
 				}).Error("Failed to set payment status to paid")
-				return errors.Wrap(err, "failed to set payment status to paid")
+				return nil, errors.Wrap(err, "failed to set payment status to paid")
 			}
 
 			amount -= payment.Amount()
-			if err := u.paymentRepo.UpdatePaymentStatus(c, &payment); err != nil {
-				log.WithFields(log.Fields{
+			if err := u.paymentRepo.UpdatePaymentStatus(ctx, &payment, "payment received"); err != nil {
+				pkg.Logger(ctx).WithFields(log.Fields{
 					"paymentID": payment.GetID(),
 					"amount":    payment.Amount(),
 					"error":     err,
 				}).Error("Failed to update payment to paid")
-				return errors.Wrap(err, "failed to update payment to paid")
+				return nil, errors.Wrap(err, "failed to update payment to paid")
 			}
+			paidWeeks = append(paidWeeks, payment.Week())
 		} else {
 			break
 		}
 	}
-	return nil
+	return paidWeeks, nil
 }
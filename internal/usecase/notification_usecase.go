@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/notification"
+	"billing_enginee/internal/repository"
+	"billing_enginee/pkg"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// notificationDeliveryBatchSize caps how many notifications one DeliverPending run sends
+const notificationDeliveryBatchSize = 100
+
+// NotificationUsecase notifies customers about their installments. Notifications are queued with the change they
+// are about, in its unit of work, and sent by DeliverPending once it is committed, so the jobs queuing them never
+// wait on a provider and a rolled back change notifies nobody.
+type NotificationUsecase interface {
+	QueueUpcomingReminders(ctx context.Context, currentDate time.Time) error
+	QueuePendingInstallmentNotices(ctx context.Context, paymentIDs []uint) error
+	DeliverPending(ctx context.Context, now time.Time) error
+}
+
+type notificationUsecase struct {
+	uow                pkg.UnitOfWork
+	paymentRepo        repository.PaymentRepository
+	notificationRepo   repository.NotificationRepository
+	notifier           notification.Notifier
+	reminderDaysBefore int
+}
+
+func NewNotificationUsecase(uow pkg.UnitOfWork, paymentRepo repository.PaymentRepository, notificationRepo repository.NotificationRepository, notifier notification.Notifier, reminderDaysBefore int) NotificationUsecase {
+	return &notificationUsecase{
+		uow:                uow,
+		paymentRepo:        paymentRepo,
+		notificationRepo:   notificationRepo,
+		notifier:           notifier,
+		reminderDaysBefore: reminderDaysBefore,
+	}
+}
+
+// QueueUpcomingReminders queues a reminder for the unpaid installments due reminderDaysBefore days after
+// currentDate. An installment is only reminded once, however many times the reminders run for the date.
+func (u *notificationUsecase) QueueUpcomingReminders(ctx context.Context, currentDate time.Time) (err error) {
+	ctx, span := pkg.StartSpan(ctx, "NotificationUsecase.QueueUpcomingReminders")
+	defer func() { pkg.EndSpan(span, err) }()
+
+	today := time.Date(currentDate.Year(), currentDate.Month(), currentDate.Day(), 0, 0, 0, 0, currentDate.Location())
+	dueDate := today.AddDate(0, 0, u.reminderDaysBefore)
+
+	payments, err := u.paymentRepo.GetPaymentsDueOnDateWithStatus(ctx, dueDate, []string{"scheduled", "outstanding"})
+	if err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"dueDate": dueDate,
+			"error":   err,
+		}).Error("Failed to fetch payments for reminders")
+		return errors.Wrap(err, "failed to fetch payments for reminders")
+	}
+
+	reminders := make([]*entity.Notification, len(payments))
+	for i, payment := range payments {
+		reminders[i] = entity.CreateNotification(payment.GetID(), string(notification.TemplateInstallmentReminder), u.reminderDaysBefore)
+	}
+	err = u.uow.Do(ctx, func(ctx context.Context) error {
+		return u.notificationRepo.QueueNotifications(ctx, reminders)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to queue reminders")
+	}
+
+	pkg.Logger(ctx).WithFields(log.Fields{
+		"dueDate":  dueDate,
+		"payments": len(payments),
+	}).Info("Upcoming installment reminders queued")
+	return nil
+}
+
+// QueuePendingInstallmentNotices queues a notice for the installments that have just turned pending. It joins
+// the unit of work of the context, so the notices are kept or dropped with the status change.
+func (u *notificationUsecase) QueuePendingInstallmentNotices(ctx context.Context, paymentIDs []uint) (err error) {
+	ctx, span := pkg.StartSpan(ctx, "NotificationUsecase.QueuePendingInstallmentNotices", attribute.Int("payments", len(paymentIDs)))
+	defer func() { pkg.EndSpan(span, err) }()
+
+	notices := make([]*entity.Notification, len(paymentIDs))
+	for i, paymentID := range paymentIDs {
+		notices[i] = entity.CreateNotification(paymentID, string(notification.TemplateInstallmentPending), 0)
+	}
+	if err := u.notificationRepo.QueueNotifications(ctx, notices); err != nil {
+		return errors.Wrap(err, "failed to queue pending installment notices")
+	}
+	return nil
+}
+
+// DeliverPending attempts every due notification once, failed attempts are rescheduled with exponential backoff.
+// A notification goes through every channel again when it is retried, so customers may get a message twice.
+func (u *notificationUsecase) DeliverPending(ctx context.Context, now time.Time) (err error) {
+	ctx, span := pkg.StartSpan(ctx, "NotificationUsecase.DeliverPending")
+	defer func() { pkg.EndSpan(span, err) }()
+
+	notifications, err := u.notificationRepo.GetDueNotifications(ctx, now, notificationDeliveryBatchSize)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch due notifications")
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	paymentIDs := make([]uint, len(notifications))
+	for i, n := range notifications {
+		paymentIDs[i] = n.PaymentID()
+	}
+	payments, err := u.paymentRepo.GetPaymentsByIDs(ctx, paymentIDs)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch the payments of due notifications")
+	}
+	paymentsByID := make(map[uint]*entity.Payment, len(payments))
+	for _, payment := range payments {
+		paymentsByID[payment.GetID()] = payment
+	}
+
+	sent := 0
+	for _, n := range notifications {
+		if u.deliver(pkg.WithTenantID(ctx, n.TenantID()), n, paymentsByID[n.PaymentID()], now) {
+			sent++
+		}
+		if err := u.notificationRepo.UpdateNotification(ctx, n); err != nil {
+			return errors.Wrap(err, "failed to record notification attempt")
+		}
+	}
+
+	pkg.Logger(ctx).WithFields(log.Fields{
+		"due":  len(notifications),
+		"sent": sent,
+	}).Info("Notification delivery run completed")
+	return nil
+}
+
+// deliver sends the notification to the customer of the payment and records the attempt, it reports whether
+// the notification was sent
+func (u *notificationUsecase) deliver(ctx context.Context, n *entity.Notification, payment *entity.Payment, now time.Time) bool {
+	if payment == nil || payment.Loan() == nil || payment.Loan().Customer() == nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"notificationID": n.GetID(),
+			"paymentID":      n.PaymentID(),
+		}).Warn("Payment has no customer to notify")
+		n.MarkFailed(errors.Errorf("payment %d has no customer to notify", n.PaymentID()))
+		return false
+	}
+	customer := payment.Loan().Customer()
+
+	recipient := notification.Recipient{
+		Name:  customer.Name(),
+		Email: customer.Email(),
+		Phone: customer.Phone(),
+	}
+	notice := notification.InstallmentNotice{
+		CustomerName: customer.Name(),
+		LoanID:       payment.LoanID(),
+		Week:         payment.Week(),
+		Amount:       payment.Amount(),
+		DueDate:      payment.DueDate(),
+		DaysUntilDue: n.DaysUntilDue(),
+	}
+
+	if err := u.notifier.Notify(ctx, notification.TemplateName(n.Template()), recipient, notice); err != nil {
+		n.MarkAttemptFailed(err, now)
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"notificationID": n.GetID(),
+			"paymentID":      payment.GetID(),
+			"customerID":     customer.GetID(),
+			"template":       n.Template(),
+			"attempts":       n.Attempts(),
+			"status":         n.Status(),
+			"error":          err,
+		}).Warn("Notification attempt failed")
+		return false
+	}
+	n.MarkSent(now)
+	return true
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"billing_enginee/internal/broker"
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/repository"
+	"billing_enginee/pkg"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// OutboxUsecase publishes events through the outbox table and relays them to the broker
+type OutboxUsecase interface {
+	EventPublisher
+	RelayPending(ctx context.Context, now time.Time, limit int) (int, error)
+}
+
+type outboxUsecase struct {
+	outboxRepo repository.OutboxRepository
+	broker     broker.Broker
+}
+
+func NewOutboxUsecase(outboxRepo repository.OutboxRepository, broker broker.Broker) OutboxUsecase {
+	return &outboxUsecase{
+		outboxRepo: outboxRepo,
+		broker:     broker,
+	}
+}
+
+// Publish stores the event in the outbox as part of the current transaction, for the tenant of the context
+func (u *outboxUsecase) Publish(ctx context.Context, event *entity.Event) error {
+	event.SetTenantID(pkg.GetTenantID(ctx))
+	message, err := entity.CreateOutboxMessage(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to create outbox message")
+	}
+
+	if err := u.outboxRepo.SaveMessage(ctx, message); err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"eventID":   event.GetID(),
+			"eventType": event.Type().String(),
+			"error":     err,
+		}).Error("Failed to write event to outbox")
+		return errors.Wrap(err, "failed to write event to outbox")
+	}
+	return nil
+}
+
+// RelayPending publishes up to limit unpublished messages whose next attempt is due and returns how many were
+// published. Messages are marked as published only after the broker accepted them, so delivery is at-least-once.
+// A failed message is retried with exponential backoff and later messages with the same ordering key are held
+// back until it is published, to keep the per-loan order. After MaxOutboxAttempts attempts the relay gives up on
+// it and it no longer holds back its key. The relay reads the messages of every tenant, each one is published in a
+// context scoped to its own tenant.
+//
+// The order of a key is the order of the message IDs, which are assigned when a message is written, not when it
+// is committed. It matches the commit order because the writers of a loan hold its row lock until they commit; an
+// event written without that lock could commit after a higher ID of its key was published, and be relayed late.
+func (u *outboxUsecase) RelayPending(ctx context.Context, now time.Time, limit int) (_ int, err error) {
+	ctx, span := pkg.StartSpan(ctx, "OutboxUsecase.RelayPending")
+	defer func() { pkg.EndSpan(span, err) }()
+
+	messages, err := u.outboxRepo.GetUnpublishedMessages(ctx, now, limit)
+	if err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to fetch outbox messages")
+		return 0, errors.Wrap(err, "failed to fetch outbox messages")
+	}
+
+	blockedKeys := make(map[string]bool)
+	published := 0
+	for _, message := range messages {
+		key := message.OrderingKey()
+		if blockedKeys[key] {
+			continue
+		}
+
+		publishErr := u.broker.Publish(pkg.WithTenantID(ctx, message.TenantID()), broker.Message{
+			ID:         message.EventID(),
+			Key:        key,
+			Type:       message.EventType(),
+			TenantID:   message.TenantID(),
+			Payload:    []byte(message.Payload()),
+			OccurredAt: message.CreatedAt(),
+		})
+		if publishErr != nil {
+			blockedKeys[key] = true
+			message.MarkPublishFailed(publishErr, now)
+			logger := pkg.Logger(ctx).WithFields(log.Fields{
+				"messageID": message.GetID(),
+				"key":       key,
+				"attempts":  message.Attempts(),
+				"error":     publishErr,
+			})
+			if message.FailedAt() != nil {
+				logger.Error("Gave up relaying outbox message")
+			} else {
+				logger.WithField("nextAttemptAt", message.NextAttemptAt()).Warn("Failed to relay outbox message, holding back its key")
+			}
+		} else {
+			message.MarkPublished(now)
+			published++
+		}
+
+		if err := u.outboxRepo.UpdateMessage(ctx, message); err != nil {
+			return published, errors.Wrap(err, "failed to record outbox relay result")
+		}
+	}
+
+	if len(messages) > 0 {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"fetched":   len(messages),
+			"published": published,
+		}).Info("Outbox relay run completed")
+	}
+	return published, nil
+}
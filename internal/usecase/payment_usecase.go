@@ -1,76 +1,254 @@
 package usecase
 
 import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/metrics"
 	"billing_enginee/internal/repository"
+	"billing_enginee/pkg"
+	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"time"
 
-	"github.com/sirupsen/logrus"
-	"gorm.io/gorm"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type PaymentUsecase interface {
-	UpdatePaymentStatus(tx *gorm.DB, tm time.Time) error
+	UpdatePaymentStatus(ctx context.Context, tm time.Time) error
+	RunPaymentStatusUpdate(ctx context.Context, currentDate time.Time) (*PaymentStatusUpdate, error)
 }
 
+// PaymentStatusUpdate tells what a run of the payment status update did. Installments of the failed loans
+// count as processed and failed, they were left unchanged. A run where some loans failed still succeeds.
+type PaymentStatusUpdate struct {
+	ItemsProcessed int
+	ItemsFailed    int
+	LoansProcessed int
+	LoansFailed    int
+	TurnedPending  int
+	// Failures are the first failed loans, at most maxReportedFailures of them
+	Failures []PaymentStatusFailure
+}
+
+// Err summarizes the loans that failed, nil when none did
+func (u *PaymentStatusUpdate) Err() error {
+	if u.LoansFailed == 0 {
+		return nil
+	}
+	first := u.Failures[0]
+	return fmt.Errorf("failed to update the payments of %d of %d loans, first loan %d: %w",
+		u.LoansFailed, u.LoansProcessed, first.LoanID, first.Err)
+}
+
+// PaymentStatusFailure is a loan whose installments could not be updated
+type PaymentStatusFailure struct {
+	LoanID uint
+	Err    error
+}
+
+// maxReportedFailures bounds the failures kept in a PaymentStatusUpdate, the others are only counted and logged
+const maxReportedFailures = 100
+
 type paymentUsecase struct {
-	paymentRepo repository.PaymentRepository
+	uow                 pkg.UnitOfWork
+	paymentRepo         repository.PaymentRepository
+	notificationUsecase NotificationUsecase
+	eventPublisher      EventPublisher
+	batchSize           int
+	workers             int
 }
 
-func NewPaymentUsecase(paymentRepo repository.PaymentRepository) PaymentUsecase {
+// NewPaymentUsecase returns the payment use case. The status update reads batchSize loans at a time and updates
+// workers of them in parallel.
+func NewPaymentUsecase(uow pkg.UnitOfWork, paymentRepo repository.PaymentRepository, notificationUsecase NotificationUsecase, eventPublisher EventPublisher, batchSize int, workers int) PaymentUsecase {
 	return &paymentUsecase{
-		paymentRepo: paymentRepo,
+		uow:                 uow,
+		paymentRepo:         paymentRepo,
+		notificationUsecase: notificationUsecase,
+		eventPublisher:      eventPublisher,
+		batchSize:           batchSize,
+		workers:             workers,
 	}
 }
 
-func (pu *paymentUsecase) UpdatePaymentStatus(tx *gorm.DB, currentDate time.Time) error {
-	logrus.Info("Scheduler started: Checking for payments due in a week...")
+// UpdatePaymentStatus refreshes the installment statuses of the tenant of the context as of currentDate
+func (pu *paymentUsecase) UpdatePaymentStatus(ctx context.Context, currentDate time.Time) error {
+	_, err := pu.RunPaymentStatusUpdate(ctx, currentDate)
+	return err
+}
+
+// RunPaymentStatusUpdate is UpdatePaymentStatus, telling what it did. The loans with installments to update are
+// read in batches and updated in parallel, each in its own unit of work, so a loan that fails is left unchanged
+// without stopping the others, and is reported in the update. The run only returns an error when it cannot go on,
+// e.g. when the loans cannot be read.
+func (pu *paymentUsecase) RunPaymentStatusUpdate(ctx context.Context, currentDate time.Time) (_ *PaymentStatusUpdate, err error) {
+	ctx, span := pkg.StartSpan(ctx, "PaymentUsecase.UpdatePaymentStatus")
+	defer func() { pkg.EndSpan(span, err) }()
+
+	pkg.Logger(ctx).Info("Scheduler started: Checking for payments due in a week...")
 
 	// Safely truncate the current date, retaining the timezone and avoiding shifting
 	today := time.Date(currentDate.Year(), currentDate.Month(), currentDate.Day(), 0, 0, 0, 0, currentDate.Location())
 	nextWeek := currentDate.AddDate(0, 0, 7)
 	nextWeek = time.Date(nextWeek.Year(), nextWeek.Month(), nextWeek.Day(), 0, 0, 0, 0, nextWeek.Location())
 
-	// Fetch all payments that are scheduled, outstanding, or pending
-	payments, err := pu.paymentRepo.GetPaymentsDueBeforeDateWithStatus(nil, nextWeek)
-	if err != nil {
-		logrus.WithError(err).Error("Error fetching payments")
-		return errors.New("error fetching payments: " + err.Error())
+	// A transaction is a single connection, the loans of a run joining one are updated one after the other
+	workers := pu.workers
+	if _, inTx := pkg.TxFromContext(ctx); inTx {
+		workers = 1
 	}
 
-	// Update the payment statuses
-	for _, payment := range payments {
-		if payment.DueDate().Before(today) {
-			// Mark payments that are overdue as "pending"
-			if err := payment.SetStatus("pending"); err != nil {
-				logrus.WithFields(logrus.Fields{
-					"paymentID": payment.GetID(),
-					"status":    "pending",
-					"error":     err,
-				}).Error("Failed to set payment status to pending")
-				return errors.New("failed to set payment status to pending: " + err.Error())
+	update := &PaymentStatusUpdate{}
+	var afterLoanID uint
+	for {
+		loanIDs, err := pu.paymentRepo.GetLoanIDsWithPaymentsDueBeforeDate(ctx, nextWeek, afterLoanID, pu.batchSize)
+		if err != nil {
+			pkg.Logger(ctx).WithError(err).Error("Error fetching payments")
+			return update, errors.New("error fetching payments: " + err.Error())
+		}
+		if len(loanIDs) == 0 {
+			break
+		}
+
+		for _, result := range pu.updateLoanPaymentStatuses(ctx, loanIDs, today, nextWeek, workers) {
+			update.LoansProcessed++
+			update.ItemsProcessed += result.items
+			if result.err != nil {
+				update.LoansFailed++
+				update.ItemsFailed += result.items
+				if len(update.Failures) < maxReportedFailures {
+					update.Failures = append(update.Failures, PaymentStatusFailure{LoanID: result.loanID, Err: result.err})
+				}
+				continue
 			}
-		} else if payment.DueDate().Before(nextWeek) && payment.Status() == "scheduled" {
-			// Mark payments due today as "outstanding"
-			if err := payment.SetStatus("outstanding"); err != nil {
-				logrus.WithFields(logrus.Fields{
+			update.TurnedPending += result.turnedPending
+		}
+		afterLoanID = loanIDs[len(loanIDs)-1]
+	}
+
+	metrics.AddJobItemsProcessed(metrics.JobUpdatePaymentStatus, pkg.GetTenantID(ctx), update.ItemsProcessed)
+	metrics.SetInstallmentsTurnedPending(pkg.GetTenantID(ctx), update.TurnedPending)
+
+	pkg.Logger(ctx).WithFields(log.Fields{
+		"loansProcessed": update.LoansProcessed,
+		"loansFailed":    update.LoansFailed,
+		"itemsFailed":    update.ItemsFailed,
+		"turnedPending":  update.TurnedPending,
+	}).Infof("Scheduler completed: Processed %d payments.", update.ItemsProcessed)
+	return update, nil
+}
+
+// loanPaymentStatusResult is the outcome of the status update of a loan
+type loanPaymentStatusResult struct {
+	loanID        uint
+	items         int
+	turnedPending int
+	err           error
+}
+
+// updateLoanPaymentStatuses updates the loans on at most workers goroutines, the results are in the order of the loans
+func (pu *paymentUsecase) updateLoanPaymentStatuses(ctx context.Context, loanIDs []uint, today time.Time, nextWeek time.Time, workers int) []loanPaymentStatusResult {
+	results := make([]loanPaymentStatusResult, len(loanIDs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(workers, len(loanIDs)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				results[index] = pu.updateLoanPaymentStatus(ctx, loanIDs[index], today, nextWeek)
+			}
+		}()
+	}
+	for index := range loanIDs {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// updateLoanPaymentStatus turns the overdue installments of the loan pending and the ones due within a week
+// outstanding, in one unit of work. The installments are read and locked in it, so an installment paid meanwhile
+// is not overwritten. Installments whose status does not change are not written. The customer is notified about
+// the installments turned pending once the unit of work commits.
+func (pu *paymentUsecase) updateLoanPaymentStatus(ctx context.Context, loanID uint, today time.Time, nextWeek time.Time) loanPaymentStatusResult {
+	result := loanPaymentStatusResult{loanID: loanID}
+
+	err := pu.uow.Do(ctx, func(ctx context.Context) error {
+		payments, err := pu.paymentRepo.LockPaymentsDueBeforeDate(ctx, loanID, nextWeek)
+		if err != nil {
+			return err
+		}
+		result.items = len(payments)
+
+		var pendingIDs []uint
+		for _, payment := range payments {
+			var status, reason string
+			if payment.DueDate().Before(today) {
+				// Mark payments that are overdue as "pending"
+				status, reason = "pending", "installment overdue"
+				pendingIDs = append(pendingIDs, payment.GetID())
+			} else if payment.DueDate().Before(nextWeek) && payment.Status() == "scheduled" {
+				// Mark payments due within a week as "outstanding"
+				status, reason = "outstanding", "installment due within a week"
+			} else {
+				continue
+			}
+
+			if err := payment.SetStatus(status); err != nil {
+				pkg.Logger(ctx).WithFields(log.Fields{
 					"paymentID": payment.GetID(),
-					"status":    "outstanding",
+					"status":    status,
 					"error":     err,
-				}).Error("Failed to set payment status to outstanding")
-				return errors.New("failed to set payment status to outstanding: " + err.Error())
+				}).Error("Failed to set payment status")
+				return errors.New("failed to set payment status to " + status + ": " + err.Error())
+			}
+			// The status change and its overdue event are kept or dropped together
+			if err := pu.savePaymentStatus(ctx, payment, reason); err != nil {
+				return err
 			}
 		}
+		if err := pu.notificationUsecase.QueuePendingInstallmentNotices(ctx, pendingIDs); err != nil {
+			return err
+		}
+		result.turnedPending = len(pendingIDs)
+		return nil
+	})
+	if err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"loanID": loanID,
+			"error":  err,
+		}).Error("Failed to update the payment statuses of the loan, it is left unchanged")
+		result.turnedPending = 0
+		result.err = err
+	}
+	return result
+}
+
+func (pu *paymentUsecase) savePaymentStatus(ctx context.Context, payment *entity.Payment, reason string) (err error) {
+	ctx, span := pkg.StartSpan(ctx, "PaymentUsecase.savePaymentStatus", attribute.Int64("payment.id", int64(payment.GetID())))
+	defer func() { pkg.EndSpan(span, err) }()
 
-		if err := pu.paymentRepo.UpdatePaymentStatus(nil, payment); err != nil {
-			logrus.WithFields(logrus.Fields{
+	if err := pu.paymentRepo.UpdatePaymentStatus(ctx, payment, reason); err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"paymentID": payment.GetID(),
+			"error":     err,
+		}).Error("Error updating payment status")
+		return errors.New("failed to update payment status: " + err.Error())
+	}
+
+	if payment.Status() == "pending" {
+		if err := pu.eventPublisher.Publish(ctx, entity.NewInstallmentOverdueEvent(payment)); err != nil {
+			pkg.Logger(ctx).WithFields(log.Fields{
 				"paymentID": payment.GetID(),
 				"error":     err,
-			}).Error("Error updating payment status")
-			return errors.New("failed to update payment status: " + err.Error())
+			}).Error("Failed to publish installment overdue event")
+			return errors.New("failed to publish installment overdue event: " + err.Error())
 		}
 	}
-
-	logrus.Infof("Scheduler completed: Processed %d payments.", len(payments))
 	return nil
 }
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/metrics"
+	"billing_enginee/internal/repository"
+	"billing_enginee/pkg"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrInvalidReportPeriod = entity.NewValidationError("period must be one of day, week or month")
+	ErrInvalidReportRange  = entity.NewValidationError("from date must be before to date")
+)
+
+var reportPeriods = map[string]bool{
+	"day":   true,
+	"week":  true,
+	"month": true,
+}
+
+type ReportUsecase interface {
+	GetPortfolio(ctx context.Context) (*PortfolioResponse, error)
+	GetPortfolioAtRisk(ctx context.Context, asOf time.Time) (*PortfolioAtRiskResponse, error)
+	GetCollections(ctx context.Context, period string, from time.Time, to time.Time) (*CollectionsResponse, error)
+}
+
+type PortfolioResponse struct {
+	LoanCount            int64
+	TotalDisbursed       float64
+	TotalRepayable       float64
+	OutstandingTotal     float64
+	OutstandingPrincipal float64
+	OutstandingInterest  float64
+	LoansByStatus        map[string]int64
+}
+
+type PortfolioAtRiskResponse struct {
+	AsOf                 time.Time
+	OutstandingPrincipal float64
+	Par30Principal       float64
+	Par30Loans           int64
+	Par30Ratio           float64
+	Par90Principal       float64
+	Par90Loans           int64
+	Par90Ratio           float64
+}
+
+type CollectionsResponse struct {
+	Period          string
+	From            time.Time
+	To              time.Time
+	TotalCollected  float64
+	TotalCount      int64
+	PeriodBreakdown []entity.CollectionPeriod
+}
+
+type reportUsecase struct {
+	reportRepo repository.ReportRepository
+}
+
+func NewReportUsecase(reportRepo repository.ReportRepository) ReportUsecase {
+	return &reportUsecase{
+		reportRepo: reportRepo,
+	}
+}
+
+func (u *reportUsecase) GetPortfolio(ctx context.Context) (_ *PortfolioResponse, err error) {
+	ctx, span := pkg.StartSpan(ctx, "ReportUsecase.GetPortfolio")
+	defer func() { pkg.EndSpan(span, err) }()
+
+	summary, err := u.reportRepo.GetPortfolioSummary(ctx)
+	if err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to get portfolio summary")
+		return nil, errors.Wrap(err, "failed to get portfolio summary")
+	}
+
+	counts, err := u.reportRepo.CountLoansByStatus(ctx)
+	if err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to count loans by status")
+		return nil, errors.Wrap(err, "failed to count loans by status")
+	}
+
+	loansByStatus := make(map[string]int64, len(counts))
+	for _, count := range counts {
+		loansByStatus[count.Status] = count.Count
+	}
+
+	return &PortfolioResponse{
+		LoanCount:            summary.LoanCount,
+		TotalDisbursed:       summary.TotalDisbursed,
+		TotalRepayable:       summary.TotalRepayable,
+		OutstandingTotal:     summary.OutstandingTotal,
+		OutstandingPrincipal: summary.OutstandingPrincipal,
+		OutstandingInterest:  summary.OutstandingInterest(),
+		LoansByStatus:        loansByStatus,
+	}, nil
+}
+
+func (u *reportUsecase) GetPortfolioAtRisk(ctx context.Context, asOf time.Time) (_ *PortfolioAtRiskResponse, err error) {
+	ctx, span := pkg.StartSpan(ctx, "ReportUsecase.GetPortfolioAtRisk")
+	defer func() { pkg.EndSpan(span, err) }()
+
+	par, err := u.reportRepo.GetPortfolioAtRisk(ctx, asOf)
+	if err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"asOf":  asOf,
+			"error": err,
+		}).Error("Failed to get portfolio at risk")
+		return nil, errors.Wrap(err, "failed to get portfolio at risk")
+	}
+
+	return &PortfolioAtRiskResponse{
+		AsOf:                 par.AsOf,
+		OutstandingPrincipal: par.OutstandingPrincipal,
+		Par30Principal:       par.Par30Principal,
+		Par30Loans:           par.Par30Loans,
+		Par30Ratio:           par.Par30Ratio(),
+		Par90Principal:       par.Par90Principal,
+		Par90Loans:           par.Par90Loans,
+		Par90Ratio:           par.Par90Ratio(),
+	}, nil
+}
+
+func (u *reportUsecase) GetCollections(ctx context.Context, period string, from time.Time, to time.Time) (_ *CollectionsResponse, err error) {
+	ctx, span := pkg.StartSpan(ctx, "ReportUsecase.GetCollections", attribute.String("period", period))
+	defer func() { pkg.EndSpan(span, err) }()
+
+	if !reportPeriods[period] {
+		pkg.Logger(ctx).WithField("period", period).Error("Invalid collections report period")
+		return nil, ErrInvalidReportPeriod
+	}
+	if !from.Before(to) {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"from": from,
+			"to":   to,
+		}).Error("Invalid collections report range")
+		return nil, ErrInvalidReportRange
+	}
+
+	collections, err := u.reportRepo.GetCollections(ctx, period, from, to)
+	if err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"period": period,
+			"from":   from,
+			"to":     to,
+			"error":  err,
+		}).Error("Failed to get collections")
+		return nil, errors.Wrap(err, "failed to get collections")
+	}
+
+	response := &CollectionsResponse{
+		Period:          period,
+		From:            from,
+		To:              to,
+		PeriodBreakdown: collections,
+	}
+	for _, collection := range collections {
+		response.TotalCollected += collection.AmountCollected
+		response.TotalCount += collection.InstallmentsCount
+	}
+
+	return response, nil
+}
+
+// NewPortfolioMetricsSource reads the portfolio gauges of a tenant from the portfolio report
+func NewPortfolioMetricsSource(reportUsecase ReportUsecase) metrics.PortfolioSource {
+	return func(ctx context.Context, tenantID string) (*metrics.Portfolio, error) {
+		portfolio, err := reportUsecase.GetPortfolio(pkg.WithTenantID(ctx, tenantID))
+		if err != nil {
+			return nil, err
+		}
+		return &metrics.Portfolio{
+			OpenLoans:   portfolio.LoansByStatus["open"],
+			Outstanding: portfolio.OutstandingTotal,
+		}, nil
+	}
+}
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/repository"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/pkg"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// MaxSimulationDays bounds how far the clock may be advanced at once, a year covers the longest loans
+const MaxSimulationDays = 366
+
+const simulationDay = 24 * time.Hour
+
+var ErrInvalidSimulationDays = entity.NewValidationError("days must be between 1 and 366")
+
+// SimulationUsecase moves the business date of the simulation mode forward, running the daily jobs
+// of every tenant for each day it skips, so QA can walk loans through their whole life.
+type SimulationUsecase interface {
+	GetClock(ctx context.Context) *SimulationClockResponse
+	Advance(ctx context.Context, days int) (*SimulationClockResponse, error)
+}
+
+type SimulationClockResponse struct {
+	Now       time.Time
+	DaysAhead int
+	// BusinessDates is the current date of every tenant, by tenant ID
+	BusinessDates map[string]time.Time
+}
+
+type simulationUsecase struct {
+	mu                  sync.Mutex // held while the clock is advanced
+	clock               *pkg.SimulatedClock
+	clockRepo           repository.SimulationClockRepository
+	tenants             tenant.Registry
+	jobRunUsecase       JobRunUsecase
+	notificationUsecase NotificationUsecase
+}
+
+func NewSimulationUsecase(clock *pkg.SimulatedClock, clockRepo repository.SimulationClockRepository, tenants tenant.Registry, jobRunUsecase JobRunUsecase, notificationUsecase NotificationUsecase) SimulationUsecase {
+	return &simulationUsecase{
+		clock:               clock,
+		clockRepo:           clockRepo,
+		tenants:             tenants,
+		jobRunUsecase:       jobRunUsecase,
+		notificationUsecase: notificationUsecase,
+	}
+}
+
+func (u *simulationUsecase) GetClock(ctx context.Context) *SimulationClockResponse {
+	now := u.clock.Now()
+	response := &SimulationClockResponse{
+		Now:           now,
+		DaysAhead:     int(u.clock.Offset() / simulationDay),
+		BusinessDates: make(map[string]time.Time),
+	}
+	for _, t := range u.tenants.All() {
+		response.BusinessDates[t.ID] = now.In(t.Location())
+	}
+	return response
+}
+
+// Advance runs the payment status update and the reminders of every tenant for each of the next days, like
+// the scheduler would, recording the status updates in the job runs, and moves the clock a day at a time. Every
+// day commits on its own like a scheduled run, so the job lock of a run is only released once its changes are
+// committed and the notifications of a day are only sent once it is. A day that fails stops the advance, the
+// clock stays on the last day that completed. Advances run one at a time.
+func (u *simulationUsecase) Advance(ctx context.Context, days int) (_ *SimulationClockResponse, err error) {
+	ctx, span := pkg.StartSpan(ctx, "SimulationUsecase.Advance", attribute.Int("simulation.days", days))
+	defer func() { pkg.EndSpan(span, err) }()
+
+	if days < 1 || days > MaxSimulationDays {
+		return nil, ErrInvalidSimulationDays
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for day := 1; day <= days; day++ {
+		if err := u.advanceDay(ctx); err != nil {
+			pkg.Logger(ctx).WithFields(log.Fields{
+				"days":          days,
+				"daysCompleted": day - 1,
+				"error":         err,
+			}).Error("Simulation clock advance stopped")
+			return nil, err
+		}
+	}
+
+	pkg.Logger(ctx).WithFields(log.Fields{
+		"days":      days,
+		"daysAhead": int(u.clock.Offset() / simulationDay),
+	}).Info("Simulation clock advanced")
+	return u.GetClock(ctx), nil
+}
+
+// advanceDay runs the daily jobs of every tenant for the next business date, then stores and moves the clock
+func (u *simulationUsecase) advanceDay(ctx context.Context) error {
+	for _, t := range u.tenants.All() {
+		tenantCtx := pkg.WithTenantID(ctx, t.ID)
+		businessDate := t.Now(u.clock).AddDate(0, 0, 1)
+
+		if _, err := u.jobRunUsecase.RunUpdatePaymentStatus(tenantCtx, businessDate, enum.JobRunTriggerSimulation); err != nil {
+			pkg.Logger(ctx).WithFields(log.Fields{
+				"tenantID":     t.ID,
+				"businessDate": businessDate.Format(time.DateOnly),
+				"error":        err,
+			}).Error("Failed to update payment statuses during simulation")
+			return errors.Wrapf(err, "failed to update payment statuses of tenant %s on %s", t.ID, businessDate.Format(time.DateOnly))
+		}
+		if err := u.notificationUsecase.QueueUpcomingReminders(tenantCtx, businessDate); err != nil {
+			pkg.Logger(ctx).WithFields(log.Fields{
+				"tenantID":     t.ID,
+				"businessDate": businessDate.Format(time.DateOnly),
+				"error":        err,
+			}).Error("Failed to queue payment reminders during simulation")
+			return errors.Wrapf(err, "failed to queue payment reminders of tenant %s on %s", t.ID, businessDate.Format(time.DateOnly))
+		}
+	}
+
+	if err := u.clockRepo.SaveOffset(ctx, u.clock.Offset()+simulationDay); err != nil {
+		return err
+	}
+	u.clock.Advance(simulationDay)
+	return nil
+}
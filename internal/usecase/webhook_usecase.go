@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"billing_enginee/internal/broker"
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/repository"
+	"billing_enginee/internal/webhook"
+	"billing_enginee/pkg"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// webhookDeliveryBatchSize caps how many deliveries one DeliverPending run attempts
+	webhookDeliveryBatchSize = 100
+	// webhookDeliveryLogLimit caps how many deliveries are returned by ListDeliveries
+	webhookDeliveryLogLimit = 100
+)
+
+type WebhookUsecase interface {
+	CreateSubscription(ctx context.Context, url string, secret string, eventTypes []string) (*entity.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]*entity.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, subscriptionID uint) error
+	ListDeliveries(ctx context.Context, subscriptionID uint) ([]*entity.WebhookDelivery, error)
+	QueueDeliveries(ctx context.Context, event *entity.Event) error
+	HandleMessage(ctx context.Context, msg broker.Message) error
+	DeliverPending(ctx context.Context, now time.Time) error
+}
+
+type webhookUsecase struct {
+	uow         pkg.UnitOfWork
+	webhookRepo repository.WebhookRepository
+	sender      webhook.Sender
+}
+
+func NewWebhookUsecase(uow pkg.UnitOfWork, webhookRepo repository.WebhookRepository, sender webhook.Sender) WebhookUsecase {
+	return &webhookUsecase{
+		uow:         uow,
+		webhookRepo: webhookRepo,
+		sender:      sender,
+	}
+}
+
+func (u *webhookUsecase) CreateSubscription(ctx context.Context, url string, secret string, eventTypes []string) (_ *entity.WebhookSubscription, err error) {
+	ctx, span := pkg.StartSpan(ctx, "WebhookUsecase.CreateSubscription")
+	defer func() { pkg.EndSpan(span, err) }()
+
+	subscription, err := entity.CreateWebhookSubscription(url, secret, eventTypes)
+	if err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"url":        url,
+			"eventTypes": eventTypes,
+			"error":      err,
+		}).Error("Invalid webhook subscription")
+		return nil, err
+	}
+
+	// The subscription and its audit entry are written together
+	err = u.uow.Do(ctx, func(ctx context.Context) error {
+		return u.webhookRepo.SaveSubscription(ctx, subscription)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to save webhook subscription")
+	}
+
+	return subscription, nil
+}
+
+func (u *webhookUsecase) ListSubscriptions(ctx context.Context) (_ []*entity.WebhookSubscription, err error) {
+	ctx, span := pkg.StartSpan(ctx, "WebhookUsecase.ListSubscriptions")
+	defer func() { pkg.EndSpan(span, err) }()
+
+	subscriptions, err := u.webhookRepo.GetSubscriptions(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list webhook subscriptions")
+	}
+	return subscriptions, nil
+}
+
+func (u *webhookUsecase) DeleteSubscription(ctx context.Context, subscriptionID uint) (err error) {
+	ctx, span := pkg.StartSpan(ctx, "WebhookUsecase.DeleteSubscription", attribute.Int64("subscription.id", int64(subscriptionID)))
+	defer func() { pkg.EndSpan(span, err) }()
+
+	return u.uow.Do(ctx, func(ctx context.Context) error {
+		return u.webhookRepo.DeleteSubscription(ctx, subscriptionID)
+	})
+}
+
+func (u *webhookUsecase) ListDeliveries(ctx context.Context, subscriptionID uint) (_ []*entity.WebhookDelivery, err error) {
+	ctx, span := pkg.StartSpan(ctx, "WebhookUsecase.ListDeliveries", attribute.Int64("subscription.id", int64(subscriptionID)))
+	defer func() { pkg.EndSpan(span, err) }()
+
+	if _, err := u.webhookRepo.GetSubscriptionByID(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := u.webhookRepo.GetDeliveriesBySubscription(ctx, subscriptionID, webhookDeliveryLogLimit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list webhook deliveries")
+	}
+	return deliveries, nil
+}
+
+// HandleMessage consumes an event relayed from the outbox. Messages can be relayed more than once,
+// so an event that already has deliveries is ignored. Only the subscriptions of the tenant of the message receive it.
+func (u *webhookUsecase) HandleMessage(ctx context.Context, msg broker.Message) (err error) {
+	ctx = pkg.WithTenantID(ctx, msg.TenantID)
+	ctx, span := pkg.StartSpan(ctx, "WebhookUsecase.HandleMessage")
+	defer func() { pkg.EndSpan(span, err) }()
+
+	event, err := entity.UnmarshalEvent(msg.Payload)
+	if err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"messageID": msg.ID,
+			"error":     err,
+		}).Error("Failed to decode relayed event")
+		return errors.Wrap(err, "failed to decode relayed event")
+	}
+
+	queued, err := u.webhookRepo.HasDeliveriesForEvent(ctx, event.GetID())
+	if err != nil {
+		return errors.Wrap(err, "failed to check existing webhook deliveries")
+	}
+	if queued {
+		pkg.Logger(ctx).WithField("eventID", event.GetID()).Info("Webhook deliveries already queued for event, skipping")
+		return nil
+	}
+
+	return u.QueueDeliveries(ctx, event)
+}
+
+// QueueDeliveries queues a delivery of the event for every active subscription of the tenant of the context interested in it
+func (u *webhookUsecase) QueueDeliveries(ctx context.Context, event *entity.Event) error {
+	subscriptions, err := u.webhookRepo.GetActiveSubscriptions(ctx)
+	if err != nil {
+		pkg.Logger(ctx).WithFields(log.Fields{
+			"eventID":   event.GetID(),
+			"eventType": event.Type().String(),
+			"error":     err,
+		}).Error("Failed to load webhook subscriptions for event")
+		return errors.Wrap(err, "failed to load webhook subscriptions")
+	}
+
+	var deliveries []*entity.WebhookDelivery
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(event.Type()) {
+			continue
+		}
+		delivery, err := entity.CreateWebhookDelivery(subscription, event)
+		if err != nil {
+			return errors.Wrap(err, "failed to create webhook delivery")
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := u.webhookRepo.SaveDeliveries(ctx, deliveries); err != nil {
+		return errors.Wrap(err, "failed to queue webhook deliveries")
+	}
+
+	pkg.Logger(ctx).WithFields(log.Fields{
+		"eventID":    event.GetID(),
+		"eventType":  event.Type().String(),
+		"deliveries": len(deliveries),
+	}).Info("Webhook deliveries queued")
+	return nil
+}
+
+// DeliverPending attempts every due delivery once, those of every tenant unless the context belongs to one.
+// Failed attempts are rescheduled with exponential backoff.
+func (u *webhookUsecase) DeliverPending(ctx context.Context, now time.Time) (err error) {
+	ctx, span := pkg.StartSpan(ctx, "WebhookUsecase.DeliverPending")
+	defer func() { pkg.EndSpan(span, err) }()
+
+	deliveries, err := u.webhookRepo.GetDueDeliveries(ctx, now, webhookDeliveryBatchSize)
+	if err != nil {
+		pkg.Logger(ctx).WithError(err).Error("Failed to fetch due webhook deliveries")
+		return errors.Wrap(err, "failed to fetch due webhook deliveries")
+	}
+
+	delivered := 0
+	for _, delivery := range deliveries {
+		subscription := delivery.Subscription()
+		responseStatus, sendErr := u.sender.Send(webhook.Request{
+			URL:        subscription.URL(),
+			Secret:     subscription.Secret(),
+			EventType:  delivery.EventType(),
+			DeliveryID: delivery.GetID(),
+			Payload:    []byte(delivery.Payload()),
+		})
+
+		if sendErr != nil {
+			var status *int
+			if responseStatus != 0 {
+				status = &responseStatus
+			}
+			delivery.MarkAttemptFailed(status, sendErr, now)
+			pkg.Logger(ctx).WithFields(log.Fields{
+				"deliveryID": delivery.GetID(),
+				"attempts":   delivery.Attempts(),
+				"status":     delivery.Status(),
+				"error":      sendErr,
+			}).Warn("Webhook delivery attempt failed")
+		} else {
+			delivery.MarkDelivered(responseStatus, now)
+			delivered++
+		}
+
+		if err := u.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			return errors.Wrap(err, "failed to record webhook delivery attempt")
+		}
+	}
+
+	pkg.Logger(ctx).WithFields(log.Fields{
+		"due":       len(deliveries),
+		"delivered": delivered,
+	}).Info("Webhook delivery run completed")
+	return nil
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+// Request is a single webhook call
+type Request struct {
+	URL        string
+	Secret     string
+	EventType  string
+	DeliveryID uint
+	Payload    []byte
+}
+
+// Sender posts signed webhook payloads to subscribers
+type Sender interface {
+	// Send returns the HTTP status code of the response, or 0 when no response was received
+	Send(req Request) (int, error)
+}
+
+type httpSender struct {
+	client *http.Client
+}
+
+func NewHTTPSender(timeout time.Duration) Sender {
+	return &httpSender{
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Sign computes the hex encoded HMAC-SHA256 of "timestamp.payload" with the subscription secret.
+// Receivers recompute it to verify the payload was sent by us and was not replayed with another timestamp.
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *httpSender) Send(req Request) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	httpReq, err := http.NewRequest(http.MethodPost, req.URL, bytes.NewReader(req.Payload))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to build webhook request")
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(HeaderEvent, req.EventType)
+	httpReq.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(req.DeliveryID), 10))
+	httpReq.Header.Set(HeaderTimestamp, timestamp)
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Payload))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to send webhook")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
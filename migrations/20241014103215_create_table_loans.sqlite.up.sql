@@ -0,0 +1,12 @@
+-- The loan_status enum of Postgres is a CHECK constraint
+CREATE TABLE loans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    customer_id INT REFERENCES customers(id),
+    amount NUMERIC(12, 2) NOT NULL,
+    total_amount NUMERIC(12, 2) NOT NULL,
+    status VARCHAR(16) DEFAULT 'open' CHECK (status IN ('open', 'close')),
+    term_weeks INT NOT NULL,
+    rates NUMERIC(5, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,11 @@
+-- The payment_status enum of Postgres is a CHECK constraint
+CREATE TABLE payments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    loan_id INT REFERENCES loans(id),
+    week INT NOT NULL,
+    amount NUMERIC(12, 2) NOT NULL,
+    due_date DATE NOT NULL,
+    status VARCHAR(16) DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'outstanding', 'paid')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,17 @@
+-- SQLite cannot change a CHECK constraint, the table is rebuilt with 'pending' among the statuses
+CREATE TABLE payments_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    loan_id INT REFERENCES loans(id),
+    week INT NOT NULL,
+    amount NUMERIC(12, 2) NOT NULL,
+    due_date DATE NOT NULL,
+    status VARCHAR(16) DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'outstanding', 'paid', 'pending')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO payments_new (id, loan_id, week, amount, due_date, status, created_at, updated_at)
+SELECT id, loan_id, week, amount, due_date, status, created_at, updated_at FROM payments;
+
+DROP TABLE payments;
+ALTER TABLE payments_new RENAME TO payments;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_payment_status ON payments (status);
@@ -0,0 +1,5 @@
+-- 20241016091200_alter_table_payment_add_paid_at.down.sql
+
+DROP INDEX IF EXISTS idx_payment_paid_at;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS paid_at;
@@ -0,0 +1,6 @@
+-- 20241016091200_alter_table_payment_add_paid_at.up.sql
+
+-- Record when an installment was settled so collections can be reported per period
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS paid_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_payment_paid_at ON payments (paid_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payment_paid_at;
+
+ALTER TABLE payments DROP COLUMN paid_at;
@@ -0,0 +1,4 @@
+-- Record when an installment was settled so collections can be reported per period
+ALTER TABLE payments ADD COLUMN paid_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_payment_paid_at ON payments (paid_at);
@@ -0,0 +1,3 @@
+-- 20241016140500_alter_table_customer_add_phone.down.sql
+
+ALTER TABLE customers DROP COLUMN IF EXISTS phone;
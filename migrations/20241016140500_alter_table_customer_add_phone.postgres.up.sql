@@ -0,0 +1,4 @@
+-- 20241016140500_alter_table_customer_add_phone.up.sql
+
+-- Phone number used for SMS notifications, optional for existing customers
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS phone VARCHAR(20) NULL;
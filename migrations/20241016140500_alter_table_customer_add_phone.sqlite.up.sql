@@ -0,0 +1,2 @@
+-- Phone number used for SMS notifications, optional for existing customers
+ALTER TABLE customers ADD COLUMN phone VARCHAR(20) NULL;
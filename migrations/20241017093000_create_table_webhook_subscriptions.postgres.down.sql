@@ -0,0 +1,2 @@
+-- Drop the webhook_subscriptions table
+DROP TABLE IF EXISTS webhook_subscriptions;
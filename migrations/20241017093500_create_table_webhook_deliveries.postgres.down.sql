@@ -0,0 +1,5 @@
+-- Drop the webhook_deliveries table
+DROP TABLE IF EXISTS webhook_deliveries;
+
+-- Drop the webhook_delivery_status enum type
+DROP TYPE IF EXISTS webhook_delivery_status;
@@ -0,0 +1,2 @@
+-- Drop the outbox_events table
+DROP TABLE IF EXISTS outbox_events;
@@ -0,0 +1,2 @@
+-- Relayed events are deduplicated by looking up their deliveries
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_event_id ON webhook_deliveries (event_id);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_audit_logs_append_only ON audit_logs;
+DROP FUNCTION IF EXISTS audit_logs_append_only();
+DROP INDEX IF EXISTS idx_audit_logs_entity;
+DROP TABLE IF EXISTS audit_logs;
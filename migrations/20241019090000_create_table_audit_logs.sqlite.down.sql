@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_audit_logs_append_only_delete;
+DROP TRIGGER IF EXISTS trg_audit_logs_append_only_update;
+DROP INDEX IF EXISTS idx_audit_logs_entity;
+DROP TABLE IF EXISTS audit_logs;
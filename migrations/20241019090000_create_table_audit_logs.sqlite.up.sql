@@ -0,0 +1,28 @@
+-- Create audit_logs table, every repository write appends a row in the same transaction
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id INT NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    actor VARCHAR(128) NOT NULL,
+    request_id VARCHAR(64) NULL,
+    before TEXT NULL,
+    after TEXT NULL,
+    reason VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs (entity_type, entity_id);
+
+-- The audit log is append-only, rows can never be changed or removed
+CREATE TRIGGER trg_audit_logs_append_only_update
+    BEFORE UPDATE ON audit_logs
+BEGIN
+    SELECT RAISE(ABORT, 'audit_logs is append-only');
+END;
+
+CREATE TRIGGER trg_audit_logs_append_only_delete
+    BEFORE DELETE ON audit_logs
+BEGIN
+    SELECT RAISE(ABORT, 'audit_logs is append-only');
+END;
@@ -0,0 +1,23 @@
+-- Irreversible once a tenant other than the default one has customers: dropping tenant_id would merge the rows
+-- of every tenant, and customers of different tenants may share an email, which would be unique again. The
+-- rollback refuses to run on such data instead of failing halfway on the email constraint.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM customers WHERE tenant_id <> 'default') THEN
+        RAISE EXCEPTION 'cannot roll back the tenants migration: customers of tenants other than default exist'
+            USING HINT = 'Delete or move the rows of the other tenants first, customer emails must be unique across tenants';
+    END IF;
+END
+$$;
+
+DROP INDEX IF EXISTS idx_customers_tenant_email;
+ALTER TABLE customers ADD CONSTRAINT customers_email_key UNIQUE (email);
+
+DROP INDEX IF EXISTS idx_audit_logs_tenant_id;
+DROP INDEX IF EXISTS idx_payments_tenant_id;
+DROP INDEX IF EXISTS idx_loans_tenant_id;
+
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE loans DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE customers DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,24 @@
+-- Irreversible once a tenant other than the default one has customers: dropping tenant_id would merge the rows
+-- of every tenant, and customers of different tenants may share an email, which would be unique again. The
+-- rollback refuses to run on such data instead of failing halfway on the email constraint.
+CREATE TEMP TABLE tenants_rollback_check (other_tenant_customers INT);
+CREATE TEMP TRIGGER tenants_rollback_check_abort BEFORE INSERT ON tenants_rollback_check
+WHEN NEW.other_tenant_customers > 0
+BEGIN
+    SELECT RAISE(ABORT, 'cannot roll back the tenants migration: customers of tenants other than default exist');
+END;
+INSERT INTO tenants_rollback_check SELECT COUNT(*) FROM customers WHERE tenant_id <> 'default';
+DROP TRIGGER tenants_rollback_check_abort;
+DROP TABLE tenants_rollback_check;
+
+DROP INDEX IF EXISTS idx_customers_tenant_email;
+CREATE UNIQUE INDEX IF NOT EXISTS customers_email_key ON customers (email);
+
+DROP INDEX IF EXISTS idx_audit_logs_tenant_id;
+DROP INDEX IF EXISTS idx_payments_tenant_id;
+DROP INDEX IF EXISTS idx_loans_tenant_id;
+
+ALTER TABLE audit_logs DROP COLUMN tenant_id;
+ALTER TABLE payments DROP COLUMN tenant_id;
+ALTER TABLE loans DROP COLUMN tenant_id;
+ALTER TABLE customers DROP COLUMN tenant_id;
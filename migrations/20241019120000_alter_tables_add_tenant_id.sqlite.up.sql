@@ -0,0 +1,13 @@
+-- Scope customers, loans, payments and the audit log to a tenant, existing rows belong to the default tenant
+ALTER TABLE customers ADD COLUMN tenant_id VARCHAR(32) NOT NULL DEFAULT 'default';
+ALTER TABLE loans ADD COLUMN tenant_id VARCHAR(32) NOT NULL DEFAULT 'default';
+ALTER TABLE payments ADD COLUMN tenant_id VARCHAR(32) NOT NULL DEFAULT 'default';
+ALTER TABLE audit_logs ADD COLUMN tenant_id VARCHAR(32) NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_loans_tenant_id ON loans (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_payments_tenant_id ON payments (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_tenant_id ON audit_logs (tenant_id);
+
+-- Customer emails are unique per tenant, the same person can borrow from several brands
+DROP INDEX IF EXISTS customers_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_tenant_email ON customers (tenant_id, email);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_job_runs_tenant_job_date;
+DROP TABLE IF EXISTS job_runs;
+DROP TYPE IF EXISTS job_run_status;
@@ -0,0 +1,19 @@
+-- Create enum type for job run status
+CREATE TYPE job_run_status AS ENUM ('running', 'succeeded', 'failed');
+
+-- Create job_runs table, every run of a daily job for a tenant and business date, including the failed ones
+CREATE TABLE IF NOT EXISTS job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(32) NOT NULL DEFAULT 'default',
+    job VARCHAR(64) NOT NULL,
+    business_date DATE NOT NULL,
+    triggered_by VARCHAR(16) NOT NULL CHECK (triggered_by IN ('schedule', 'catch_up', 'manual', 'simulation')),
+    status job_run_status NOT NULL DEFAULT 'running',
+    items_processed INT NOT NULL DEFAULT 0,
+    error TEXT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_tenant_job_date ON job_runs (tenant_id, job, business_date);
@@ -0,0 +1,16 @@
+-- The job_run_status enum of Postgres is a CHECK constraint
+CREATE TABLE IF NOT EXISTS job_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id VARCHAR(32) NOT NULL DEFAULT 'default',
+    job VARCHAR(64) NOT NULL,
+    business_date DATE NOT NULL,
+    triggered_by VARCHAR(16) NOT NULL CHECK (triggered_by IN ('schedule', 'catch_up', 'manual', 'simulation')),
+    status VARCHAR(16) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    items_processed INT NOT NULL DEFAULT 0,
+    error TEXT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_tenant_job_date ON job_runs (tenant_id, job, business_date);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_payment_open_tenant_loan;
@@ -0,0 +1,3 @@
+-- Serve the daily status update, which pages through the unpaid installments of a tenant by loan
+CREATE INDEX IF NOT EXISTS idx_payment_open_tenant_loan ON payments (tenant_id, loan_id, due_date)
+    WHERE status IN ('scheduled', 'outstanding');
@@ -0,0 +1,2 @@
+-- Runs carry on past the loans that fail, record how many installments were left unchanged
+ALTER TABLE job_runs ADD COLUMN items_failed INT NOT NULL DEFAULT 0;
@@ -0,0 +1,20 @@
+DO $$
+BEGIN
+    -- Partially failed runs are recorded as failed without the value
+    UPDATE job_runs SET status = 'failed' WHERE status = 'partially_failed';
+
+    CREATE TYPE job_run_status_new AS ENUM ('running', 'succeeded', 'failed');
+
+    ALTER TABLE job_runs
+    ALTER COLUMN status DROP DEFAULT;
+
+    ALTER TABLE job_runs
+    ALTER COLUMN status TYPE job_run_status_new USING status::text::job_run_status_new;
+
+    ALTER TABLE job_runs
+    ALTER COLUMN status SET DEFAULT 'running';
+
+    DROP TYPE job_run_status;
+
+    ALTER TYPE job_run_status_new RENAME TO job_run_status;
+END $$;
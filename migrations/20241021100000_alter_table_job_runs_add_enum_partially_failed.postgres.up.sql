@@ -0,0 +1,9 @@
+-- Runs that carry on past the loans that fail are recorded as partially failed
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM pg_type WHERE typname = 'job_run_status'
+    ) THEN
+        ALTER TYPE job_run_status ADD VALUE IF NOT EXISTS 'partially_failed';
+    END IF;
+END $$;
@@ -0,0 +1,24 @@
+-- Rebuild the table without 'partially_failed', like the Postgres migration recreates the enum. Partially
+-- failed runs are recorded as failed.
+CREATE TABLE job_runs_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id VARCHAR(32) NOT NULL DEFAULT 'default',
+    job VARCHAR(64) NOT NULL,
+    business_date DATE NOT NULL,
+    triggered_by VARCHAR(16) NOT NULL CHECK (triggered_by IN ('schedule', 'catch_up', 'manual', 'simulation')),
+    status VARCHAR(16) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    items_processed INT NOT NULL DEFAULT 0,
+    error TEXT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    items_failed INT NOT NULL DEFAULT 0
+);
+
+INSERT INTO job_runs_new (id, tenant_id, job, business_date, triggered_by, status, items_processed, error, started_at, finished_at, created_at, items_failed)
+SELECT id, tenant_id, job, business_date, triggered_by, CASE status WHEN 'partially_failed' THEN 'failed' ELSE status END, items_processed, error, started_at, finished_at, created_at, items_failed FROM job_runs;
+
+DROP TABLE job_runs;
+ALTER TABLE job_runs_new RENAME TO job_runs;
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_tenant_job_date ON job_runs (tenant_id, job, business_date);
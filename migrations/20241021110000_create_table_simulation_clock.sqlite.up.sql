@@ -0,0 +1,7 @@
+-- Create simulation_clock table, the single row of how far the simulated clock is ahead of the system clock,
+-- so the business date of the simulation mode survives restarts
+CREATE TABLE IF NOT EXISTS simulation_clock (
+    id INT PRIMARY KEY CHECK (id = 1),
+    offset_seconds BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+-- Customers created since then are only known by their external ID to their tenant, it is dropped with the column
+DROP INDEX IF EXISTS idx_customers_tenant_external_id;
+ALTER TABLE customers DROP COLUMN IF EXISTS external_id;
@@ -0,0 +1,10 @@
+-- Customers are known to a tenant by the ID the tenant gives them, unique per tenant, so tenants can use the same
+-- IDs. The primary key is our own, existing customers keep theirs as their external ID.
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS external_id BIGINT;
+UPDATE customers SET external_id = id WHERE external_id IS NULL;
+ALTER TABLE customers ALTER COLUMN external_id SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_tenant_external_id ON customers (tenant_id, external_id);
+
+-- The IDs were given by the clients so far, the sequence never moved
+SELECT setval(pg_get_serial_sequence('customers', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM customers;
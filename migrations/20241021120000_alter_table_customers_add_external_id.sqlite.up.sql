@@ -0,0 +1,6 @@
+-- Customers are known to a tenant by the ID the tenant gives them, unique per tenant, so tenants can use the same
+-- IDs. The primary key is our own, existing customers keep theirs as their external ID.
+ALTER TABLE customers ADD COLUMN external_id INTEGER NOT NULL DEFAULT 0;
+UPDATE customers SET external_id = id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_tenant_external_id ON customers (tenant_id, external_id);
@@ -0,0 +1,7 @@
+-- Scope webhook subscriptions, their deliveries and the outbox to a tenant, existing rows belong to the default tenant
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(32) NOT NULL DEFAULT 'default';
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(32) NOT NULL DEFAULT 'default';
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(32) NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_tenant_id ON webhook_subscriptions (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_tenant_id ON webhook_deliveries (tenant_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_tenant_id;
+DROP INDEX IF EXISTS idx_webhook_subscriptions_tenant_id;
+
+ALTER TABLE outbox_events DROP COLUMN tenant_id;
+ALTER TABLE webhook_deliveries DROP COLUMN tenant_id;
+ALTER TABLE webhook_subscriptions DROP COLUMN tenant_id;
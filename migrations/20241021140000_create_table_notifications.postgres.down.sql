@@ -0,0 +1,5 @@
+-- Drop the notifications table
+DROP TABLE IF EXISTS notifications;
+
+-- Drop the notification_status enum type
+DROP TYPE IF EXISTS notification_status;
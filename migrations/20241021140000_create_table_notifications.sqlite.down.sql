@@ -0,0 +1,2 @@
+-- Drop the notifications table
+DROP TABLE IF EXISTS notifications;
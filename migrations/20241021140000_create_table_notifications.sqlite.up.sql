@@ -0,0 +1,19 @@
+-- Create notifications table like the Postgres one, whose notification_status enum is a CHECK constraint
+CREATE TABLE notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id VARCHAR(32) NOT NULL DEFAULT 'default',
+    payment_id INT NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    template VARCHAR(64) NOT NULL,
+    days_until_due INT NOT NULL DEFAULT 0,
+    status VARCHAR(16) DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT NULL,
+    sent_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_payment_template ON notifications (payment_id, template);
+CREATE INDEX IF NOT EXISTS idx_notifications_due ON notifications (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_notifications_tenant_id ON notifications (tenant_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_outbox_key;
+DROP INDEX IF EXISTS idx_outbox_relay;
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox_events (id) WHERE published_at IS NULL;
+
+ALTER TABLE outbox_events DROP COLUMN failed_at;
+ALTER TABLE outbox_events DROP COLUMN next_attempt_at;
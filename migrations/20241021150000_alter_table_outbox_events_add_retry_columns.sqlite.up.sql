@@ -0,0 +1,9 @@
+-- Retry failed outbox messages with backoff and give up on them after too many attempts, so messages that keep
+-- failing neither fill every relay batch nor get retried on every tick
+ALTER TABLE outbox_events ADD COLUMN next_attempt_at TIMESTAMP NULL;
+ALTER TABLE outbox_events ADD COLUMN failed_at TIMESTAMP NULL;
+
+-- The relay only scans the messages it may still publish, in insertion order, and checks the earlier ones of a key
+DROP INDEX IF EXISTS idx_outbox_unpublished;
+CREATE INDEX IF NOT EXISTS idx_outbox_relay ON outbox_events (id) WHERE published_at IS NULL AND failed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_key ON outbox_events (aggregate_type, aggregate_id, id) WHERE published_at IS NULL AND failed_at IS NULL;
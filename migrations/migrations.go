@@ -0,0 +1,100 @@
+// Package migrations holds the SQL migrations of the database schema. They are embedded in the service binary,
+// which applies them with its migrate command and knows the schema version it expects.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// Files are the up and down migrations, named <version>_<name>.<dialect>.<up|down>.sql. Every version has
+// the files of every dialect, so a schema at a version has the same tables and constraints on each backend.
+//
+//go:embed *.sql
+var Files embed.FS
+
+const (
+	// Dialects, named like the GORM dialectors
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+
+	// Directions of a migration file
+	up   = "up"
+	down = "down"
+)
+
+// Migration is a change of the schema, with the files moving the schema to it and back, by dialect
+type Migration struct {
+	Version string
+	Name    string
+	files   map[string]string
+}
+
+// UpFile returns the file moving the schema of the dialect to the migration, empty when there is none
+func (m *Migration) UpFile(dialect string) string {
+	return m.files[dialect+"."+up]
+}
+
+// DownFile returns the file undoing the migration on the dialect, empty when there is none
+func (m *Migration) DownFile(dialect string) string {
+	return m.files[dialect+"."+down]
+}
+
+// All returns the embedded migrations in the order they are applied
+func All() []*Migration {
+	entries, err := fs.ReadDir(Files, ".")
+	if err != nil {
+		return nil
+	}
+
+	byVersion := make(map[string]*Migration)
+	for _, entry := range entries {
+		file := entry.Name()
+		// <version>_<name>.<dialect>.<direction>.sql
+		parts := strings.Split(strings.TrimSuffix(file, ".sql"), ".")
+		if len(parts) != 3 || (parts[2] != up && parts[2] != down) {
+			continue
+		}
+		version, name, found := strings.Cut(parts[0], "_")
+		if !found {
+			continue
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name, files: make(map[string]string)}
+			byVersion[version] = migration
+		}
+		migration.files[parts[1]+"."+parts[2]] = file
+	}
+
+	var migrations []*Migration
+	for _, migration := range byVersion {
+		// A down migration alone does not change the schema
+		if migration.UpFile(DialectPostgres) != "" || migration.UpFile(DialectSQLite) != "" {
+			migrations = append(migrations, migration)
+		}
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations
+}
+
+// LatestVersion returns the version of the newest up migration, the version of a fully migrated schema
+func LatestVersion() string {
+	versions := Versions()
+	if len(versions) == 0 {
+		return ""
+	}
+	return versions[len(versions)-1]
+}
+
+// Versions returns the versions of the up migrations in the order they are applied
+func Versions() []string {
+	var versions []string
+	for _, migration := range All() {
+		versions = append(versions, migration.Version)
+	}
+	return versions
+}
@@ -0,0 +1,239 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// lockKey identifies the Postgres advisory lock held while migrating, so two instances started
+// at the same time do not apply the same migration twice
+const lockKey int64 = 7_204_411_001
+
+// Status tells whether a migration is applied to the database. Migration is nil for a version
+// recorded in the database that the binary does not embed, e.g. applied by a newer release.
+type Status struct {
+	Version   string
+	Migration *Migration
+	Applied   bool
+}
+
+// Migrator applies the embedded migrations of a dialect and records their versions in the schema_migration table,
+// the one Soda keeps, so databases migrated with Soda carry on with the service binary
+type Migrator struct {
+	db      *sql.DB
+	dialect string
+}
+
+func NewMigrator(db *sql.DB, dialect string) *Migrator {
+	return &Migrator{db: db, dialect: dialect}
+}
+
+// Up applies every migration that is not applied yet, oldest first, each in its own transaction,
+// and returns the migrations it applied
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	var applied []*Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range All() {
+			if versions[migration.Version] {
+				continue
+			}
+			file := migration.UpFile(m.dialect)
+			if file == "" {
+				return fmt.Errorf("migration %s_%s has no %s migration", migration.Version, migration.Name, m.dialect)
+			}
+			if err := m.apply(ctx, conn, migration, file, "INSERT INTO schema_migration (version) VALUES ("+m.placeholder()+")"); err != nil {
+				return err
+			}
+			log.WithField("version", migration.Version).WithField("name", migration.Name).Info("Migration applied")
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the steps newest applied migrations, newest first, and returns the migrations it rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+
+	var rolledBack []*Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		byVersion := make(map[string]*Migration)
+		for _, migration := range All() {
+			byVersion[migration.Version] = migration
+		}
+
+		newestFirst := make([]string, 0, len(versions))
+		for version := range versions {
+			newestFirst = append(newestFirst, version)
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(newestFirst)))
+
+		for _, version := range newestFirst {
+			if len(rolledBack) == steps {
+				break
+			}
+			migration, ok := byVersion[version]
+			if !ok {
+				return fmt.Errorf("migration %s is applied but not embedded in this binary, roll it back with the release that applied it", version)
+			}
+			file := migration.DownFile(m.dialect)
+			if file == "" {
+				return fmt.Errorf("migration %s_%s has no %s down migration", migration.Version, migration.Name, m.dialect)
+			}
+			if err := m.apply(ctx, conn, migration, file, "DELETE FROM schema_migration WHERE version = "+m.placeholder()); err != nil {
+				return err
+			}
+			log.WithField("version", migration.Version).WithField("name", migration.Name).Info("Migration rolled back")
+			rolledBack = append(rolledBack, migration)
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status lists every embedded migration and every applied version, oldest first
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get a database connection")
+	}
+	defer conn.Close()
+
+	versions, err := m.appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, migration := range All() {
+		statuses = append(statuses, Status{Version: migration.Version, Migration: migration, Applied: versions[migration.Version]})
+		delete(versions, migration.Version)
+	}
+	for version := range versions {
+		statuses = append(statuses, Status{Version: version, Applied: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// withLock runs fn on a single connection holding the migration lock, with the version table created.
+// SQLite databases belong to a single process and are not locked.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get a database connection")
+	}
+	defer conn.Close()
+
+	if m.dialect == DialectPostgres {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+			return errors.Wrap(err, "failed to take the migration lock")
+		}
+		defer func() {
+			// The lock is released with the session anyway, a failed unlock only delays the next migration
+			if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+				log.WithError(err).Warn("Failed to release the migration lock")
+			}
+		}()
+	}
+
+	if err := createVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// apply runs the SQL file and records the change of version in the same transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration *Migration, file string, recordVersion string) error {
+	content, err := Files.ReadFile(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read migration %s", file)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin migration transaction")
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op once committed
+
+	if _, err := tx.ExecContext(ctx, string(content)); err != nil {
+		return errors.Wrapf(err, "failed to run migration %s", file)
+	}
+	if _, err := tx.ExecContext(ctx, recordVersion, migration.Version); err != nil {
+		return errors.Wrapf(err, "failed to record schema version %s", migration.Version)
+	}
+	return errors.Wrapf(tx.Commit(), "failed to commit migration %s", file)
+}
+
+// createVersionTable creates the version table the way Soda does, when the database has none yet
+func createVersionTable(ctx context.Context, conn *sql.Conn) error {
+	statements := []string{
+		"CREATE TABLE IF NOT EXISTS schema_migration (version varchar(14) NOT NULL)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS schema_migration_version_idx ON schema_migration (version)",
+	}
+	for _, statement := range statements {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return errors.Wrap(err, "failed to create the schema version table")
+		}
+	}
+	return nil
+}
+
+// placeholder is the parameter of the recorded version in the statements of the dialect
+func (m *Migrator) placeholder() string {
+	if m.dialect == DialectPostgres {
+		return "$1"
+	}
+	return "?"
+}
+
+// appliedVersions reads the versions recorded in the version table, none when the table does not exist yet
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[string]bool, error) {
+	tableExists := "SELECT to_regclass('schema_migration') IS NOT NULL"
+	if m.dialect != DialectPostgres {
+		tableExists = "SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migration'"
+	}
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, tableExists).Scan(&exists); err != nil {
+		return nil, errors.Wrap(err, "failed to look up the schema version table")
+	}
+
+	versions := make(map[string]bool)
+	if !exists {
+		return versions, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version FROM schema_migration")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the schema versions")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, errors.Wrap(err, "failed to read the schema versions")
+		}
+		versions[version] = true
+	}
+	return versions, errors.Wrap(rows.Err(), "failed to read the schema versions")
+}
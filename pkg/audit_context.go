@@ -0,0 +1,42 @@
+// pkg/audit_context.go
+package pkg
+
+import "context"
+
+const (
+	// SystemActor is recorded for writes made outside of a request, e.g. by scheduled jobs or the CLI
+	SystemActor = "system"
+	// AnonymousActor is recorded for requests that did not identify their caller
+	AnonymousActor = "anonymous"
+)
+
+type auditContextKey string
+
+const (
+	actorContextKey     auditContextKey = "actor"
+	requestIDContextKey auditContextKey = "request_id"
+)
+
+// WithActor returns a context recording who performs the work, it is recorded in the audit log
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey, actor)
+}
+
+// GetActor returns the actor of the context, or SystemActor when the work does not come from a request
+func GetActor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorContextKey).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
+
+// WithRequestID returns a context carrying the ID correlating the audit entries written by one request
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// GetRequestID returns the ID of the request, or an empty string when there is no request
+func GetRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
@@ -0,0 +1,284 @@
+// pkg/config/config.go
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration of the service. Load builds it from the defaults, then the YAML file at
+// CONFIG_FILE, then the environment, so a variable always wins over the file.
+type Config struct {
+	Database     DatabaseConfig     `yaml:"database"`
+	HTTP         HTTPConfig         `yaml:"http"`
+	GRPC         GRPCConfig         `yaml:"grpc"`
+	Scheduler    SchedulerConfig    `yaml:"scheduler"`
+	Log          LogConfig          `yaml:"log"`
+	Auth         AuthConfig         `yaml:"auth"`
+	Tenants      TenantsConfig      `yaml:"tenants"`
+	Notification NotificationConfig `yaml:"notification"`
+	Webhook      WebhookConfig      `yaml:"webhook"`
+	Events       EventsConfig       `yaml:"events"`
+	Tracing      TracingConfig      `yaml:"tracing"`
+	Features     FeaturesConfig     `yaml:"features"`
+}
+
+// DatabaseConfig selects the storage backend. Postgres is the one of deployments, SQLite stores everything
+// in the file at Path so local runs and the tests need no database server.
+type DatabaseConfig struct {
+	// Driver is postgres or sqlite
+	Driver   string `yaml:"driver"`
+	Path     string `yaml:"path"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+	// Pool of connections shared by the requests and the jobs
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	// SlowQueryThreshold is the duration above which a query is logged as slow
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+}
+
+// DSN is the Data Source Name of the database. SQLite enforces foreign keys, waits for the lock of another
+// writer instead of failing right away, and takes the write lock when a transaction begins, since it cannot
+// upgrade the read lock of a transaction once another connection wrote.
+func (c DatabaseConfig) DSN() string {
+	if c.Driver == DriverSQLite {
+		return c.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	}
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s", c.Host, c.User, c.Password, c.Name, c.Port, c.SSLMode)
+}
+
+type HTTPConfig struct {
+	Port              int           `yaml:"port"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout bounds how long in-flight requests and calls may take to finish on shutdown
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type GRPCConfig struct {
+	Port int `yaml:"port"`
+}
+
+// SchedulerConfig holds the cron specs of the jobs. The daily jobs run once per tenant in the timezone of
+// the tenant, DefaultTimezone is the one of tenants that do not configure any.
+type SchedulerConfig struct {
+	Enabled             bool   `yaml:"enabled"`
+	DefaultTimezone     string `yaml:"default_timezone"`
+	PaymentStatusCron   string `yaml:"payment_status_cron"`
+	PaymentReminderCron string `yaml:"payment_reminder_cron"`
+	WebhookDeliveryCron string `yaml:"webhook_delivery_cron"`
+	OutboxRelayCron     string `yaml:"outbox_relay_cron"`
+	// NotificationDeliveryCron sends the queued reminders and pending installment notices
+	NotificationDeliveryCron string `yaml:"notification_delivery_cron"`
+	// CatchUpDays is how many missed business dates the payment status update catches up on startup, 0 disables it
+	CatchUpDays int `yaml:"catch_up_days"`
+	// StatusUpdateBatchSize is how many loans the payment status update reads at once
+	StatusUpdateBatchSize int `yaml:"status_update_batch_size"`
+	// StatusUpdateWorkers is how many loans the payment status update processes in parallel, each on a connection of the pool
+	StatusUpdateWorkers int `yaml:"status_update_workers"`
+}
+
+type LogConfig struct {
+	// Level is a logrus level: trace, debug, info, warn, error, fatal or panic
+	Level string `yaml:"level"`
+	// Format is json or text
+	Format string `yaml:"format"`
+}
+
+type AuthConfig struct {
+	// APIKeys is a comma separated list of name:role:sha256-hex[:tenant] entries
+	APIKeys   string `yaml:"api_keys"`
+	JWTSecret string `yaml:"jwt_secret"`
+	JWTIssuer string `yaml:"jwt_issuer"`
+}
+
+type TenantsConfig struct {
+	// File is the JSON file with the tenants, a single default tenant is served when it is empty
+	File string `yaml:"file"`
+}
+
+type NotificationConfig struct {
+	// Channels used for reminders: email, sms and log
+	Channels           []string   `yaml:"channels"`
+	ReminderDaysBefore int        `yaml:"reminder_days_before"`
+	SMTP               SMTPConfig `yaml:"smtp"`
+	SMS                SMSConfig  `yaml:"sms"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+	// Timeout bounds how long sending one email may take, from connecting to the end of the message
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type SMSConfig struct {
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"api_key"`
+	Sender string `yaml:"sender"`
+}
+
+type WebhookConfig struct {
+	// Timeout bounds how long a subscriber may take to answer a webhook call
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type EventsConfig struct {
+	// BrokerFile additionally appends every relayed event to this JSON lines file when set
+	BrokerFile string `yaml:"broker_file"`
+}
+
+type TracingConfig struct {
+	// Exporter is otlp, stdout, file or none, otlp when OTEL_EXPORTER_OTLP_ENDPOINT is set and stdout otherwise by default
+	Exporter string `yaml:"exporter"`
+	// File the spans are appended to by the file exporter
+	File string `yaml:"file"`
+}
+
+// FeaturesConfig switches optional parts of the service on and off
+type FeaturesConfig struct {
+	GRPC    bool `yaml:"grpc"`
+	Metrics bool `yaml:"metrics"`
+	// Simulation runs the service on a clock advanced through the simulation endpoints, for QA environments only
+	Simulation bool `yaml:"simulation"`
+}
+
+// Default returns the configuration used for every setting neither the file nor the environment sets
+func Default() *Config {
+	return &Config{
+		Database: DatabaseConfig{
+			Driver:             DriverPostgres,
+			Path:               "billing.db",
+			Host:               "localhost",
+			Port:               5432,
+			User:               "postgres",
+			Name:               "billing",
+			SSLMode:            "disable",
+			MaxOpenConns:       25,
+			MaxIdleConns:       5,
+			ConnMaxLifetime:    30 * time.Minute,
+			ConnMaxIdleTime:    5 * time.Minute,
+			SlowQueryThreshold: time.Second,
+		},
+		HTTP: HTTPConfig{
+			Port:              8080,
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   5 * time.Second,
+		},
+		GRPC: GRPCConfig{
+			Port: 9090,
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:                  true,
+			DefaultTimezone:          "Asia/Jakarta",
+			PaymentStatusCron:        "0 0 * * *",
+			PaymentReminderCron:      "0 8 * * *",
+			WebhookDeliveryCron:      "@every 1m",
+			OutboxRelayCron:          "@every 5s",
+			NotificationDeliveryCron: "@every 1m",
+			CatchUpDays:              7,
+			StatusUpdateBatchSize:    500,
+			StatusUpdateWorkers:      8,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: LogFormatJSON,
+		},
+		Notification: NotificationConfig{
+			Channels:           []string{ChannelLog},
+			ReminderDaysBefore: 3,
+			SMTP: SMTPConfig{
+				Timeout: 10 * time.Second,
+			},
+		},
+		Webhook: WebhookConfig{
+			Timeout: 10 * time.Second,
+		},
+		Features: FeaturesConfig{
+			GRPC:    true,
+			Metrics: true,
+		},
+	}
+}
+
+// Load reads the env files that exist, in order, then builds and validates the configuration. Env files never
+// override a variable that is already set, so the first file setting a variable wins over the next ones.
+func Load(envFiles ...string) (*Config, error) {
+	for _, envFile := range envFiles {
+		if err := godotenv.Load(envFile); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				log.WithField("envFile", envFile).Debug("Environment file not found, skipping")
+				continue
+			}
+			return nil, fmt.Errorf("failed to load environment file %s: %w", envFile, err)
+		}
+		log.WithField("envFile", envFile).Info("Environment variables loaded")
+	}
+
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	cfg.resolve()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile overlays the YAML file on the configuration, rejecting keys the configuration does not have
+func (c *Config) loadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	log.WithField("configFile", path).Info("Configuration file loaded")
+	return nil
+}
+
+// resolve fills in the settings whose default depends on other settings
+func (c *Config) resolve() {
+	if c.Tracing.Exporter == "" {
+		c.Tracing.Exporter = TraceExporterStdout
+		if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
+			c.Tracing.Exporter = TraceExporterOTLP
+		}
+	}
+}
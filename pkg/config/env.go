@@ -0,0 +1,162 @@
+// pkg/config/env.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loadEnv overlays the environment variables on the configuration. An empty variable counts as not set,
+// so the blank entries of the env files keep the value of the defaults or of the config file.
+func (c *Config) loadEnv() error {
+	env := &envReader{}
+
+	env.string("DB_DRIVER", &c.Database.Driver)
+	env.string("DB_PATH", &c.Database.Path)
+	env.string("DB_HOST", &c.Database.Host)
+	env.int("DB_PORT", &c.Database.Port)
+	env.string("DB_USER", &c.Database.User)
+	env.string("DB_PASSWORD", &c.Database.Password)
+	env.string("DB_NAME", &c.Database.Name)
+	env.string("DB_SSLMODE", &c.Database.SSLMode)
+	env.int("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	env.int("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	env.duration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	env.duration("DB_CONN_MAX_IDLE_TIME", &c.Database.ConnMaxIdleTime)
+	env.duration("DB_SLOW_QUERY_THRESHOLD", &c.Database.SlowQueryThreshold)
+
+	env.int("PORT", &c.HTTP.Port)
+	env.duration("READ_HEADER_TIMEOUT", &c.HTTP.ReadHeaderTimeout)
+	env.duration("HTTP_READ_TIMEOUT", &c.HTTP.ReadTimeout)
+	env.duration("HTTP_WRITE_TIMEOUT", &c.HTTP.WriteTimeout)
+	env.duration("HTTP_IDLE_TIMEOUT", &c.HTTP.IdleTimeout)
+	env.duration("SHUTDOWN_TIMEOUT", &c.HTTP.ShutdownTimeout)
+
+	env.int("GRPC_PORT", &c.GRPC.Port)
+
+	env.bool("SCHEDULER_ENABLED", &c.Scheduler.Enabled)
+	env.string("SCHEDULER_DEFAULT_TIMEZONE", &c.Scheduler.DefaultTimezone)
+	env.string("PAYMENT_STATUS_CRON", &c.Scheduler.PaymentStatusCron)
+	env.string("PAYMENT_REMINDER_CRON", &c.Scheduler.PaymentReminderCron)
+	env.string("WEBHOOK_DELIVERY_CRON", &c.Scheduler.WebhookDeliveryCron)
+	env.string("OUTBOX_RELAY_CRON", &c.Scheduler.OutboxRelayCron)
+	env.string("NOTIFICATION_DELIVERY_CRON", &c.Scheduler.NotificationDeliveryCron)
+	env.int("SCHEDULER_CATCH_UP_DAYS", &c.Scheduler.CatchUpDays)
+	env.int("SCHEDULER_STATUS_UPDATE_BATCH_SIZE", &c.Scheduler.StatusUpdateBatchSize)
+	env.int("SCHEDULER_STATUS_UPDATE_WORKERS", &c.Scheduler.StatusUpdateWorkers)
+
+	env.string("LOG_LEVEL", &c.Log.Level)
+	env.string("LOG_FORMAT", &c.Log.Format)
+
+	env.string("API_KEYS", &c.Auth.APIKeys)
+	env.string("JWT_SECRET", &c.Auth.JWTSecret)
+	env.string("JWT_ISSUER", &c.Auth.JWTIssuer)
+
+	env.string("TENANTS_FILE", &c.Tenants.File)
+
+	env.list("NOTIFICATION_CHANNELS", &c.Notification.Channels)
+	env.int("REMINDER_DAYS_BEFORE", &c.Notification.ReminderDaysBefore)
+	env.string("SMTP_HOST", &c.Notification.SMTP.Host)
+	env.string("SMTP_PORT", &c.Notification.SMTP.Port)
+	env.string("SMTP_USERNAME", &c.Notification.SMTP.Username)
+	env.string("SMTP_PASSWORD", &c.Notification.SMTP.Password)
+	env.string("SMTP_FROM", &c.Notification.SMTP.From)
+	env.duration("SMTP_TIMEOUT", &c.Notification.SMTP.Timeout)
+	env.string("SMS_PROVIDER_URL", &c.Notification.SMS.URL)
+	env.string("SMS_PROVIDER_API_KEY", &c.Notification.SMS.APIKey)
+	env.string("SMS_SENDER", &c.Notification.SMS.Sender)
+
+	env.duration("WEBHOOK_TIMEOUT", &c.Webhook.Timeout)
+
+	env.string("EVENT_BROKER_FILE", &c.Events.BrokerFile)
+
+	env.string("TRACE_EXPORTER", &c.Tracing.Exporter)
+	env.string("TRACE_FILE", &c.Tracing.File)
+
+	env.bool("FEATURE_GRPC", &c.Features.GRPC)
+	env.bool("FEATURE_METRICS", &c.Features.Metrics)
+	env.bool("FEATURE_SIMULATION", &c.Features.Simulation)
+
+	return errors.Join(env.errs...)
+}
+
+// envReader parses the variables into their setting and collects the values it cannot parse,
+// so a misconfigured deployment learns about every bad variable at once
+type envReader struct {
+	errs []error
+}
+
+func (r *envReader) lookup(key string) (string, bool) {
+	value, ok := os.LookupEnv(key)
+	value = strings.TrimSpace(value)
+	return value, ok && value != ""
+}
+
+func (r *envReader) string(key string, target *string) {
+	if value, ok := r.lookup(key); ok {
+		*target = value
+	}
+}
+
+func (r *envReader) int(key string, target *int) {
+	value, ok := r.lookup(key)
+	if !ok {
+		return
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be an integer, got %q", key, value))
+		return
+	}
+	*target = parsed
+}
+
+func (r *envReader) bool(key string, target *bool) {
+	value, ok := r.lookup(key)
+	if !ok {
+		return
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a boolean, got %q", key, value))
+		return
+	}
+	*target = parsed
+}
+
+// duration reads a Go duration, e.g. 1m30s, or a number of seconds
+func (r *envReader) duration(key string, target *time.Duration) {
+	value, ok := r.lookup(key)
+	if !ok {
+		return
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		*target = time.Duration(seconds) * time.Second
+		return
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a duration like 30s or a number of seconds, got %q", key, value))
+		return
+	}
+	*target = parsed
+}
+
+// list reads a comma separated list, ignoring the empty entries
+func (r *envReader) list(key string, target *[]string) {
+	value, ok := r.lookup(key)
+	if !ok {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*target = items
+}
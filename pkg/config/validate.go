@@ -0,0 +1,123 @@
+// pkg/config/validate.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Database drivers
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+
+	// Log formats
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+
+	// Notification channels
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelLog   = "log"
+
+	// Trace exporters
+	TraceExporterOTLP   = "otlp"
+	TraceExporterStdout = "stdout"
+	TraceExporterFile   = "file"
+	TraceExporterNone   = "none"
+)
+
+// Validate reports every invalid setting of the configuration at once
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	switch c.Database.Driver {
+	case DriverPostgres:
+		check(c.Database.Host != "", "database host is required")
+		check(validPort(c.Database.Port), "database port %d is not a valid port", c.Database.Port)
+		check(c.Database.User != "", "database user is required")
+		check(c.Database.Name != "", "database name is required")
+	case DriverSQLite:
+		check(c.Database.Path != "", "database path is required by the sqlite driver")
+	default:
+		check(false, "database driver %q is not one of postgres or sqlite", c.Database.Driver)
+	}
+	check(c.Database.MaxOpenConns > 0, "database max open connections must be positive")
+	check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database max idle connections must be between 0 and the max open connections (%d)", c.Database.MaxOpenConns)
+	check(c.Database.ConnMaxLifetime >= 0, "database connection max lifetime must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database connection max idle time must not be negative")
+	check(c.Database.SlowQueryThreshold > 0, "database slow query threshold must be positive")
+
+	check(validPort(c.HTTP.Port), "HTTP port %d is not a valid port", c.HTTP.Port)
+	check(c.HTTP.ReadHeaderTimeout > 0, "HTTP read header timeout must be positive")
+	check(c.HTTP.ReadTimeout > 0, "HTTP read timeout must be positive")
+	check(c.HTTP.WriteTimeout > 0, "HTTP write timeout must be positive")
+	check(c.HTTP.IdleTimeout > 0, "HTTP idle timeout must be positive")
+	check(c.HTTP.ShutdownTimeout > 0, "shutdown timeout must be positive")
+
+	if c.Features.GRPC {
+		check(validPort(c.GRPC.Port), "gRPC port %d is not a valid port", c.GRPC.Port)
+		check(c.GRPC.Port != c.HTTP.Port, "gRPC port %d is already the HTTP port", c.GRPC.Port)
+	}
+
+	_, err := time.LoadLocation(c.Scheduler.DefaultTimezone)
+	check(c.Scheduler.DefaultTimezone != "" && err == nil, "scheduler default timezone %q is not a known timezone", c.Scheduler.DefaultTimezone)
+	for _, job := range []struct{ name, spec string }{
+		{"payment status", c.Scheduler.PaymentStatusCron},
+		{"payment reminder", c.Scheduler.PaymentReminderCron},
+		{"webhook delivery", c.Scheduler.WebhookDeliveryCron},
+		{"outbox relay", c.Scheduler.OutboxRelayCron},
+		{"notification delivery", c.Scheduler.NotificationDeliveryCron},
+	} {
+		_, err := cron.ParseStandard(job.spec)
+		check(err == nil, "%s cron %q is invalid: %v", job.name, job.spec, err)
+	}
+	check(c.Scheduler.CatchUpDays >= 0, "scheduler catch up days must not be negative")
+	check(c.Scheduler.StatusUpdateBatchSize > 0, "scheduler status update batch size must be positive")
+	check(c.Scheduler.StatusUpdateWorkers > 0, "scheduler status update workers must be positive")
+
+	_, err = log.ParseLevel(c.Log.Level)
+	check(err == nil, "log level %q is not one of trace, debug, info, warn, error, fatal or panic", c.Log.Level)
+	check(c.Log.Format == LogFormatJSON || c.Log.Format == LogFormatText, "log format %q is not one of json or text", c.Log.Format)
+
+	for _, channel := range c.Notification.Channels {
+		check(channel == ChannelEmail || channel == ChannelSMS || channel == ChannelLog, "notification channel %q is not one of email, sms or log", channel)
+		if channel == ChannelEmail {
+			check(c.Notification.SMTP.Host != "", "SMTP host is required by the email channel")
+		}
+		if channel == ChannelSMS {
+			check(c.Notification.SMS.URL != "", "SMS provider URL is required by the sms channel")
+		}
+	}
+	check(c.Notification.ReminderDaysBefore >= 0, "reminder days before must not be negative")
+	check(c.Notification.SMTP.Timeout > 0, "SMTP timeout must be positive")
+
+	check(c.Webhook.Timeout > 0, "webhook timeout must be positive")
+
+	switch c.Tracing.Exporter {
+	case TraceExporterOTLP, TraceExporterStdout, TraceExporterNone:
+	case TraceExporterFile:
+		check(c.Tracing.File != "", "trace file is required by the file trace exporter")
+	default:
+		check(false, "trace exporter %q is not one of otlp, stdout, file or none", c.Tracing.Exporter)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
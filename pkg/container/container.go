@@ -2,52 +2,206 @@
 package container
 
 import (
+	"billing_enginee/api/openapi"
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/broker"
+	"billing_enginee/internal/health"
+	"billing_enginee/internal/metrics"
+	"billing_enginee/internal/notification"
 	"billing_enginee/internal/repository"
+	"billing_enginee/internal/tenant"
 	"billing_enginee/internal/usecase"
+	"billing_enginee/internal/webhook"
+	"billing_enginee/migrations"
 	"billing_enginee/pkg"
+	"billing_enginee/pkg/config"
+	"context"
 	"database/sql"
 	"fmt"
 
+	"github.com/getkin/kin-openapi/routers"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"gorm.io/gorm"
 )
 
 type Container struct {
-	DB              *gorm.DB
-	SQLDB           *sql.DB
-	Router          *gin.Engine
-	CustomerUsecase usecase.CustomerUsecase
-	PaymentUsecase  usecase.PaymentUsecase
-	LoanUsecase     usecase.LoanUsecase
+	Config     *config.Config
+	DB         *gorm.DB
+	SQLDB      *sql.DB
+	UnitOfWork pkg.UnitOfWork
+	Router     *gin.Engine
+	// OpenAPIRouter finds the operations of the OpenAPI document the requests are validated against
+	OpenAPIRouter       routers.Router
+	Authenticator       auth.Authenticator
+	Tenants             tenant.Registry
+	Clock               pkg.Clock
+	Leader              pkg.Leader
+	JobLock             pkg.JobLock
+	CustomerUsecase     usecase.CustomerUsecase
+	PaymentUsecase      usecase.PaymentUsecase
+	LoanUsecase         usecase.LoanUsecase
+	ReportUsecase       usecase.ReportUsecase
+	NotificationUsecase usecase.NotificationUsecase
+	WebhookUsecase      usecase.WebhookUsecase
+	OutboxUsecase       usecase.OutboxUsecase
+	AuditUsecase        usecase.AuditUsecase
+	JobRunUsecase       usecase.JobRunUsecase
+	// SimulationUsecase is only set when the simulation feature is enabled
+	SimulationUsecase usecase.SimulationUsecase
+	Metrics           *prometheus.Registry
+	Health            *health.Checker
 }
 
-func NewContainer() (*Container, error) {
+// NewContainer wires the dependencies of the service from the validated configuration
+func NewContainer(cfg *config.Config) (*Container, error) {
 	// Initialize DB
-	db, sqlDb, err := pkg.InitDB()
+	db, sqlDb, err := pkg.InitDB(cfg.Database)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize DB: %w", err)
 	}
 
-	// Initialize router
-	router := gin.Default()
+	// Initialize router, requests are logged with their request ID by RequestLoggerMiddleware
+	router := gin.New()
+	router.Use(gin.Recovery())
 	pkg.InitValidators()
 
+	openAPIDoc, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+	openAPIRouter, err := openapi.NewRouter(openAPIDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticator, err := auth.NewAuthenticatorFromConfig(cfg.Auth.APIKeys, cfg.Auth.JWTSecret, cfg.Auth.JWTIssuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize authentication: %w", err)
+	}
+
+	tenants, err := tenant.NewRegistryFromFile(cfg.Tenants.File, cfg.Scheduler.DefaultTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenants: %w", err)
+	}
+
+	// The business time comes from the system clock, or from a clock QA advances in the simulation mode.
+	// The simulated clock starts where the last process stored it.
+	var clock pkg.Clock = pkg.NewSystemClock()
+	var simulatedClock *pkg.SimulatedClock
+	simulationClockRepo := repository.NewSimulationClockRepository(db)
+	if cfg.Features.Simulation {
+		offset, err := simulationClockRepo.GetOffset(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore the simulation clock: %w", err)
+		}
+		simulatedClock = pkg.NewSimulatedClock()
+		simulatedClock.Restore(offset)
+		clock = simulatedClock
+	}
+
+	// Usecases run their writes in units of work that join the transaction of the request, if any
+	uow := pkg.NewUnitOfWork(db)
+
 	// Repositories and Usecases
 	customerRepo := repository.NewCustomerRepository(db)
-	customerUsecase := usecase.NewCustomerUsecase(customerRepo)
+	customerUsecase := usecase.NewCustomerUsecase(customerRepo, tenants)
+
+	webhookRepo := repository.NewWebhookRepository(db)
+	webhookUsecase := usecase.NewWebhookUsecase(uow, webhookRepo, webhook.NewHTTPSender(cfg.Webhook.Timeout))
 
+	// Events are written to the outbox and relayed to the broker, webhooks consume them in-process
+	outboxRepo := repository.NewOutboxRepository(db)
+	outboxUsecase := usecase.NewOutboxUsecase(outboxRepo, newEventBroker(webhookUsecase, cfg.Events.BrokerFile))
+
+	// Notifications are queued with the changes they are about and sent by their own scheduled task
 	paymentRepo := repository.NewPaymentRepository(db)
-	paymentUsecase := usecase.NewPaymentUsecase(paymentRepo)
+	notificationRepo := repository.NewNotificationRepository(db)
+	notifier := notification.NewNotifier(newNotificationChannels(cfg.Notification)...)
+	notificationUsecase := usecase.NewNotificationUsecase(uow, paymentRepo, notificationRepo, notifier, cfg.Notification.ReminderDaysBefore)
+	paymentUsecase := usecase.NewPaymentUsecase(uow, paymentRepo, notificationUsecase, outboxUsecase, cfg.Scheduler.StatusUpdateBatchSize, cfg.Scheduler.StatusUpdateWorkers)
 
 	loanRepo := repository.NewLoanRepository(db)
-	loanUsecase := usecase.NewLoanUsecase(loanRepo, customerRepo, paymentRepo)
+	loanUsecase := usecase.NewLoanUsecase(uow, loanRepo, customerRepo, paymentRepo, outboxUsecase, tenants, clock)
+
+	// Every run of the daily payment status update is recorded, whatever started it
+	jobRunRepo := repository.NewJobRunRepository(db)
+	jobLock := pkg.NewJobLock(sqlDb, cfg.Database.Driver)
+	jobRunUsecase := usecase.NewJobRunUsecase(jobRunRepo, paymentUsecase, tenants, clock, jobLock)
+
+	var simulationUsecase usecase.SimulationUsecase
+	if simulatedClock != nil {
+		simulationUsecase = usecase.NewSimulationUsecase(simulatedClock, simulationClockRepo, tenants, jobRunUsecase, notificationUsecase)
+	}
+
+	reportRepo := repository.NewReportRepository(db)
+	reportUsecase := usecase.NewReportUsecase(reportRepo)
+
+	auditRepo := repository.NewAuditRepository(db)
+	auditUsecase := usecase.NewAuditUsecase(auditRepo)
+
+	// Readiness needs the database at the schema version of the embedded migrations, main adds the scheduler
+	healthChecker := health.NewChecker()
+	healthChecker.AddCheck("database", health.DatabaseCheck(sqlDb))
+	healthChecker.AddCheck("schema", health.SchemaCheck(sqlDb, migrations.LatestVersion()))
+
+	// Metrics of the service, with the portfolio gauges read from the report on every scrape
+	metricsRegistry := metrics.NewRegistry(sqlDb, usecase.NewPortfolioMetricsSource(reportUsecase), tenants)
 
 	return &Container{
-		DB:              db,
-		SQLDB:           sqlDb,
-		Router:          router,
-		CustomerUsecase: customerUsecase,
-		PaymentUsecase:  paymentUsecase,
-		LoanUsecase:     loanUsecase,
+		Config:              cfg,
+		DB:                  db,
+		SQLDB:               sqlDb,
+		UnitOfWork:          uow,
+		Router:              router,
+		OpenAPIRouter:       openAPIRouter,
+		Authenticator:       authenticator,
+		Tenants:             tenants,
+		Clock:               clock,
+		Leader:              pkg.NewLeader(sqlDb, cfg.Database.Driver),
+		JobLock:             jobLock,
+		CustomerUsecase:     customerUsecase,
+		PaymentUsecase:      paymentUsecase,
+		LoanUsecase:         loanUsecase,
+		ReportUsecase:       reportUsecase,
+		NotificationUsecase: notificationUsecase,
+		WebhookUsecase:      webhookUsecase,
+		OutboxUsecase:       outboxUsecase,
+		AuditUsecase:        auditUsecase,
+		JobRunUsecase:       jobRunUsecase,
+		SimulationUsecase:   simulationUsecase,
+		Metrics:             metricsRegistry,
+		Health:              healthChecker,
 	}, nil
 }
+
+// newEventBroker builds the broker the outbox relays to. Webhooks always consume events in-process,
+// and a broker file additionally receives every event as a JSON line for local runs.
+func newEventBroker(webhookUsecase usecase.WebhookUsecase, path string) broker.Broker {
+	inProcess := broker.NewInProcessBroker()
+	inProcess.Subscribe(webhookUsecase.HandleMessage)
+
+	if path != "" {
+		return broker.NewMultiBroker(inProcess, broker.NewFileBroker(path))
+	}
+	return inProcess
+}
+
+// newNotificationChannels builds the reminder channels of the configuration
+func newNotificationChannels(cfg config.NotificationConfig) []notification.Channel {
+	return notification.NewChannels(cfg.Channels,
+		notification.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+			Timeout:  cfg.SMTP.Timeout,
+		},
+		notification.SMSConfig{
+			URL:    cfg.SMS.URL,
+			APIKey: cfg.SMS.APIKey,
+			Sender: cfg.SMS.Sender,
+		},
+	)
+}
@@ -1,11 +1,11 @@
 package pkg
 
 import (
+	"billing_enginee/pkg/config"
 	"database/sql"
 	"fmt"
-	"os"
-	"time"
 
+	"github.com/glebarez/sqlite"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -15,85 +15,87 @@ import (
 var DB *gorm.DB
 var err error
 
-func InitDB() (*gorm.DB, *sql.DB, error) {
-	// Load environment variables centrally
-	LoadEnv(".env")
-
-	// Read database connection parameters from the environment
-	dsn := createDSN()
-
+// InitDB connects to the main database with the pool of the configuration
+func InitDB(cfg config.DatabaseConfig) (*gorm.DB, *sql.DB, error) {
 	// Open the database connection with custom GORM logger
-	DB, err = openDBConnection(dsn)
+	DB, err = openDBConnection(cfg)
 	if err != nil {
 		log.WithError(err).Error("Failed to connect to main database")
 		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// Scope the queries of tenant sessions to their tenant
+	if err := RegisterTenantScope(DB); err != nil {
+		return nil, nil, fmt.Errorf("failed to register tenant scope: %w", err)
+	}
+
+	// Trace the statements under the span of their context
+	if err := RegisterQueryTracing(DB); err != nil {
+		return nil, nil, fmt.Errorf("failed to register query tracing: %w", err)
+	}
+
 	// Get the underlying sql.DB connection from the gorm.DB
 	sqlDB, err := DB.DB()
 	if err != nil {
 		log.WithError(err).Error("Failed to get sql.DB from gorm.DB")
 		return nil, nil, fmt.Errorf("failed to get sql.DB from gorm.DB: %w", err)
 	}
+	configurePool(sqlDB, cfg)
 
 	log.Info("Successfully connected to the main database")
 	return DB, sqlDB, nil
 }
 
+// InitTestDB connects to the test database configured by the .env.test file of the repository, like InitDB
 func InitTestDB() (*gorm.DB, *sql.DB, error) {
 	// Load the .env.test file for the test environment
-	LoadEnv("../../.env.test")
-
-	// Create DSN for the test database
-	dsn := createDSN()
-
-	// Open the database connection with custom GORM logger
-	DB, err = openDBConnection(dsn)
-	if err != nil {
-		log.WithError(err).Error("Failed to connect to test database")
-		return nil, nil, fmt.Errorf("failed to connect to test database: %w", err)
-	}
-
-	// Get the underlying sql.DB connection from the gorm.DB
-	sqlDB, err := DB.DB()
+	cfg, err := config.Load("../../.env.test")
 	if err != nil {
-		log.WithError(err).Error("Failed to get sql.DB from test gorm.DB")
-		return nil, nil, fmt.Errorf("failed to get sql.DB from gorm.DB: %w", err)
+		return nil, nil, fmt.Errorf("failed to load test configuration: %w", err)
 	}
-
-	log.Info("Successfully connected to the test database")
-	return DB, sqlDB, nil
+	return InitDB(cfg.Database)
 }
 
-// createDSN generates the Data Source Name (DSN) based on environment variables
-func createDSN() string {
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable", host, user, password, dbname, port)
-	log.WithField("dsn", dsn).Debug("Generated DSN for database connection")
-	return dsn
+// configurePool sizes the pool of connections shared by the requests and the jobs
+func configurePool(sqlDB *sql.DB, cfg config.DatabaseConfig) {
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 }
 
 // openDBConnection sets up and returns a new GORM DB instance
-func openDBConnection(dsn string) (*gorm.DB, error) {
+func openDBConnection(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	log.WithFields(log.Fields{
+		"driver": cfg.Driver,
+		"host":   cfg.Host,
+		"port":   cfg.Port,
+		"dbname": cfg.Name,
+		"path":   cfg.Path,
+	}).Debug("Connecting to the database")
+
 	// Create a new GORM logger using logrus for better logging
 	gormLogger := logger.New(
 		log.StandardLogger(), // Use the standard logrus logger
 		logger.Config{
-			SlowThreshold:             time.Second, // Log slow queries
-			LogLevel:                  logger.Warn, // Set log level to warn
-			IgnoreRecordNotFoundError: true,        // Ignore ErrRecordNotFound
-			Colorful:                  true,        // Enable colorful logs
+			SlowThreshold:             cfg.SlowQueryThreshold, // Log slow queries
+			LogLevel:                  logger.Warn,            // Set log level to warn
+			IgnoreRecordNotFoundError: true,                   // Ignore ErrRecordNotFound
+			Colorful:                  true,                   // Enable colorful logs
 		},
 	)
 
-	return gorm.Open(postgres.Open(dsn), &gorm.Config{
+	return gorm.Open(dialector(cfg), &gorm.Config{
 		PrepareStmt: true, // Prepare statements for better performance
 		QueryFields: true, // Log all query fields
 		Logger:      gormLogger,
 	})
 }
+
+// dialector selects the GORM driver of the configured backend
+func dialector(cfg config.DatabaseConfig) gorm.Dialector {
+	if cfg.Driver == config.DriverSQLite {
+		return sqlite.Open(cfg.DSN())
+	}
+	return postgres.Open(cfg.DSN())
+}
@@ -0,0 +1,113 @@
+// pkg/db_tracing.go
+package pkg
+
+import (
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// querySpanKey holds the span of a statement between its first and its last callback
+const querySpanKey = "tracing:span"
+
+// RegisterQueryTracing wraps every statement in a span, child of the span of the context of the session,
+// so the queries of a use case show up under it with their SQL, without the values, and the rows they affected.
+func RegisterQueryTracing(db *gorm.DB) error {
+	callbacks := []struct {
+		name     string
+		register func() error
+	}{
+		{"create", func() error {
+			if err := db.Callback().Create().Before("*").Register("tracing:start", startQuerySpan("create")); err != nil {
+				return err
+			}
+			return db.Callback().Create().After("*").Register("tracing:end", endQuerySpan)
+		}},
+		{"query", func() error {
+			if err := db.Callback().Query().Before("*").Register("tracing:start", startQuerySpan("query")); err != nil {
+				return err
+			}
+			return db.Callback().Query().After("*").Register("tracing:end", endQuerySpan)
+		}},
+		{"update", func() error {
+			if err := db.Callback().Update().Before("*").Register("tracing:start", startQuerySpan("update")); err != nil {
+				return err
+			}
+			return db.Callback().Update().After("*").Register("tracing:end", endQuerySpan)
+		}},
+		{"delete", func() error {
+			if err := db.Callback().Delete().Before("*").Register("tracing:start", startQuerySpan("delete")); err != nil {
+				return err
+			}
+			return db.Callback().Delete().After("*").Register("tracing:end", endQuerySpan)
+		}},
+		{"row", func() error {
+			if err := db.Callback().Row().Before("*").Register("tracing:start", startQuerySpan("row")); err != nil {
+				return err
+			}
+			return db.Callback().Row().After("*").Register("tracing:end", endQuerySpan)
+		}},
+		{"raw", func() error {
+			if err := db.Callback().Raw().Before("*").Register("tracing:start", startQuerySpan("raw")); err != nil {
+				return err
+			}
+			return db.Callback().Raw().After("*").Register("tracing:end", endQuerySpan)
+		}},
+	}
+
+	for _, callback := range callbacks {
+		if err := callback.register(); err != nil {
+			log.WithFields(log.Fields{
+				"callback": callback.name,
+				"error":    err,
+			}).Error("Failed to register query tracing")
+			return err
+		}
+	}
+	return nil
+}
+
+func startQuerySpan(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		_, span := StartSpan(db.Statement.Context, "db."+operation,
+			attribute.String("db.system", dbSystem(db.Dialector.Name())),
+			attribute.String("db.operation", operation),
+		)
+		db.InstanceSet(querySpanKey, span)
+	}
+}
+
+// dbSystem names the database of the dialect the way the OpenTelemetry conventions do
+func dbSystem(dialect string) string {
+	if dialect == "postgres" {
+		return "postgresql"
+	}
+	return dialect
+}
+
+func endQuerySpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(querySpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("db.sql.table", db.Statement.Table),
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+
+	// A lookup that finds nothing is an answer, not a failure of the query
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	EndSpan(span, err)
+}
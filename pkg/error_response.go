@@ -1,9 +1,11 @@
 // pkg/error_response.go
 package pkg
 
+// ErrorResponse is the body of every error answered by the API
 type ErrorResponse struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
-	TraceID string `json:"trace_id,omitempty"`
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Details string            `json:"details,omitempty"`
+	Errors  map[string]string `json:"errors,omitempty"` // Maps each invalid field of the request body to a message
+	TraceID string            `json:"trace_id,omitempty"`
 }
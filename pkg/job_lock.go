@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"billing_enginee/pkg/config"
+	"context"
+	"database/sql"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// jobLockClass identifies the Postgres advisory locks of the job runs. They take two keys, the class and the
+// hash of the job key, so they never collide with the single key locks of the scheduler and the migrations.
+const jobLockClass int32 = 72_044_110
+
+// JobLock lets a job run once at a time for a key, e.g. the job and its tenant, whatever started the run
+type JobLock interface {
+	// TryLock takes the lock of the key without waiting, acquired is false when another run holds it.
+	// The lock is held until unlock is called.
+	TryLock(ctx context.Context, key string) (unlock func(), acquired bool, err error)
+}
+
+// NewJobLock returns the job lock of the database. Replicas sharing a Postgres database take session advisory
+// locks, a SQLite database belongs to a single process which locks in memory.
+func NewJobLock(db *sql.DB, driver string) JobLock {
+	if driver == config.DriverSQLite {
+		return NewLocalJobLock()
+	}
+	return &advisoryJobLock{db: db}
+}
+
+// NewLocalJobLock returns a job lock only seen by this process
+func NewLocalJobLock() JobLock {
+	return &localJobLock{held: make(map[string]bool)}
+}
+
+type localJobLock struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *localJobLock) TryLock(ctx context.Context, key string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held[key] {
+		return nil, false, nil
+	}
+	l.held[key] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, key)
+	}, true, nil
+}
+
+// advisoryJobLock keeps a connection of the pool while the lock is held, the lock is released with its session
+// when the replica holding it stops. Keys whose hashes collide only run one after the other.
+type advisoryJobLock struct {
+	db *sql.DB
+}
+
+func (l *advisoryJobLock) TryLock(ctx context.Context, key string) (func(), bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1, hashtext($2))", jobLockClass, key).Scan(&acquired); err != nil || !acquired {
+		conn.Close()
+		return nil, false, err
+	}
+
+	return func() {
+		// The run may have been canceled, the lock is released anyway
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1, hashtext($2))", jobLockClass, key); err != nil {
+			log.WithFields(log.Fields{
+				"key":   key,
+				"error": err,
+			}).Warn("Failed to release the job lock, it is released when its session ends")
+		}
+		conn.Close()
+	}, true, nil
+}
@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"billing_enginee/pkg/config"
+	"context"
+	"database/sql"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// schedulerLockKey identifies the Postgres advisory lock held by the instance running the scheduled jobs,
+// apart from the lock of the migrations
+const schedulerLockKey int64 = 7_204_411_002
+
+// Leader tells whether this instance is the one of the replicas that runs the scheduled jobs
+type Leader interface {
+	IsLeader(ctx context.Context) bool
+	// Resign hands the leadership over to the next replica asking for it
+	Resign()
+}
+
+// NewLeader returns the leader election of the database. Replicas sharing a Postgres database elect the one
+// holding a session advisory lock, a SQLite database belongs to a single process which always leads.
+func NewLeader(db *sql.DB, driver string) Leader {
+	if driver == config.DriverSQLite {
+		return soleLeader{}
+	}
+	return &advisoryLockLeader{db: db, key: schedulerLockKey}
+}
+
+type soleLeader struct{}
+
+func (soleLeader) IsLeader(ctx context.Context) bool {
+	return true
+}
+
+func (soleLeader) Resign() {}
+
+// advisoryLockLeader keeps a connection of the pool for as long as it leads, the lock is released with its
+// session, so a replica that stops or loses its database leaves the leadership to the next one asking
+type advisoryLockLeader struct {
+	mu   sync.Mutex
+	db   *sql.DB
+	key  int64
+	conn *sql.Conn
+}
+
+// IsLeader checks that the session holding the lock is still alive, or tries to take the lock when it is not held
+func (l *advisoryLockLeader) IsLeader(ctx context.Context) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		err := l.conn.PingContext(ctx)
+		if err == nil {
+			return true
+		}
+		log.WithError(err).Warn("Lost the scheduler leadership with its database session")
+		l.conn.Close()
+		l.conn = nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to get a database connection for the scheduler leadership")
+		return false
+	}
+	// The lock is re-entrant within a session, it is only asked for on a connection that does not hold it
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&acquired); err != nil || !acquired {
+		if err != nil {
+			log.WithError(err).Error("Failed to take the scheduler lock")
+		}
+		conn.Close()
+		return false
+	}
+
+	l.conn = conn
+	log.Info("Became the scheduler leader, this instance runs the scheduled jobs")
+	return true
+}
+
+// Resign releases the lock, the other replicas would otherwise wait for the session to end
+func (l *advisoryLockLeader) Resign() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return
+	}
+	if _, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", l.key); err != nil {
+		log.WithError(err).Warn("Failed to release the scheduler lock, it is released when its session ends")
+	}
+	l.conn.Close()
+	l.conn = nil
+	log.Info("Resigned the scheduler leadership")
+}
@@ -0,0 +1,27 @@
+// pkg/logger.go
+package pkg
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Logger returns a logger for the work of the context. Its entries carry the request ID, the tenant and the
+// trace of the context, so every line logged while serving a request can be found by its X-Request-ID
+// and next to its spans. Outside of a request it logs like the standard logger.
+func Logger(ctx context.Context) *log.Entry {
+	fields := log.Fields{}
+	if requestID := GetRequestID(ctx); requestID != "" {
+		fields["request_id"] = requestID
+	}
+	if tenantID := GetTenantID(ctx); tenantID != "" {
+		fields["tenant_id"] = tenantID
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		fields["trace_id"] = spanContext.TraceID().String()
+		fields["span_id"] = spanContext.SpanID().String()
+	}
+	return log.WithContext(ctx).WithFields(fields)
+}
@@ -1,9 +1,22 @@
 package pkg
 
-import log "github.com/sirupsen/logrus"
+import (
+	"billing_enginee/pkg/config"
 
-func SetupLogger() {
-	// Set up logrus logging format and level
-	log.SetFormatter(&log.JSONFormatter{})
-	log.SetLevel(log.InfoLevel)
+	log "github.com/sirupsen/logrus"
+)
+
+// SetupLogger sets the logrus format and level, the configuration was validated so the level parses
+func SetupLogger(cfg config.LogConfig) {
+	if cfg.Format == config.LogFormatText {
+		log.SetFormatter(&log.TextFormatter{FullTimestamp: true})
+	} else {
+		log.SetFormatter(&log.JSONFormatter{})
+	}
+
+	level, err := log.ParseLevel(cfg.Level)
+	if err != nil {
+		level = log.InfoLevel
+	}
+	log.SetLevel(level)
 }
@@ -0,0 +1,153 @@
+// pkg/tenant_scope.go
+package pkg
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// TenantSettingKey is the gorm setting carrying the tenant ID that queries are scoped to
+	TenantSettingKey = "billing:tenant_id"
+
+	tenantField = "TenantID"
+)
+
+// ErrNoTenant is the error of a scoped session used with a context that has no tenant and did not opt in to
+// the rows of every tenant with WithAllTenants
+var ErrNoTenant = errors.New("the context has no tenant")
+
+type tenantContextKey struct{}
+
+type allTenantsContextKey struct{}
+
+// WithTenantID returns a context scoped to the tenant
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// GetTenantID returns the tenant of the context, or an empty string when there is none
+func GetTenantID(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenantID
+}
+
+// WithAllTenants returns a context that reads and updates the rows of every tenant. Only the loops that work
+// through the rows of all tenants, like the outbox relay, use it; a tenant set on the context still wins.
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsContextKey{}, true)
+}
+
+// AllTenants reports whether the context opted in to the rows of every tenant
+func AllTenants(ctx context.Context) bool {
+	allTenants, _ := ctx.Value(allTenantsContextKey{}).(bool)
+	return allTenants
+}
+
+// NewTenantContext returns a context scoped to the tenant, for work that does not come from a request
+func NewTenantContext(tenantID string) context.Context {
+	return WithTenantID(context.Background(), tenantID)
+}
+
+// RegisterTenantScope makes every query on a model with a TenantID field filter on the tenant of the
+// session setting, and every create store it. A setting without a tenant fails the statement with ErrNoTenant,
+// sessions without the setting are not scoped.
+// Raw SQL bypasses the callbacks and must filter on tenant_id itself.
+func RegisterTenantScope(db *gorm.DB) error {
+	callbacks := []struct {
+		name     string
+		register func() error
+	}{
+		{"create", func() error {
+			return db.Callback().Create().Before("gorm:create").Register("tenant:assign", assignTenant)
+		}},
+		{"query", func() error {
+			return db.Callback().Query().Before("gorm:query").Register("tenant:scope", scopeTenant)
+		}},
+		{"update", func() error {
+			return db.Callback().Update().Before("gorm:update").Register("tenant:scope", scopeTenant)
+		}},
+		{"delete", func() error {
+			return db.Callback().Delete().Before("gorm:delete").Register("tenant:scope", scopeTenant)
+		}},
+		{"row", func() error {
+			return db.Callback().Row().Before("gorm:row").Register("tenant:scope", scopeTenant)
+		}},
+	}
+
+	for _, callback := range callbacks {
+		if err := callback.register(); err != nil {
+			log.WithFields(log.Fields{
+				"callback": callback.name,
+				"error":    err,
+			}).Error("Failed to register tenant scope")
+			return err
+		}
+	}
+	return nil
+}
+
+// sessionTenant returns the tenant of the session setting and whether the session is scoped at all
+func sessionTenant(db *gorm.DB) (string, bool) {
+	value, exists := db.Get(TenantSettingKey)
+	if !exists {
+		return "", false
+	}
+	tenantID, _ := value.(string)
+	return tenantID, true
+}
+
+func scopeTenant(db *gorm.DB) {
+	tenantID, scoped := sessionTenant(db)
+	if !scoped || db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(tenantField)
+	if field == nil {
+		return
+	}
+	if tenantID == "" {
+		_ = db.AddError(ErrNoTenant)
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantID},
+	}})
+}
+
+func assignTenant(db *gorm.DB) {
+	tenantID, scoped := sessionTenant(db)
+	if !scoped || db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(tenantField)
+	if field == nil {
+		return
+	}
+	if tenantID == "" {
+		_ = db.AddError(ErrNoTenant)
+		return
+	}
+
+	ctx := db.Statement.Context
+	value := db.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := field.Set(ctx, reflect.Indirect(value.Index(i)), tenantID); err != nil {
+				_ = db.AddError(err)
+				return
+			}
+		}
+	case reflect.Struct:
+		if err := field.Set(ctx, value, tenantID); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+}
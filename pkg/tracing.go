@@ -0,0 +1,119 @@
+// pkg/tracing.go
+package pkg
+
+import (
+	"billing_enginee/pkg/config"
+	"context"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName names the service in the traces it exports
+const ServiceName = "billing_enginee"
+
+// tracer creates the spans of the service from the current global provider. Until SetupTracing runs that is
+// the no-op provider of otel, so code and specs that never set tracing up pay nothing for their spans.
+func tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
+
+// SetupTracing installs the global tracer provider and the W3C trace context propagator. Spans are exported with
+// the exporter of the configuration: "otlp" to the collector of the standard OTEL_EXPORTER_OTLP_* variables,
+// "stdout", "file" appending to the trace file, or "none". The returned function flushes pending spans.
+func SetupTracing(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporterName := cfg.Exporter
+	if exporterName == config.TraceExporterNone {
+		log.Info("Tracing disabled")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closeOutput, err := newTraceExporter(ctx, exporterName, cfg.File)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	log.WithField("exporter", exporterName).Info("Tracing enabled")
+
+	return func(ctx context.Context) error {
+		if err := provider.Shutdown(ctx); err != nil {
+			return err
+		}
+		return closeOutput()
+	}, nil
+}
+
+// newTraceExporter creates the exporter with the name, and the function closing the output it writes to
+func newTraceExporter(ctx context.Context, name string, path string) (sdktrace.SpanExporter, func() error, error) {
+	noClose := func() error { return nil }
+
+	switch name {
+	case config.TraceExporterOTLP:
+		exporter, err := otlptracegrpc.New(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+		return exporter, noClose, nil
+	case config.TraceExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create stdout trace exporter: %w", err)
+		}
+		return exporter, noClose, nil
+	case config.TraceExporterFile:
+		if path == "" {
+			return nil, nil, fmt.Errorf("a trace file is required by the file trace exporter")
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("failed to create file trace exporter: %w", err)
+		}
+		return exporter, file.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown trace exporter %q", name)
+	}
+}
+
+// StartSpan starts a span of the service as a child of the span of ctx, if any
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartServerSpan starts the span of a request or call received by the service
+func StartServerSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err on the span, when there is one, and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,58 @@
+// pkg/unit_of_work.go
+package pkg
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+type txContextKey struct{}
+
+// UnitOfWork runs work in a database transaction that follows the context.
+// Repositories called with the context passed to the work join the transaction.
+type UnitOfWork interface {
+	// Do commits when fn returns nil and rolls back otherwise. When the context already carries
+	// a transaction, fn runs in a savepoint of it: a failure of fn undoes only its own writes,
+	// while the outer transaction can still roll back everything.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWork{
+		db: db,
+	}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	db := u.db
+	tx, nested := TxFromContext(ctx)
+	if nested {
+		db = tx
+	}
+
+	// The span covers the begin and the commit or rollback, which the query spans do not
+	ctx, span := StartSpan(ctx, "UnitOfWork.Do", attribute.Bool("db.savepoint", nested))
+	defer func() { EndSpan(span, err) }()
+
+	// Transaction uses a savepoint when db already is a transaction
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(WithTx(ctx, tx))
+	})
+}
+
+// WithTx returns a context carrying the transaction
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext returns the transaction of the context, if any
+func TxFromContext(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(*gorm.DB)
+	return tx, ok
+}
@@ -0,0 +1,36 @@
+// pkg/version.go
+package pkg
+
+import "runtime/debug"
+
+// GitSHA and BuildTime identify the build. They are set at build time with
+// -ldflags "-X billing_enginee/pkg.GitSHA=<sha> -X billing_enginee/pkg.BuildTime=<RFC 3339 time>",
+// otherwise they are read from the VCS information Go embeds in the binary, when there is any,
+// with the time of the commit standing in for the build time.
+var (
+	GitSHA    = ""
+	BuildTime = ""
+)
+
+// BuildInfo returns the git SHA and the build time of the binary, "unknown" when they are not known
+func BuildInfo() (gitSHA string, buildTime string) {
+	gitSHA, buildTime = GitSHA, BuildTime
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && gitSHA == "":
+				gitSHA = setting.Value
+			case setting.Key == "vcs.time" && buildTime == "":
+				buildTime = setting.Value
+			}
+		}
+	}
+
+	if gitSHA == "" {
+		gitSHA = "unknown"
+	}
+	if buildTime == "" {
+		buildTime = "unknown"
+	}
+	return gitSHA, buildTime
+}
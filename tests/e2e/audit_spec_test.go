@@ -0,0 +1,156 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/model"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Audit Log", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var paymentUsecase usecase.PaymentUsecase
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+		paymentUsecase = env.PaymentUsecase
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	createLoan := func(requestID string) string {
+		payload := map[string]interface{}{
+			"customer_id": 1,
+			"name":        "John Doe",
+			"email":       "johndoe@example.com",
+			"amount":      5000000,
+			"term_weeks":  50,
+			"rates":       10,
+		}
+		payloadJSON, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/api/v1/loans", bytes.NewBuffer(payloadJSON))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Request-ID", requestID)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		var loanResponse map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &loanResponse)
+		Expect(err).ToNot(HaveOccurred())
+		return loanResponse["loan_id"].(string)
+	}
+
+	getAudit := func(entityType string, entityID string) []map[string]interface{} {
+		req, _ := http.NewRequest("GET", "/api/v1/audit?entity="+entityType+"&id="+entityID, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		var response map[string][]map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &response)
+		Expect(err).ToNot(HaveOccurred())
+		return response["entries"]
+	}
+
+	firstPaymentID := func(loanID string) string {
+		var payment model.Payment
+		err := db.Where("loan_id = ? AND week = ?", loanID, 1).First(&payment).Error
+		Expect(err).ToNot(HaveOccurred())
+		return strconv.FormatUint(uint64(payment.ID), 10)
+	}
+
+	ginkgo.It("should record the creation of a loan with the actor and request ID", func() {
+		loanID := createLoan("req-create-loan")
+
+		entries := getAudit("loan", loanID)
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0]["action"]).To(Equal("create"))
+		Expect(entries[0]["actor"]).To(Equal("api_key:e2e-tests"))
+		Expect(entries[0]["request_id"]).To(Equal("req-create-loan"))
+		Expect(entries[0]["reason"]).To(Equal("loan created"))
+		Expect(entries[0]["before"]).To(BeNil())
+		after := entries[0]["after"].(map[string]interface{})
+		Expect(after["status"]).To(Equal("open"))
+		Expect(after["total_amount"]).To(BeEquivalentTo(5500000.0))
+	})
+
+	ginkgo.It("should record status changes of payments with before and after values", func() {
+		loanID := createLoan("req-create-loan")
+		paymentID := firstPaymentID(loanID)
+
+		req, _ := http.NewRequest("POST", "/api/v1/loans/"+loanID+"/payment?amount=110000", nil)
+		req.Header.Set("X-Request-ID", "req-pay")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		entries := getAudit("payment", paymentID)
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0]["action"]).To(Equal("create"))
+		Expect(entries[1]["action"]).To(Equal("update"))
+		Expect(entries[1]["request_id"]).To(Equal("req-pay"))
+		Expect(entries[1]["reason"]).To(Equal("payment received"))
+		Expect(entries[1]["before"]).To(HaveKeyWithValue("status", "outstanding"))
+		Expect(entries[1]["after"]).To(HaveKeyWithValue("status", "paid"))
+		Expect(entries[1]["after"]).To(HaveKey("paid_at"))
+	})
+
+	ginkgo.It("should record changes made by scheduled jobs as the system actor", func() {
+		loanID := createLoan("req-create-loan")
+		paymentID := firstPaymentID(loanID)
+
+		// The first installment becomes overdue
+		err := paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), time.Now().AddDate(0, 0, 8))
+		Expect(err).ToNot(HaveOccurred())
+
+		entries := getAudit("payment", paymentID)
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[1]["actor"]).To(Equal("system"))
+		Expect(entries[1]["request_id"]).To(BeEmpty())
+		Expect(entries[1]["reason"]).To(Equal("installment overdue"))
+		Expect(entries[1]["after"]).To(HaveKeyWithValue("status", "pending"))
+
+		// Running the job again without a status change does not add entries
+		err = paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), time.Now().AddDate(0, 0, 8))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getAudit("payment", paymentID)).To(HaveLen(2))
+	})
+
+	ginkgo.It("should reject unknown entity types", func() {
+		req, _ := http.NewRequest("GET", "/api/v1/audit?entity=invoice&id=1", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+
+		var response map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response["code"]).To(Equal("INVALID_INPUT"))
+	})
+})
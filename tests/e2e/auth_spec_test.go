@@ -0,0 +1,153 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/auth"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+const (
+	agentAPIKey  = "agent-secret-key"
+	viewerAPIKey = "viewer-secret-key"
+	jwtSecret    = "staff-jwt-signing-secret"
+)
+
+var _ = ginkgo.Describe("Authentication and Authorization", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment with real credentials
+		authenticator := auth.NewAuthenticator([]auth.APIKey{
+			{Name: "collector-app", Role: auth.RoleAgent, Hash: auth.HashAPIKey(agentAPIKey)},
+			{Name: "dashboard", Role: auth.RoleViewer, Hash: auth.HashAPIKey(viewerAPIKey)},
+		}, jwtSecret, "")
+		env := helpers.InitializeTestEnvironmentWithAuthenticator(authenticator)
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	staffToken := func(secret string, role string, expiresAt time.Time) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.StaffClaims{
+			Role: role,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "staff-7",
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+			},
+		})
+		signed, err := token.SignedString([]byte(secret))
+		Expect(err).ToNot(HaveOccurred())
+		return signed
+	}
+
+	createLoanRequest := func() *http.Request {
+		payload := map[string]interface{}{
+			"customer_id": 1,
+			"name":        "John Doe",
+			"email":       "johndoe@example.com",
+			"amount":      5000000,
+			"term_weeks":  50,
+			"rates":       10,
+		}
+		payloadJSON, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/api/v1/loans", bytes.NewBuffer(payloadJSON))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	serve := func(req *http.Request) (int, map[string]interface{}) {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &response)
+		Expect(err).ToNot(HaveOccurred())
+		return resp.Code, response
+	}
+
+	ginkgo.It("should answer 401 when no credentials are provided", func() {
+		code, response := serve(createLoanRequest())
+		Expect(code).To(Equal(http.StatusUnauthorized))
+		Expect(response["code"]).To(Equal("UNAUTHORIZED"))
+		Expect(response["trace_id"]).ToNot(BeEmpty())
+
+		var count int64
+		err := db.Table("loans").Count(&count).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(BeEquivalentTo(0))
+	})
+
+	ginkgo.It("should answer 401 for an unknown API key", func() {
+		req := createLoanRequest()
+		req.Header.Set(auth.APIKeyHeader, "not-a-key")
+		code, response := serve(req)
+		Expect(code).To(Equal(http.StatusUnauthorized))
+		Expect(response["code"]).To(Equal("UNAUTHORIZED"))
+	})
+
+	ginkgo.It("should answer 403 when the role lacks the permission", func() {
+		req := createLoanRequest()
+		req.Header.Set(auth.APIKeyHeader, viewerAPIKey)
+		code, response := serve(req)
+		Expect(code).To(Equal(http.StatusForbidden))
+		Expect(response["code"]).To(Equal("FORBIDDEN"))
+	})
+
+	ginkgo.It("should let an agent API key create loans and record it as the actor", func() {
+		req := createLoanRequest()
+		req.Header.Set(auth.APIKeyHeader, agentAPIKey)
+		code, response := serve(req)
+		Expect(code).To(Equal(http.StatusOK))
+
+		var actor string
+		err := db.Table("audit_logs").Where("entity_type = ? AND entity_id = ?", "loan", response["loan_id"]).Select("actor").Scan(&actor).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actor).To(Equal("api_key:collector-app"))
+	})
+
+	ginkgo.It("should authorize staff by the role of their JWT", func() {
+		req, _ := http.NewRequest("GET", "/api/v1/reports/portfolio", nil)
+		req.Header.Set("Authorization", "Bearer "+staffToken(jwtSecret, "finance", time.Now().Add(time.Hour)))
+		code, _ := serve(req)
+		Expect(code).To(Equal(http.StatusOK))
+
+		req, _ = http.NewRequest("GET", "/api/v1/webhooks", nil)
+		req.Header.Set("Authorization", "Bearer "+staffToken(jwtSecret, "finance", time.Now().Add(time.Hour)))
+		code, response := serve(req)
+		Expect(code).To(Equal(http.StatusForbidden))
+		Expect(response["code"]).To(Equal("FORBIDDEN"))
+	})
+
+	ginkgo.It("should answer 401 for expired or forged JWTs", func() {
+		req, _ := http.NewRequest("GET", "/api/v1/reports/portfolio", nil)
+		req.Header.Set("Authorization", "Bearer "+staffToken(jwtSecret, "admin", time.Now().Add(-time.Minute)))
+		code, _ := serve(req)
+		Expect(code).To(Equal(http.StatusUnauthorized))
+
+		req, _ = http.NewRequest("GET", "/api/v1/reports/portfolio", nil)
+		req.Header.Set("Authorization", "Bearer "+staffToken("some-other-secret", "admin", time.Now().Add(time.Hour)))
+		code, _ = serve(req)
+		Expect(code).To(Equal(http.StatusUnauthorized))
+	})
+})
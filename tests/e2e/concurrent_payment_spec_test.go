@@ -0,0 +1,117 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/model"
+	"billing_enginee/migrations"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Concurrent Payments", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var loanID string
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+
+		payload := map[string]interface{}{
+			"customer_id": 1,
+			"name":        "John Doe",
+			"email":       "johndoe@example.com",
+			"amount":      5000000,
+			"term_weeks":  50,
+			"rates":       10,
+		}
+		payloadJSON, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/api/v1/loans", bytes.NewBuffer(payloadJSON))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		var loanResponse map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &loanResponse)
+		Expect(err).ToNot(HaveOccurred())
+		loanID = loanResponse["loan_id"].(string)
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	pay := func() int {
+		req, _ := http.NewRequest("POST", "/api/v1/loans/"+loanID+"/payment?amount=110000", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	ginkgo.It("should reject a payment while another transaction holds the loan", func() {
+		if db.Dialector.Name() == migrations.DialectSQLite {
+			ginkgo.Skip("SQLite has no row locks, its writers wait for the lock of the whole database")
+		}
+		tx := db.Begin()
+		err := tx.Exec("SELECT id FROM loans WHERE id = ? FOR UPDATE", loanID).Error
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(pay()).To(Equal(http.StatusConflict))
+
+		tx.Rollback()
+		Expect(pay()).To(Equal(http.StatusOK))
+	})
+
+	ginkgo.It("should record every successful payment exactly once when payments race", func() {
+		const requests = 10
+		statuses := make(chan int, requests)
+
+		var wg sync.WaitGroup
+		for i := 0; i < requests; i++ {
+			wg.Add(1)
+			go func() {
+				defer ginkgo.GinkgoRecover()
+				defer wg.Done()
+				statuses <- pay()
+			}()
+		}
+		wg.Wait()
+		close(statuses)
+
+		succeeded := 0
+		for status := range statuses {
+			Expect(status).To(BeElementOf(http.StatusOK, http.StatusConflict))
+			if status == http.StatusOK {
+				succeeded++
+			}
+		}
+		Expect(succeeded).To(BeNumerically(">=", 1))
+
+		// Each successful payment settled its own installment, none was counted twice
+		var paid int64
+		db.Model(&model.Payment{}).Where("loan_id = ? AND status = ?", loanID, "paid").Count(&paid)
+		Expect(paid).To(BeEquivalentTo(succeeded))
+
+		var events int64
+		db.Model(&model.OutboxEvent{}).Where("event_type = ?", "payment.received").Count(&events)
+		Expect(events).To(BeEquivalentTo(succeeded))
+	})
+})
@@ -0,0 +1,93 @@
+package e2e_test
+
+import (
+	"billing_enginee/pkg/config"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Config", func() {
+	// writeConfigFile points CONFIG_FILE at a YAML file with the content for the duration of the spec
+	writeConfigFile := func(content string) {
+		path := filepath.Join(ginkgo.GinkgoT().TempDir(), "config.yaml")
+		Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+		ginkgo.GinkgoT().Setenv("CONFIG_FILE", path)
+	}
+
+	// The suite loads .env.test, an empty variable counts as not set so the file decides
+	unsetEnv := func(keys ...string) {
+		for _, key := range keys {
+			ginkgo.GinkgoT().Setenv(key, "")
+		}
+	}
+
+	ginkgo.It("should read the file and let the environment override it", func() {
+		unsetEnv("PORT", "READ_HEADER_TIMEOUT", "LOG_FORMAT", "PAYMENT_STATUS_CRON", "SCHEDULER_DEFAULT_TIMEZONE")
+		writeConfigFile(`
+http:
+  port: 8181
+  read_header_timeout: 3s
+log:
+  level: debug
+  format: text
+scheduler:
+  default_timezone: Asia/Makassar
+  payment_status_cron: "30 1 * * *"
+`)
+		ginkgo.GinkgoT().Setenv("LOG_LEVEL", "warn")
+		ginkgo.GinkgoT().Setenv("DB_MAX_OPEN_CONNS", "7")
+		ginkgo.GinkgoT().Setenv("SHUTDOWN_TIMEOUT", "12")
+
+		cfg, err := config.Load()
+		Expect(err).ToNot(HaveOccurred())
+
+		// From the file
+		Expect(cfg.HTTP.Port).To(Equal(8181))
+		Expect(cfg.HTTP.ReadHeaderTimeout).To(Equal(3 * time.Second))
+		Expect(cfg.Log.Format).To(Equal(config.LogFormatText))
+		Expect(cfg.Scheduler.DefaultTimezone).To(Equal("Asia/Makassar"))
+		Expect(cfg.Scheduler.PaymentStatusCron).To(Equal("30 1 * * *"))
+		// From the environment, over the file and the defaults
+		Expect(cfg.Log.Level).To(Equal("warn"))
+		Expect(cfg.Database.MaxOpenConns).To(Equal(7))
+		Expect(cfg.HTTP.ShutdownTimeout).To(Equal(12 * time.Second))
+		// From the defaults
+		Expect(cfg.HTTP.IdleTimeout).To(Equal(2 * time.Minute))
+	})
+
+	ginkgo.It("should report every invalid setting at once", func() {
+		unsetEnv("LOG_FORMAT", "PAYMENT_STATUS_CRON", "NOTIFICATION_CHANNELS")
+		writeConfigFile(`
+log:
+  format: xml
+scheduler:
+  payment_status_cron: "every day"
+notification:
+  channels: [pigeon]
+`)
+
+		_, err := config.Load()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`log format "xml"`))
+		Expect(err.Error()).To(ContainSubstring(`payment status cron "every day"`))
+		Expect(err.Error()).To(ContainSubstring(`notification channel "pigeon"`))
+	})
+
+	ginkgo.It("should reject variables and keys it cannot read", func() {
+		ginkgo.GinkgoT().Setenv("DB_MAX_IDLE_CONNS", "many")
+		_, err := config.Load()
+		Expect(err).To(MatchError(ContainSubstring(`DB_MAX_IDLE_CONNS must be an integer`)))
+
+		ginkgo.GinkgoT().Setenv("DB_MAX_IDLE_CONNS", "")
+		writeConfigFile(`
+http:
+  read_header_timeuot: 3s
+`)
+		_, err = config.Load()
+		Expect(err).To(MatchError(ContainSubstring("read_header_timeuot")))
+	})
+})
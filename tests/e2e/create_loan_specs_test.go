@@ -33,7 +33,7 @@ var _ = ginkgo.Describe("Create Loan Endpoint", func() {
 	ginkgo.AfterEach(func() {
 		// Clean up the database by truncating tables
 		// Use the helper function to truncate tables
-		err := helpers.TruncateTables(db, "loans", "customers", "payments")
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
 		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
 		sqlDB.Close()
 	})
@@ -70,7 +70,7 @@ var _ = ginkgo.Describe("Create Loan Endpoint", func() {
 
 		// Verify that the customer was created in the database
 		var customer model.Customer
-		err = db.Where("id = ?", response["loan_id"]).First(&customer).Error
+		err = db.Where("external_id = ?", 1).First(&customer).Error
 		Expect(err).ToNot(HaveOccurred())
 		Expect(customer.Name).To(Equal("John Doe"))
 		Expect(customer.Email).To(Equal("johndoe@example.com"))
@@ -129,12 +129,13 @@ var _ = ginkgo.Describe("Create Loan Endpoint", func() {
 		// Check if the errors field contains the expected validation error messages
 		errors := response["errors"].(map[string]interface{})
 
-		Expect(errors["customer_id"]).To(ContainSubstring("customer ID is required"))
-		Expect(errors["name"]).To(ContainSubstring("name is required"))
-		Expect(errors["email"]).To(ContainSubstring("email is required"))
-		Expect(errors["amount"]).To(ContainSubstring("amount is required"))
-		Expect(errors["term_weeks"]).To(ContainSubstring("term weeks is required"))
-		Expect(errors["rates"]).To(ContainSubstring("rates is required"))
+		// The request is rejected against the OpenAPI document before it reaches the handler
+		Expect(errors["customer_id"]).To(Equal("customer_id is required"))
+		Expect(errors["name"]).To(Equal("name is required"))
+		Expect(errors["email"]).To(Equal("email is required"))
+		Expect(errors["amount"]).To(Equal("amount is required"))
+		Expect(errors["term_weeks"]).To(Equal("term_weeks is required"))
+		Expect(errors["rates"]).To(Equal("rates is required"))
 	})
 
 })
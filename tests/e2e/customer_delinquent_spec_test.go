@@ -2,7 +2,9 @@ package e2e_test
 
 import (
 	"billing_enginee/internal/model"
+	"billing_enginee/internal/tenant"
 	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
 	"billing_enginee/tests/helpers"
 	"bytes"
 	"database/sql"
@@ -37,7 +39,7 @@ var _ = ginkgo.Describe("Is Delinquent Endpoint", func() {
 	ginkgo.AfterEach(func() {
 		// Clean up the database by truncating tables
 		// Use the helper function to truncate tables
-		err := helpers.TruncateTables(db, "loans", "customers", "payments")
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
 		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
 		sqlDB.Close()
 	})
@@ -123,7 +125,7 @@ var _ = ginkgo.Describe("Is Delinquent Endpoint", func() {
 
 		// Step 2: Run the scheduler once
 		currentDate := time.Now().AddDate(0, 0, 8) // Simulate 8 days later
-		err = paymentUsecase.UpdatePaymentStatus(db, currentDate)
+		err = paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), currentDate)
 		Expect(err).ToNot(HaveOccurred())
 
 		// Step 3: Call the IsDelinquent endpoint
@@ -171,11 +173,11 @@ var _ = ginkgo.Describe("Is Delinquent Endpoint", func() {
 
 		// Step 2: Run the scheduler twice
 		currentDate := time.Now().AddDate(0, 0, 8) // Simulate 8 days later
-		err = paymentUsecase.UpdatePaymentStatus(db, currentDate)
+		err = paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), currentDate)
 		Expect(err).ToNot(HaveOccurred())
 
 		currentDate = time.Now().AddDate(0, 0, 15) // Simulate 15 days later
-		err = paymentUsecase.UpdatePaymentStatus(db, currentDate)
+		err = paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), currentDate)
 		Expect(err).ToNot(HaveOccurred())
 
 		// Step 3: Call the IsDelinquent endpoint
@@ -224,7 +226,7 @@ var _ = ginkgo.Describe("Is Delinquent Endpoint", func() {
 		// Step 2: Run the scheduler multiple times (simulate many overdue payments)
 		for i := 1; i <= 3; i++ {
 			currentDate := time.Now().AddDate(0, 0, 7*i) // Simulate multiple weeks later
-			err := paymentUsecase.UpdatePaymentStatus(db, currentDate)
+			err := paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), currentDate)
 			Expect(err).ToNot(HaveOccurred())
 		}
 
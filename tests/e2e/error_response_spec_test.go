@@ -0,0 +1,106 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/model"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Error Responses", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	// request sends the request with a known request ID and decodes the error response
+	request := func(method string, path string, payload interface{}) (int, map[string]interface{}) {
+		body := bytes.NewBuffer(nil)
+		if payload != nil {
+			payloadJSON, _ := json.Marshal(payload)
+			body = bytes.NewBuffer(payloadJSON)
+		}
+		req, _ := http.NewRequest(method, path, body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Request-ID", "req-error")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &response)
+		Expect(err).ToNot(HaveOccurred())
+		return resp.Code, response
+	}
+
+	createLoan := func() string {
+		code, response := request("POST", "/api/v1/loans", map[string]interface{}{
+			"customer_id": 1,
+			"name":        "John Doe",
+			"email":       "johndoe@example.com",
+			"amount":      5000000,
+			"term_weeks":  50,
+			"rates":       10,
+		})
+		Expect(code).To(Equal(http.StatusOK))
+		return response["loan_id"].(string)
+	}
+
+	ginkgo.It("should answer 404 for a loan that does not exist", func() {
+		code, response := request("GET", "/api/v1/loans/999999/outstanding", nil)
+		Expect(code).To(Equal(http.StatusNotFound))
+		Expect(response["code"]).To(Equal("NOT_FOUND"))
+		Expect(response["message"]).To(Equal("loan not found"))
+		Expect(response["trace_id"]).To(Equal("req-error"))
+
+		code, _ = request("POST", "/api/v1/loans/999999/payment?amount=110000", nil)
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	ginkgo.It("should answer 422 for a payment that does not match the outstanding amount", func() {
+		loanID := createLoan()
+
+		code, response := request("POST", "/api/v1/loans/"+loanID+"/payment?amount=1", nil)
+		Expect(code).To(Equal(http.StatusUnprocessableEntity))
+		Expect(response["code"]).To(Equal("BUSINESS_RULE_VIOLATION"))
+		Expect(response["message"]).To(Equal("payment amount does not match outstanding balance"))
+
+		var paid int64
+		db.Model(&model.Payment{}).Where("loan_id = ? AND status = ?", loanID, "paid").Count(&paid)
+		Expect(paid).To(BeZero())
+	})
+
+	ginkgo.It("should answer 400 with the invalid fields of a request body", func() {
+		code, response := request("POST", "/api/v1/loans", map[string]interface{}{"name": "John 2"})
+		Expect(code).To(Equal(http.StatusBadRequest))
+		Expect(response["code"]).To(Equal("INVALID_INPUT"))
+		Expect(response["errors"]).To(HaveKey("email"))
+
+		code, response = request("GET", "/api/v1/loans/abc/outstanding", nil)
+		Expect(code).To(Equal(http.StatusBadRequest))
+		Expect(response["code"]).To(Equal("INVALID_INPUT"))
+		Expect(response["trace_id"]).To(Equal("req-error"))
+	})
+})
@@ -2,7 +2,9 @@ package e2e_test
 
 import (
 	"billing_enginee/internal/model"
+	"billing_enginee/internal/tenant"
 	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
 	"billing_enginee/tests/helpers"
 	"bytes"
 	"database/sql"
@@ -37,7 +39,7 @@ var _ = ginkgo.Describe("Get Outstanding Endpoint", func() {
 	ginkgo.AfterEach(func() {
 		// Clean up the database by truncating tables
 		// Use the helper function to truncate tables
-		err := helpers.TruncateTables(db, "loans", "customers", "payments")
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
 		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
 		sqlDB.Close()
 	})
@@ -126,7 +128,7 @@ var _ = ginkgo.Describe("Get Outstanding Endpoint", func() {
 
 		// Step 2: Run the scheduler once
 		currentDate := time.Now().AddDate(0, 0, 8) // Move one week ahead
-		err = paymentUsecase.UpdatePaymentStatus(db, currentDate)
+		err = paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), currentDate)
 		Expect(err).ToNot(HaveOccurred())
 
 		// Verify that the first payment is pending, second is outstanding, ordered by week asc
@@ -188,10 +190,10 @@ var _ = ginkgo.Describe("Get Outstanding Endpoint", func() {
 
 		// Step 2: Run the scheduler twice
 		currentDate := time.Now().AddDate(0, 0, 8) // Move one week ahead
-		err = paymentUsecase.UpdatePaymentStatus(db, currentDate)
+		err = paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), currentDate)
 		Expect(err).ToNot(HaveOccurred())
 		currentDate = time.Now().AddDate(0, 0, 15) // Move another week ahead
-		err = paymentUsecase.UpdatePaymentStatus(db, currentDate)
+		err = paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), currentDate)
 		Expect(err).ToNot(HaveOccurred())
 
 		// Verify that the first two payments are pending, third is outstanding, ordered by week asc
@@ -0,0 +1,155 @@
+package e2e_test
+
+import (
+	"billing_enginee/api/grpcserver"
+	billingv1 "billing_enginee/api/proto/billing/v1"
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/model"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/tests/helpers"
+	"context"
+	"database/sql"
+	"net"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/gorm"
+)
+
+const (
+	grpcAgentKey  = "agent-secret-key"
+	grpcViewerKey = "viewer-secret-key"
+)
+
+var _ = ginkgo.Describe("gRPC API", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var server *grpc.Server
+	var conn *grpc.ClientConn
+	var loanClient billingv1.LoanServiceClient
+	var customerClient billingv1.CustomerServiceClient
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		authenticator := auth.NewAuthenticator([]auth.APIKey{
+			{Name: "agent", Role: auth.RoleAgent, Hash: auth.HashAPIKey(grpcAgentKey), TenantID: tenant.DefaultTenantID},
+			{Name: "viewer", Role: auth.RoleViewer, Hash: auth.HashAPIKey(grpcViewerKey), TenantID: tenant.DefaultTenantID},
+		}, "", "")
+
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironmentWithAuthenticator(authenticator)
+		db = env.DB
+		sqlDB = env.SQLDB
+
+		// Serve over an in-memory listener
+		listener := bufconn.Listen(1024 * 1024)
+		server = grpcserver.NewServer(env.UnitOfWork, authenticator, tenant.DefaultRegistry(), env.LoanUsecase, env.CustomerUsecase)
+		go func() {
+			_ = server.Serve(listener)
+		}()
+
+		var err error
+		conn, err = grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		loanClient = billingv1.NewLoanServiceClient(conn)
+		customerClient = billingv1.NewCustomerServiceClient(conn)
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		conn.Close()
+		server.Stop()
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), grpcserver.APIKeyMetadata, key)
+	}
+
+	createLoanRequest := &billingv1.CreateLoanRequest{
+		CustomerId: 1,
+		Name:       "John Doe",
+		Email:      "johndoe@example.com",
+		Amount:     5000000,
+		TermWeeks:  50,
+		Rates:      10,
+	}
+
+	ginkgo.It("should create a loan, pay an installment and check delinquency", func() {
+		ctx := withKey(grpcAgentKey)
+
+		loan, err := loanClient.CreateLoan(ctx, createLoanRequest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loan.GetLoanId()).ToNot(BeZero())
+		Expect(loan.GetTotalAmount()).To(BeEquivalentTo(5500000))
+		Expect(loan.GetWeek()).To(BeEquivalentTo(1))
+
+		outstanding, err := loanClient.GetOutstanding(ctx, &billingv1.GetOutstandingRequest{LoanId: loan.GetLoanId()})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(outstanding.GetLoanId()).To(Equal(loan.GetLoanId()))
+		Expect(outstanding.GetOutstandingAmount()).To(BeEquivalentTo(110000))
+
+		payment, err := loanClient.MakePayment(ctx, &billingv1.MakePaymentRequest{LoanId: loan.GetLoanId(), Amount: 110000})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payment.GetMessage()).To(Equal("Payment successful"))
+
+		delinquency, err := customerClient.IsDelinquent(ctx, &billingv1.IsDelinquentRequest{CustomerId: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(delinquency.GetIsDelinquent()).To(BeFalse())
+
+		// Writes are audited with the caller of the call
+		var entry model.AuditLog
+		err = db.Where("entity_type = ? AND entity_id = ?", "loan", loan.GetLoanId()).First(&entry).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entry.Actor).To(Equal("api_key:agent"))
+	})
+
+	ginkgo.It("should reject calls without credentials or without the permission", func() {
+		_, err := customerClient.IsDelinquent(context.Background(), &billingv1.IsDelinquentRequest{CustomerId: 1})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+		_, err = customerClient.IsDelinquent(withKey("unknown-key"), &billingv1.IsDelinquentRequest{CustomerId: 1})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+		_, err = loanClient.CreateLoan(withKey(grpcViewerKey), createLoanRequest)
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+
+		var count int64
+		db.Model(&model.Loan{}).Count(&count)
+		Expect(count).To(BeZero())
+	})
+
+	ginkgo.It("should reject invalid requests and roll back failed calls", func() {
+		ctx := withKey(grpcAgentKey)
+
+		_, err := loanClient.CreateLoan(ctx, &billingv1.CreateLoanRequest{CustomerId: 1, Name: "John 2", Email: "not-an-email"})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		Expect(status.Convert(err).Message()).To(ContainSubstring("email"))
+
+		_, err = loanClient.GetOutstanding(ctx, &billingv1.GetOutstandingRequest{LoanId: 999})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+
+		// A payment with the wrong amount fails and leaves the installments untouched
+		loan, err := loanClient.CreateLoan(ctx, createLoanRequest)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = loanClient.MakePayment(ctx, &billingv1.MakePaymentRequest{LoanId: loan.GetLoanId(), Amount: 1})
+		Expect(err).To(HaveOccurred())
+
+		var paid int64
+		db.Model(&model.Payment{}).Where("loan_id = ? AND status = ?", loan.GetLoanId(), "paid").Count(&paid)
+		Expect(paid).To(BeZero())
+	})
+})
@@ -0,0 +1,143 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/health"
+	"billing_enginee/internal/runner"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/migrations"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+// blockingCatchUpUsecase holds the catch-up until it is released, then fails it
+type blockingCatchUpUsecase struct {
+	usecase.JobRunUsecase
+	release chan struct{}
+}
+
+func (u *blockingCatchUpUsecase) CatchUpUpdatePaymentStatus(ctx context.Context, maxDays int) ([]*entity.JobRun, error) {
+	<-u.release
+	return nil, errors.New("error fetching payments: connection refused")
+}
+
+var _ = ginkgo.Describe("Health", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var checker *health.Checker
+	var insertedVersions []string
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+		checker = env.Health
+		insertedVersions = nil
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Only remove the versions the specs recorded, the environment applied the real migrations
+		for _, version := range insertedVersions {
+			Expect(db.Exec("DELETE FROM schema_migration WHERE version = ?", version).Error).ToNot(HaveOccurred())
+		}
+		sqlDB.Close()
+	})
+
+	recordVersion := func(version string) {
+		var count int64
+		Expect(db.Raw("SELECT COUNT(*) FROM schema_migration WHERE version = ?", version).Scan(&count).Error).ToNot(HaveOccurred())
+		if count > 0 {
+			return
+		}
+		Expect(db.Exec("INSERT INTO schema_migration (version) VALUES (?)", version).Error).ToNot(HaveOccurred())
+		insertedVersions = append(insertedVersions, version)
+	}
+
+	get := func(path string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var body map[string]interface{}
+		Expect(json.Unmarshal(resp.Body.Bytes(), &body)).To(Succeed())
+		return resp.Code, body
+	}
+
+	ginkgo.It("should report liveness, readiness and the build without credentials", func() {
+		status, body := get("/healthz")
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(body["status"]).To(Equal("ok"))
+
+		status, body = get("/readyz")
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(body["status"]).To(Equal("ok"))
+		Expect(body["checks"]).To(HaveKeyWithValue("database", "ok"))
+		Expect(body["checks"]).To(HaveKeyWithValue("schema", "ok"))
+
+		status, body = get("/version")
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(body["git_sha"]).ToNot(BeEmpty())
+		Expect(body["build_time"]).ToNot(BeEmpty())
+		Expect(body["schema_version"]).To(Equal(migrations.LatestVersion()))
+		Expect(body["expected_schema_version"]).To(Equal(migrations.LatestVersion()))
+	})
+
+	ginkgo.It("should not be ready when the schema is not at the version of the migrations", func() {
+		recordVersion("99991231235959")
+
+		status, body := get("/readyz")
+		Expect(status).To(Equal(http.StatusServiceUnavailable))
+		Expect(body["status"]).To(Equal("unavailable"))
+		Expect(body["checks"]).To(HaveKeyWithValue("database", "ok"))
+		Expect(body["checks"]).To(HaveKeyWithValue("schema", ContainSubstring("99991231235959")))
+
+		// Liveness does not depend on the dependencies
+		status, _ = get("/healthz")
+		Expect(status).To(Equal(http.StatusOK))
+	})
+
+	ginkgo.It("should stop being ready once the shutdown started", func() {
+		checker.SetShuttingDown()
+
+		status, body := get("/readyz")
+		Expect(status).To(Equal(http.StatusServiceUnavailable))
+		Expect(body["checks"]).To(HaveKeyWithValue("shutdown", "service is shutting down"))
+	})
+
+	ginkgo.It("should stay ready while the scheduler catches up and report a catch-up that failed", func() {
+		leader := pkg.NewLeader(sqlDB, db.Dialector.Name())
+		defer leader.Resign()
+		jobRunUsecase := &blockingCatchUpUsecase{release: make(chan struct{})}
+		catchUp := &runner.CatchUpStatus{}
+		checker.AddCheck("scheduler", health.SchedulerCheck(func() bool { return true }, catchUp.Err))
+
+		runner.StartCatchUpUpdatePaymentStatus(catchUp, leader, jobRunUsecase, tenant.DefaultRegistry(), 7)
+		status, body := get("/readyz")
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(body["checks"]).To(HaveKeyWithValue("scheduler", "ok"))
+
+		close(jobRunUsecase.release)
+		Eventually(func() int {
+			status, _ := get("/readyz")
+			return status
+		}).Should(Equal(http.StatusServiceUnavailable))
+		_, body = get("/readyz")
+		Expect(body["checks"]).To(HaveKeyWithValue("scheduler", ContainSubstring("catch-up of missed business dates failed")))
+	})
+})
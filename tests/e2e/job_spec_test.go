@@ -0,0 +1,164 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Job Runs", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var env *helpers.TestEnvironment
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env = helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "job_runs", "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	// call sends the request to the router and checks the status of the response
+	call := func(method string, path string, payload interface{}, expectedStatus int) map[string]interface{} {
+		body := bytes.NewBuffer(nil)
+		if payload != nil {
+			payloadJSON, _ := json.Marshal(payload)
+			body = bytes.NewBuffer(payloadJSON)
+		}
+		req, _ := http.NewRequest(method, path, body)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(expectedStatus), resp.Body.String())
+
+		var response map[string]interface{}
+		Expect(json.Unmarshal(resp.Body.Bytes(), &response)).To(Succeed())
+		return response
+	}
+
+	ginkgo.It("should re-run the payment status update as of a past date and record the run", func() {
+		call("POST", "/api/v1/loans", map[string]interface{}{
+			"customer_id": 1,
+			"name":        "John Doe",
+			"email":       "johndoe@example.com",
+			"amount":      1000000,
+			"term_weeks":  4,
+			"rates":       10,
+		}, http.StatusOK)
+
+		run := call("POST", "/api/v1/jobs/update-payment-status/runs", map[string]interface{}{"as_of": businessDate(-1)}, http.StatusCreated)
+		Expect(run["job"]).To(Equal("update_payment_status"))
+		Expect(run["business_date"]).To(Equal(businessDate(-1)))
+		Expect(run["trigger"]).To(Equal("manual"))
+		Expect(run["status"]).To(Equal("succeeded"))
+		Expect(run["items_failed"]).To(BeEquivalentTo(0))
+		Expect(run["finished_at"]).ToNot(BeEmpty())
+
+		var triggeredBy string
+		Expect(db.Raw("SELECT triggered_by FROM job_runs WHERE id = ?", run["id"]).Scan(&triggeredBy).Error).To(Succeed())
+		Expect(triggeredBy).To(Equal("manual"))
+	})
+
+	ginkgo.It("should record a manual run that fails and answer it with an error status", func() {
+		// The installments cannot be read while their table is away
+		Expect(db.Exec("ALTER TABLE payments RENAME TO payments_away").Error).To(Succeed())
+		run := call("POST", "/api/v1/jobs/update-payment-status/runs", map[string]interface{}{"as_of": businessDate(0)}, http.StatusInternalServerError)
+		Expect(db.Exec("ALTER TABLE payments_away RENAME TO payments").Error).To(Succeed())
+
+		Expect(run["status"]).To(Equal("failed"))
+		Expect(run["error"]).To(ContainSubstring("error fetching payments"))
+
+		response := call("GET", "/api/v1/jobs/update-payment-status/runs", nil, http.StatusOK)
+		runs := response["runs"].([]interface{})
+		Expect(runs).To(HaveLen(1))
+		Expect(runs[0]).To(HaveKeyWithValue("id", run["id"]))
+		Expect(runs[0]).To(HaveKeyWithValue("status", "failed"))
+	})
+
+	ginkgo.It("should update the loans of several batches in parallel when scheduled", func() {
+		for customerID := 1; customerID <= 5; customerID++ {
+			call("POST", "/api/v1/loans", map[string]interface{}{
+				"customer_id": customerID,
+				"name":        "John Doe",
+				"email":       fmt.Sprintf("johndoe%d@example.com", customerID),
+				"amount":      1000000,
+				"term_weeks":  4,
+				"rates":       10,
+			}, http.StatusOK)
+		}
+
+		// Outside a request every loan gets its own transaction on the workers
+		ctx := pkg.NewTenantContext(tenant.DefaultTenantID)
+		run, err := env.JobRunUsecase.RunUpdatePaymentStatus(ctx, time.Now().AddDate(0, 0, 8), enum.JobRunTriggerSchedule)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(run.ItemsProcessed()).To(Equal(10))
+		Expect(run.ItemsFailed()).To(BeZero())
+
+		var pending int64
+		Expect(db.Raw("SELECT COUNT(*) FROM payments WHERE status = 'pending'").Scan(&pending).Error).To(Succeed())
+		Expect(pending).To(BeEquivalentTo(5))
+		var outstanding int64
+		Expect(db.Raw("SELECT COUNT(*) FROM payments WHERE status = 'outstanding'").Scan(&outstanding).Error).To(Succeed())
+		Expect(outstanding).To(BeEquivalentTo(5))
+	})
+
+	ginkgo.It("should list the runs newest first", func() {
+		call("POST", "/api/v1/jobs/update-payment-status/runs", map[string]interface{}{"as_of": businessDate(-2)}, http.StatusCreated)
+		call("POST", "/api/v1/jobs/update-payment-status/runs", map[string]interface{}{"as_of": businessDate(0)}, http.StatusCreated)
+
+		response := call("GET", "/api/v1/jobs/update-payment-status/runs", nil, http.StatusOK)
+		runs := response["runs"].([]interface{})
+		Expect(runs).To(HaveLen(2))
+		Expect(runs[0]).To(HaveKeyWithValue("business_date", businessDate(0)))
+		Expect(runs[1]).To(HaveKeyWithValue("business_date", businessDate(-2)))
+
+		response = call("GET", "/api/v1/jobs/update-payment-status/runs?limit=1", nil, http.StatusOK)
+		Expect(response["runs"]).To(HaveLen(1))
+	})
+
+	ginkgo.It("should reject invalid business dates and limits", func() {
+		response := call("POST", "/api/v1/jobs/update-payment-status/runs", map[string]interface{}{"as_of": "19-10-2026"}, http.StatusBadRequest)
+		Expect(response["errors"]).To(HaveKey("as_of"))
+		call("POST", "/api/v1/jobs/update-payment-status/runs", map[string]interface{}{"as_of": businessDate(1)}, http.StatusBadRequest)
+		call("GET", "/api/v1/jobs/update-payment-status/runs?limit=0", nil, http.StatusBadRequest)
+		call("GET", "/api/v1/jobs/update-payment-status/runs?limit=abc", nil, http.StatusBadRequest)
+
+		response = call("GET", "/api/v1/jobs/update-payment-status/runs", nil, http.StatusOK)
+		Expect(response["runs"]).To(BeEmpty())
+	})
+
+	ginkgo.It("should only let admins run the jobs", func() {
+		env := helpers.InitializeTestEnvironmentWithAuthenticator(auth.NewStaticAuthenticator(&auth.Principal{
+			Subject: "finance", Role: auth.RoleFinance, Method: auth.MethodAPIKey, TenantID: helpers.TestPrincipal.TenantID,
+		}))
+		defer env.SQLDB.Close()
+		router = env.Router
+
+		call("POST", "/api/v1/jobs/update-payment-status/runs", map[string]interface{}{"as_of": businessDate(0)}, http.StatusForbidden)
+		call("GET", "/api/v1/jobs/update-payment-status/runs", nil, http.StatusForbidden)
+	})
+})
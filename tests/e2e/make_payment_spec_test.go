@@ -2,7 +2,9 @@ package e2e_test
 
 import (
 	"billing_enginee/internal/model"
+	"billing_enginee/internal/tenant"
 	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
 	"billing_enginee/tests/helpers"
 	"bytes"
 	"database/sql"
@@ -37,7 +39,7 @@ var _ = ginkgo.Describe("MakePayment Endpoint", func() {
 	ginkgo.AfterEach(func() {
 		// Clean up the database by truncating tables
 		// Use the helper function to truncate tables
-		err := helpers.TruncateTables(db, "loans", "customers", "payments")
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
 		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
 		sqlDB.Close()
 	})
@@ -102,7 +104,7 @@ var _ = ginkgo.Describe("MakePayment Endpoint", func() {
 
 		// Step 2: Run scheduler for one week ahead
 		currentDate := time.Now().AddDate(0, 0, 8)
-		err = paymentUsecase.UpdatePaymentStatus(db, currentDate)
+		err = paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), currentDate)
 		Expect(err).ToNot(HaveOccurred())
 
 		// Step 3: Make a payment
@@ -142,11 +144,11 @@ var _ = ginkgo.Describe("MakePayment Endpoint", func() {
 
 		// Step 2: Run scheduler for two weeks ahead (simulate two weeks of payments)
 		currentDate := time.Now().AddDate(0, 0, 8) // 1st week
-		err = paymentUsecase.UpdatePaymentStatus(db, currentDate)
+		err = paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), currentDate)
 		Expect(err).ToNot(HaveOccurred())
 
 		currentDate = time.Now().AddDate(0, 0, 15) // 2nd week
-		err = paymentUsecase.UpdatePaymentStatus(db, currentDate)
+		err = paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), currentDate)
 		Expect(err).ToNot(HaveOccurred())
 
 		// Step 3: Make payment for the full outstanding balance (weeks 1, 2, and 3)
@@ -0,0 +1,133 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Metrics", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var paymentUsecase usecase.PaymentUsecase
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+		paymentUsecase = env.PaymentUsecase
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	// scrape reads the metrics families served at /metrics
+	scrape := func() map[string]*dto.MetricFamily {
+		req, _ := http.NewRequest("GET", "/metrics", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		var parser expfmt.TextParser
+		families, err := parser.TextToMetricFamilies(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return families
+	}
+
+	// valueOf returns the value of the series of the family with the labels, 0 when there is none
+	valueOf := func(families map[string]*dto.MetricFamily, name string, labels map[string]string) float64 {
+		family, ok := families[name]
+		if !ok {
+			return 0
+		}
+	series:
+		for _, metric := range family.GetMetric() {
+			for _, pair := range metric.GetLabel() {
+				if value, ok := labels[pair.GetName()]; ok && value != pair.GetValue() {
+					continue series
+				}
+			}
+			switch {
+			case metric.GetCounter() != nil:
+				return metric.GetCounter().GetValue()
+			case metric.GetGauge() != nil:
+				return metric.GetGauge().GetValue()
+			case metric.GetHistogram() != nil:
+				return float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+		return 0
+	}
+
+	ginkgo.It("should expose request, pool, job and portfolio metrics", func() {
+		defaultTenant := map[string]string{"tenant": tenant.DefaultTenantID}
+		paymentRoute := map[string]string{"method": "POST", "route": "/api/v1/loans/:loan_id/payment", "status": "200"}
+		before := scrape()
+
+		payload, _ := json.Marshal(map[string]interface{}{
+			"customer_id": 1,
+			"name":        "John Doe",
+			"email":       "johndoe@example.com",
+			"amount":      5000000,
+			"term_weeks":  50,
+			"rates":       10,
+		})
+		req, _ := http.NewRequest("POST", "/api/v1/loans", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		var loanResponse map[string]interface{}
+		Expect(json.Unmarshal(resp.Body.Bytes(), &loanResponse)).To(Succeed())
+		loanID := loanResponse["loan_id"].(string)
+
+		req, _ = http.NewRequest("POST", "/api/v1/loans/"+loanID+"/payment?amount=110000", nil)
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		// Two weeks and a day later the second installment is overdue
+		err := paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), time.Now().AddDate(0, 0, 15))
+		Expect(err).ToNot(HaveOccurred())
+
+		after := scrape()
+
+		// Requests are recorded under their route template, not their path
+		Expect(valueOf(after, "billing_http_request_duration_seconds", paymentRoute) - valueOf(before, "billing_http_request_duration_seconds", paymentRoute)).To(BeEquivalentTo(1))
+
+		Expect(after).To(HaveKey("go_sql_open_connections"))
+
+		Expect(valueOf(after, "billing_payments_received_total", defaultTenant) - valueOf(before, "billing_payments_received_total", defaultTenant)).To(BeEquivalentTo(1))
+		Expect(valueOf(after, "billing_payments_received_amount_total", defaultTenant) - valueOf(before, "billing_payments_received_amount_total", defaultTenant)).To(BeEquivalentTo(110000))
+
+		Expect(valueOf(after, "billing_installments_turned_pending", defaultTenant)).To(BeEquivalentTo(1))
+		jobLabels := map[string]string{"job": "update_payment_status", "tenant": tenant.DefaultTenantID}
+		Expect(valueOf(after, "billing_job_items_processed_total", jobLabels) - valueOf(before, "billing_job_items_processed_total", jobLabels)).To(BeNumerically(">=", 1))
+
+		Expect(valueOf(after, "billing_open_loans", defaultTenant)).To(BeEquivalentTo(1))
+		Expect(valueOf(after, "billing_outstanding_amount", defaultTenant)).To(BeEquivalentTo(5500000 - 110000))
+	})
+})
@@ -0,0 +1,116 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/health"
+	"billing_enginee/migrations"
+	"billing_enginee/tests/helpers"
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Migrations", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var migrator *migrations.Migrator
+	ctx := context.Background()
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment, it applies the migrations
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		migrator = migrations.NewMigrator(sqlDB, db.Dialector.Name())
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Leave the schema fully migrated for the next specs, even when a spec failed halfway
+		_, err := migrator.Up(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		sqlDB.Close()
+	})
+
+	// columnType returns the type of the column without its length, empty when the column does not exist
+	columnType := func(table string, column string) string {
+		query := "SELECT udt_name FROM information_schema.columns WHERE table_name = ? AND column_name = ?"
+		if db.Dialector.Name() == migrations.DialectSQLite {
+			query = "SELECT type FROM pragma_table_info(?) WHERE name = ?"
+		}
+		var columnType string
+		err := db.Raw(query, table, column).Scan(&columnType).Error
+		Expect(err).ToNot(HaveOccurred())
+		columnType, _, _ = strings.Cut(strings.ToLower(columnType), "(")
+		return columnType
+	}
+
+	ginkgo.It("should build the test schema with the migrations of production", func() {
+		statuses, err := migrator.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		for _, status := range statuses {
+			if status.Migration != nil {
+				Expect(status.Applied).To(BeTrue(), "migration %s is not applied", status.Version)
+			}
+		}
+
+		// Applying again is a no-op
+		applied, err := migrator.Up(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(applied).To(BeEmpty())
+
+		// The statuses are constrained by the migrations, enum types on Postgres and checks on SQLite
+		err = db.Exec("INSERT INTO payments (week, amount, due_date, status) VALUES (1, 100, '2024-10-14', 'bounced')").Error
+		Expect(err).To(HaveOccurred())
+		if db.Dialector.Name() == migrations.DialectPostgres {
+			Expect(columnType("payments", "status")).To(Equal("payment_status"))
+			Expect(columnType("loans", "status")).To(Equal("loan_status"))
+		}
+	})
+
+	ginkgo.It("should roll back the newest migration and apply it again", func() {
+		latest := migrations.LatestVersion()
+
+		rolledBack, err := migrator.Down(ctx, 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rolledBack).To(HaveLen(1))
+		Expect(rolledBack[0].Version).To(Equal(latest))
+		Expect(columnType("outbox_events", "next_attempt_at")).To(BeEmpty())
+
+		version, err := health.SchemaVersion(ctx, sqlDB)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).ToNot(Equal(latest))
+
+		applied, err := migrator.Up(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(applied).To(HaveLen(1))
+		Expect(applied[0].Version).To(Equal(latest))
+		Expect(columnType("outbox_events", "next_attempt_at")).ToNot(BeEmpty())
+	})
+
+	ginkgo.It("should refuse to roll back the tenants once customers of several tenants share an email", func() {
+		const tenantsVersion = "20241019120000"
+		steps := 0
+		for _, version := range migrations.Versions() {
+			if version >= tenantsVersion {
+				steps++
+			}
+		}
+
+		_, err := sqlDB.ExecContext(ctx, "INSERT INTO customers (name, email, tenant_id) VALUES ('John Doe', 'shared@example.com', 'default'), ('John Doe', 'shared@example.com', 'brand-b')")
+		Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			_, err := sqlDB.ExecContext(ctx, "DELETE FROM customers WHERE email = 'shared@example.com'")
+			Expect(err).ToNot(HaveOccurred())
+		}()
+
+		rolledBack, err := migrator.Down(ctx, steps)
+		Expect(err).To(MatchError(ContainSubstring("cannot roll back the tenants migration")))
+		// The newer migrations were rolled back, the tenants migration is still applied
+		Expect(rolledBack).To(HaveLen(steps - 1))
+		Expect(columnType("customers", "tenant_id")).ToNot(BeEmpty())
+	})
+})
@@ -0,0 +1,126 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/notification"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Installment Notifications", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var paymentUsecase usecase.PaymentUsecase
+	var notificationUsecase usecase.NotificationUsecase
+	var channel *notification.MemoryChannel
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+		paymentUsecase = env.PaymentUsecase
+		notificationUsecase = env.NotificationUsecase
+		channel = env.NotificationChannel
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "notifications", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	createLoan := func() {
+		payload := map[string]interface{}{
+			"customer_id": 1,
+			"name":        "John Doe",
+			"email":       "johndoe@example.com",
+			"phone":       "+6281234567890",
+			"amount":      5000000,
+			"term_weeks":  50,
+			"rates":       10,
+		}
+		payloadJSON, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/api/v1/loans", bytes.NewBuffer(payloadJSON))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+	}
+
+	// deliver runs the notification delivery and returns the messages sent so far
+	deliver := func() []notification.Message {
+		Expect(notificationUsecase.DeliverPending(pkg.WithAllTenants(context.Background()), time.Now())).To(Succeed())
+		return channel.Messages()
+	}
+
+	ginkgo.It("should remind the customer days before the installment is due", func() {
+		createLoan()
+
+		// The first installment is due in 7 days, so the reminder goes out 7 - N days from now
+		currentDate := time.Now().AddDate(0, 0, 7-helpers.TestReminderDaysBefore)
+		err := notificationUsecase.QueueUpcomingReminders(pkg.NewTenantContext(tenant.DefaultTenantID), currentDate)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(channel.Messages()).To(BeEmpty())
+
+		messages := deliver()
+		Expect(messages).To(HaveLen(1))
+		Expect(messages[0].Recipient.Email).To(Equal("johndoe@example.com"))
+		Expect(messages[0].Recipient.Phone).To(Equal("+6281234567890"))
+		Expect(messages[0].Subject).To(ContainSubstring("Installment 1"))
+		Expect(messages[0].Body).To(ContainSubstring("110000.00"))
+	})
+
+	ginkgo.It("should not remind anyone when no installment is due at the reminder date", func() {
+		createLoan()
+
+		err := notificationUsecase.QueueUpcomingReminders(pkg.NewTenantContext(tenant.DefaultTenantID), time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deliver()).To(BeEmpty())
+	})
+
+	ginkgo.It("should notify the customer when an installment turns pending", func() {
+		createLoan()
+
+		// Move one week ahead so the first installment becomes pending
+		err := paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), time.Now().AddDate(0, 0, 8))
+		Expect(err).ToNot(HaveOccurred())
+
+		messages := deliver()
+		Expect(messages).To(HaveLen(1))
+		Expect(messages[0].Recipient.Name).To(Equal("John Doe"))
+		Expect(messages[0].Subject).To(ContainSubstring("overdue"))
+	})
+
+	ginkgo.It("should remind an installment only once however many times the reminders run", func() {
+		createLoan()
+
+		currentDate := time.Now().AddDate(0, 0, 7-helpers.TestReminderDaysBefore)
+		Expect(notificationUsecase.QueueUpcomingReminders(pkg.NewTenantContext(tenant.DefaultTenantID), currentDate)).To(Succeed())
+		Expect(deliver()).To(HaveLen(1))
+
+		Expect(notificationUsecase.QueueUpcomingReminders(pkg.NewTenantContext(tenant.DefaultTenantID), currentDate)).To(Succeed())
+		Expect(deliver()).To(HaveLen(1))
+
+		var statuses []string
+		Expect(db.Raw("SELECT status FROM notifications").Scan(&statuses).Error).To(Succeed())
+		Expect(statuses).To(Equal([]string{"sent"}))
+	})
+})
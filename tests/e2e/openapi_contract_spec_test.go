@@ -0,0 +1,245 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("OpenAPI Contract", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var paymentUsecase usecase.PaymentUsecase
+	var outboxUsecase usecase.OutboxUsecase
+	var webhookUsecase usecase.WebhookUsecase
+	var contract *helpers.ContractValidator
+	var receiver *httptest.Server
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+		paymentUsecase = env.PaymentUsecase
+		outboxUsecase = env.OutboxUsecase
+		webhookUsecase = env.WebhookUsecase
+
+		var err error
+		contract, err = helpers.NewContractValidator()
+		Expect(err).ToNot(HaveOccurred())
+
+		// Local stand-in for a webhook subscriber that rejects every delivery
+		receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		receiver.Close()
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "job_runs", "webhook_deliveries", "webhook_subscriptions", "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	// call sends a request through the contract validator and checks the status of the response.
+	// The request itself is only validated when it is expected to succeed.
+	call := func(method string, path string, payload interface{}, expectedStatus int) map[string]interface{} {
+		var body *bytes.Buffer
+		if payload != nil {
+			payloadJSON, _ := json.Marshal(payload)
+			body = bytes.NewBuffer(payloadJSON)
+		} else {
+			body = bytes.NewBuffer(nil)
+		}
+		req, _ := http.NewRequest(method, path, body)
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := contract.Serve(router, req, expectedStatus < http.StatusBadRequest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.Code).To(Equal(expectedStatus), resp.Body.String())
+
+		var response map[string]interface{}
+		if resp.Body.Len() > 0 {
+			err = json.Unmarshal(resp.Body.Bytes(), &response)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		return response
+	}
+
+	loanPayload := map[string]interface{}{
+		"customer_id": 1,
+		"name":        "John Doe",
+		"email":       "johndoe@example.com",
+		"phone":       "+6281234567890",
+		"amount":      5000000,
+		"term_weeks":  50,
+		"rates":       10,
+	}
+
+	ginkgo.It("should serve a valid OpenAPI document without credentials", func() {
+		env := helpers.InitializeTestEnvironmentWithAuthenticator(auth.NewAuthenticator(nil, "", ""))
+		defer env.SQLDB.Close()
+
+		req, _ := http.NewRequest("GET", "/openapi.json", nil)
+		resp := httptest.NewRecorder()
+		env.Router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Type")).To(ContainSubstring("application/json"))
+		var document map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &document)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(document["openapi"]).To(HavePrefix("3."))
+	})
+
+	ginkgo.It("should document every /api/v1 route and nothing else", func() {
+		Expect(contract.DocumentedRoutes()).To(Equal(helpers.RegisteredRoutes(router, "/api/v1")))
+	})
+
+	ginkgo.It("should match the document for loans, payments and customers", func() {
+		loan := call("POST", "/api/v1/loans", loanPayload, http.StatusOK)
+		loanID := loan["loan_id"].(string)
+
+		call("GET", "/api/v1/loans/"+loanID+"/outstanding", nil, http.StatusOK)
+		call("POST", "/api/v1/loans/"+loanID+"/payment?amount=110000", nil, http.StatusOK)
+		call("GET", "/api/v1/customers/1/is_delinquent", nil, http.StatusOK)
+
+		// Error responses are part of the contract too
+		call("POST", "/api/v1/loans", map[string]interface{}{"name": "John 2"}, http.StatusBadRequest)
+		call("GET", "/api/v1/loans/abc/outstanding", nil, http.StatusBadRequest)
+		call("POST", "/api/v1/loans/"+loanID+"/payment?amount=abc", nil, http.StatusBadRequest)
+		call("GET", "/api/v1/customers/0/is_delinquent", nil, http.StatusBadRequest)
+		call("GET", "/api/v1/loans/999999/outstanding", nil, http.StatusNotFound)
+		call("POST", "/api/v1/loans/"+loanID+"/payment?amount=1", nil, http.StatusUnprocessableEntity)
+	})
+
+	ginkgo.It("should match the document for reports", func() {
+		loan := call("POST", "/api/v1/loans", loanPayload, http.StatusOK)
+		call("POST", "/api/v1/loans/"+loan["loan_id"].(string)+"/payment?amount=110000", nil, http.StatusOK)
+		err := paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), time.Now().AddDate(0, 0, 60))
+		Expect(err).ToNot(HaveOccurred())
+
+		portfolio := call("GET", "/api/v1/reports/portfolio", nil, http.StatusOK)
+		Expect(portfolio["loan_count"]).To(BeEquivalentTo(1))
+		call("GET", "/api/v1/reports/portfolio-at-risk?as_of="+time.Now().AddDate(0, 0, 60).Format("2006-01-02"), nil, http.StatusOK)
+		collections := call("GET", "/api/v1/reports/collections?period=week", nil, http.StatusOK)
+		Expect(collections["periods"]).To(HaveLen(1))
+
+		call("GET", "/api/v1/reports/collections?period=year", nil, http.StatusBadRequest)
+		call("GET", "/api/v1/reports/portfolio-at-risk?as_of=yesterday", nil, http.StatusBadRequest)
+	})
+
+	ginkgo.It("should match the document for webhooks and the audit log", func() {
+		subscription := call("POST", "/api/v1/webhooks", map[string]interface{}{
+			"url":         receiver.URL,
+			"secret":      "super-secret-signing-key",
+			"event_types": []string{"loan.created"},
+		}, http.StatusCreated)
+		webhookID := subscription["id"].(string)
+
+		// A failed delivery fills in the nullable fields of the delivery log
+		call("POST", "/api/v1/loans", loanPayload, http.StatusOK)
+		_, err := outboxUsecase.RelayPending(pkg.WithAllTenants(context.Background()), time.Now(), 100)
+		Expect(err).ToNot(HaveOccurred())
+		err = webhookUsecase.DeliverPending(pkg.WithAllTenants(context.Background()), time.Now())
+		Expect(err).ToNot(HaveOccurred())
+
+		call("GET", "/api/v1/webhooks", nil, http.StatusOK)
+		deliveries := call("GET", "/api/v1/webhooks/"+webhookID+"/deliveries", nil, http.StatusOK)
+		Expect(deliveries["deliveries"]).To(HaveLen(1))
+
+		entries := call("GET", "/api/v1/audit?entity=webhook_subscription&id="+webhookID, nil, http.StatusOK)
+		Expect(entries["entries"]).To(HaveLen(1))
+		call("GET", "/api/v1/audit?entity=loan&id=1", nil, http.StatusOK)
+
+		call("DELETE", "/api/v1/webhooks/"+webhookID, nil, http.StatusNoContent)
+		call("DELETE", "/api/v1/webhooks/"+webhookID, nil, http.StatusNotFound)
+		call("POST", "/api/v1/webhooks", map[string]interface{}{"url": "not a url"}, http.StatusBadRequest)
+		call("GET", "/api/v1/audit?entity=invoice&id=1", nil, http.StatusBadRequest)
+	})
+
+	ginkgo.It("should match the document for the simulation clock", func() {
+		call("POST", "/api/v1/loans", loanPayload, http.StatusOK)
+
+		call("GET", "/api/v1/simulation/clock", nil, http.StatusOK)
+		clock := call("POST", "/api/v1/simulation/clock/advance", map[string]interface{}{"days": 8}, http.StatusOK)
+		Expect(clock["days_ahead"]).To(BeEquivalentTo(8))
+
+		call("POST", "/api/v1/simulation/clock/advance", map[string]interface{}{"days": 0}, http.StatusBadRequest)
+	})
+
+	ginkgo.It("should match the document for the job runs", func() {
+		call("POST", "/api/v1/loans", loanPayload, http.StatusOK)
+
+		run := call("POST", "/api/v1/jobs/update-payment-status/runs", map[string]interface{}{"as_of": businessDate(0)}, http.StatusCreated)
+		Expect(run["status"]).To(Equal("succeeded"))
+		call("GET", "/api/v1/jobs/update-payment-status/runs?limit=5", nil, http.StatusOK)
+
+		call("POST", "/api/v1/jobs/update-payment-status/runs", map[string]interface{}{"as_of": "tomorrow"}, http.StatusBadRequest)
+	})
+
+	ginkgo.It("should match the document when credentials are missing or lack the permission", func() {
+		env := helpers.InitializeTestEnvironmentWithAuthenticator(auth.NewStaticAuthenticator(&auth.Principal{
+			Subject: "viewer", Role: auth.RoleViewer, Method: auth.MethodAPIKey, TenantID: helpers.TestPrincipal.TenantID,
+		}))
+		defer env.SQLDB.Close()
+		router = env.Router
+
+		call("POST", "/api/v1/loans", loanPayload, http.StatusForbidden)
+		call("GET", "/api/v1/reports/portfolio", nil, http.StatusForbidden)
+
+		env = helpers.InitializeTestEnvironmentWithAuthenticator(auth.NewAuthenticator(nil, "", ""))
+		defer env.SQLDB.Close()
+		router = env.Router
+
+		call("GET", "/api/v1/webhooks", nil, http.StatusUnauthorized)
+	})
+
+	ginkgo.It("should reject the requests that do not match the document before they reach the handlers", func() {
+		router = helpers.NewRouter(db, auth.NewStaticAuthenticator(helpers.TestPrincipal), tenant.DefaultRegistry())
+		handled := false
+		handle := func(c *gin.Context) {
+			handled = true
+			c.Error(entity.ErrLoanNotFound)
+		}
+		router.POST("/api/v1/loans", handle)
+		router.POST("/api/v1/loans/:loan_id/payment", handle)
+
+		payload := map[string]interface{}{}
+		for key, value := range loanPayload {
+			payload[key] = value
+		}
+		payload["customer_id"] = "one"
+		response := call("POST", "/api/v1/loans", payload, http.StatusBadRequest)
+		Expect(response["code"]).To(Equal("INVALID_INPUT"))
+		Expect(response["errors"]).To(HaveKey("customer_id"))
+
+		response = call("POST", "/api/v1/loans/1/payment?amount=all", nil, http.StatusBadRequest)
+		Expect(response["errors"]).To(HaveKey("amount"))
+		Expect(handled).To(BeFalse())
+
+		call("POST", "/api/v1/loans/1/payment?amount=110000", nil, http.StatusNotFound)
+		Expect(handled).To(BeTrue())
+	})
+})
@@ -0,0 +1,199 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/broker"
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/model"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Transactional Outbox", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var outboxUsecase usecase.OutboxUsecase
+	var published []broker.Message
+	var failingKey string
+	var mu sync.Mutex
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+		outboxUsecase = env.OutboxUsecase
+
+		// Record what the broker hands to consumers, optionally failing the messages of one key
+		published = nil
+		failingKey = ""
+		env.EventBroker.Subscribe(func(_ context.Context, msg broker.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if msg.Key == failingKey {
+				return errors.New("consumer unavailable")
+			}
+			published = append(published, msg)
+			return nil
+		})
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "webhook_deliveries", "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	setFailingKey := func(key string) {
+		mu.Lock()
+		defer mu.Unlock()
+		failingKey = key
+	}
+
+	publishedMessages := func() []broker.Message {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]broker.Message(nil), published...)
+	}
+
+	createLoan := func(customerID int) string {
+		payload := map[string]interface{}{
+			"customer_id": customerID,
+			"name":        "John Doe",
+			"email":       fmt.Sprintf("customer%d@example.com", customerID),
+			"amount":      5000000,
+			"term_weeks":  50,
+			"rates":       10,
+		}
+		payloadJSON, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/api/v1/loans", bytes.NewBuffer(payloadJSON))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		var loanResponse map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &loanResponse)
+		Expect(err).ToNot(HaveOccurred())
+		return loanResponse["loan_id"].(string)
+	}
+
+	makePayment := func(loanID string) {
+		req, _ := http.NewRequest("POST", "/api/v1/loans/"+loanID+"/payment?amount=110000", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+	}
+
+	messageTypes := func(messages []broker.Message) []string {
+		types := make([]string, len(messages))
+		for i, msg := range messages {
+			types[i] = msg.Type
+		}
+		return types
+	}
+
+	ginkgo.It("should write events to the outbox and publish them only when relayed", func() {
+		loanID := createLoan(1)
+		makePayment(loanID)
+
+		var outboxEvents []model.OutboxEvent
+		err := db.Order("id ASC").Find(&outboxEvents).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(outboxEvents).To(HaveLen(2))
+		Expect(outboxEvents[0].EventType).To(Equal("loan.created"))
+		Expect(outboxEvents[1].EventType).To(Equal("payment.received"))
+		Expect(outboxEvents[0].PublishedAt).To(BeNil())
+		Expect(publishedMessages()).To(BeEmpty())
+
+		count, err := outboxUsecase.RelayPending(pkg.WithAllTenants(context.Background()), time.Now(), 100)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(2))
+		Expect(messageTypes(publishedMessages())).To(Equal([]string{"loan.created", "payment.received"}))
+		Expect(publishedMessages()[0].Key).To(Equal("loan:" + loanID))
+
+		// Published messages are not relayed again
+		count, err = outboxUsecase.RelayPending(pkg.WithAllTenants(context.Background()), time.Now(), 100)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(0))
+		Expect(publishedMessages()).To(HaveLen(2))
+	})
+
+	ginkgo.It("should hold back the events of a loan whose message failed while other loans proceed", func() {
+		blockedLoanID := createLoan(1)
+		makePayment(blockedLoanID)
+		otherLoanID := createLoan(2)
+
+		setFailingKey("loan:" + blockedLoanID)
+		count, err := outboxUsecase.RelayPending(pkg.WithAllTenants(context.Background()), time.Now(), 100)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(1))
+		Expect(publishedMessages()).To(HaveLen(1))
+		Expect(publishedMessages()[0].Key).To(Equal("loan:" + otherLoanID))
+
+		var failed model.OutboxEvent
+		err = db.Where("event_type = ?", "loan.created").Order("id ASC").First(&failed).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(failed.PublishedAt).To(BeNil())
+		Expect(failed.Attempts).To(Equal(1))
+		Expect(*failed.LastError).To(ContainSubstring("consumer unavailable"))
+
+		// The failed message waits for its backoff, the later message of its loan with it
+		setFailingKey("")
+		count, err = outboxUsecase.RelayPending(pkg.WithAllTenants(context.Background()), time.Now(), 100)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(0))
+
+		// Once the backoff is over the held back events are published in their original order
+		count, err = outboxUsecase.RelayPending(pkg.WithAllTenants(context.Background()), time.Now().Add(time.Minute), 100)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(2))
+		Expect(messageTypes(publishedMessages()[1:])).To(Equal([]string{"loan.created", "payment.received"}))
+	})
+
+	ginkgo.It("should give up on a message that keeps failing so newer messages are still relayed", func() {
+		failingLoanID := createLoan(1)
+		setFailingKey("loan:" + failingLoanID)
+
+		// A batch of one is filled by the failing message until the relay gives up on it
+		now := time.Now()
+		for i := 0; i < entity.MaxOutboxAttempts; i++ {
+			count, err := outboxUsecase.RelayPending(pkg.WithAllTenants(context.Background()), now, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(0))
+			now = now.Add(2 * time.Hour)
+		}
+
+		var failed model.OutboxEvent
+		Expect(db.Where("aggregate_id = ?", failingLoanID).First(&failed).Error).To(Succeed())
+		Expect(failed.Attempts).To(Equal(entity.MaxOutboxAttempts))
+		Expect(failed.FailedAt).ToNot(BeNil())
+		Expect(failed.PublishedAt).To(BeNil())
+
+		otherLoanID := createLoan(2)
+		count, err := outboxUsecase.RelayPending(pkg.WithAllTenants(context.Background()), now, 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(1))
+		Expect(publishedMessages()).To(HaveLen(1))
+		Expect(publishedMessages()[0].Key).To(Equal("loan:" + otherLoanID))
+	})
+})
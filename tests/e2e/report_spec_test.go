@@ -0,0 +1,165 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/model"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Report Endpoints", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var env *helpers.TestEnvironment
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env = helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	// Helper function to create a loan and return its ID
+	createLoan := func(customerID int, email string) string {
+		payload := map[string]interface{}{
+			"customer_id": customerID,
+			"name":        "John Doe",
+			"email":       email,
+			"amount":      5000000,
+			"term_weeks":  50,
+			"rates":       10,
+		}
+		payloadJSON, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/api/v1/loans", bytes.NewBuffer(payloadJSON))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		var loanResponse map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &loanResponse)
+		Expect(err).ToNot(HaveOccurred())
+		return loanResponse["loan_id"].(string)
+	}
+
+	// Helper function to call a report endpoint and decode its body
+	getReport := func(url string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &response)
+		Expect(err).ToNot(HaveOccurred())
+		return resp.Code, response
+	}
+
+	ginkgo.It("should aggregate the outstanding book and loan statuses", func() {
+		createLoan(1, "johndoe@example.com")
+		loanID := createLoan(2, "janedoe@example.com")
+
+		// Pay the first installment of the second loan
+		req, _ := http.NewRequest("POST", "/api/v1/loans/"+loanID+"/payment?amount=110000", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		code, response := getReport("/api/v1/reports/portfolio")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(response["loan_count"]).To(BeEquivalentTo(2))
+		Expect(response["total_disbursed"]).To(BeEquivalentTo(10000000.0))
+		Expect(response["total_repayable"]).To(BeEquivalentTo(11000000.0))
+		Expect(response["outstanding_amount"]).To(BeNumerically("~", 10890000.0, 0.01))
+		Expect(response["outstanding_principal"]).To(BeNumerically("~", 9900000.0, 0.01))
+		Expect(response["outstanding_interest"]).To(BeNumerically("~", 990000.0, 0.01))
+
+		loansByStatus := response["loans_by_status"].(map[string]interface{})
+		Expect(loansByStatus["open"]).To(BeEquivalentTo(2))
+	})
+
+	ginkgo.It("should report loans that are more than 30 days late as portfolio at risk", func() {
+		lateLoanID := createLoan(1, "johndoe@example.com")
+		createLoan(2, "janedoe@example.com")
+
+		// Move the first installment of the late loan 40 days into the past
+		err := db.Model(&model.Payment{}).
+			Where("loan_id = ? AND week = ?", lateLoanID, 1).
+			Update("due_date", time.Now().AddDate(0, 0, -40)).Error
+		Expect(err).ToNot(HaveOccurred())
+
+		code, response := getReport("/api/v1/reports/portfolio-at-risk")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(response["outstanding_principal"]).To(BeNumerically("~", 10000000.0, 0.01))
+
+		par30 := response["par30"].(map[string]interface{})
+		Expect(par30["loans"]).To(BeEquivalentTo(1))
+		Expect(par30["principal"]).To(BeNumerically("~", 5000000.0, 0.01))
+		Expect(par30["ratio"]).To(BeNumerically("~", 0.5, 0.0001))
+
+		par90 := response["par90"].(map[string]interface{})
+		Expect(par90["loans"]).To(BeEquivalentTo(0))
+	})
+
+	ginkgo.It("should report the amount collected per period", func() {
+		loanID := createLoan(1, "johndoe@example.com")
+
+		req, _ := http.NewRequest("POST", "/api/v1/loans/"+loanID+"/payment?amount=110000", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		code, response := getReport("/api/v1/reports/collections?period=month")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(response["total_collected"]).To(BeEquivalentTo(110000.0))
+		Expect(response["total_count"]).To(BeEquivalentTo(1))
+		Expect(response["periods"]).To(HaveLen(1))
+	})
+
+	ginkgo.It("should report as of the business date of the clock", func() {
+		env.Clock.Advance(40 * 24 * time.Hour)
+		defaultTenant, err := tenant.DefaultRegistry().Get(tenant.DefaultTenantID)
+		Expect(err).ToNot(HaveOccurred())
+		today := defaultTenant.Now(env.Clock).Format("2006-01-02")
+
+		loanID := createLoan(1, "johndoe@example.com")
+		req, _ := http.NewRequest("POST", "/api/v1/loans/"+loanID+"/payment?amount=110000", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		code, response := getReport("/api/v1/reports/portfolio-at-risk")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(response["as_of"]).To(Equal(today))
+
+		// The installment was paid on the business date
+		code, response = getReport("/api/v1/reports/collections?from=" + today + "&to=" + today)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(response["total_count"]).To(BeEquivalentTo(1))
+	})
+
+	ginkgo.It("should reject an unknown collections period", func() {
+		code, response := getReport("/api/v1/reports/collections?period=year")
+		Expect(code).To(Equal(http.StatusBadRequest))
+		Expect(response["code"]).To(Equal("INVALID_INPUT"))
+	})
+})
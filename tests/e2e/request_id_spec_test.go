@@ -0,0 +1,98 @@
+package e2e_test
+
+import (
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Request IDs", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var logs *test.Hook
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+
+		// Capture the entries logged while serving the requests
+		logs = test.NewLocal(log.StandardLogger())
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		log.StandardLogger().ReplaceHooks(make(log.LevelHooks))
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	entriesOf := func(requestID string) []*log.Entry {
+		var entries []*log.Entry
+		for _, entry := range logs.AllEntries() {
+			if entry.Data["request_id"] == requestID {
+				entries = append(entries, entry)
+			}
+		}
+		return entries
+	}
+
+	ginkgo.It("should echo the request ID of the caller and log every line of the request with it", func() {
+		payload, _ := json.Marshal(map[string]interface{}{
+			"customer_id": 1,
+			"name":        "John Doe",
+			"email":       "johndoe@example.com",
+			"amount":      5000000,
+			"term_weeks":  50,
+			"rates":       10,
+		})
+		req, _ := http.NewRequest("POST", "/api/v1/loans", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Request-ID", "req-logs")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("X-Request-ID")).To(Equal("req-logs"))
+
+		// The use case, the repositories and the access log all logged with the request ID
+		entries := entriesOf("req-logs")
+		messages := make([]string, len(entries))
+		for i, entry := range entries {
+			messages[i] = entry.Message
+			Expect(entry.Data["tenant_id"]).To(Equal(helpers.TestPrincipal.TenantID))
+		}
+		Expect(messages).To(ContainElement("Request completed"))
+		Expect(len(messages)).To(BeNumerically(">", 1))
+	})
+
+	ginkgo.It("should generate a request ID and return it in the header and the error body", func() {
+		req, _ := http.NewRequest("GET", "/api/v1/loans/999999/outstanding", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		requestID := resp.Header().Get("X-Request-ID")
+		Expect(requestID).ToNot(BeEmpty())
+
+		var response map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response["trace_id"]).To(Equal(requestID))
+		Expect(entriesOf(requestID)).ToNot(BeEmpty())
+	})
+})
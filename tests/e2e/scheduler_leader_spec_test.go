@@ -0,0 +1,108 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/runner"
+	"billing_enginee/migrations"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"context"
+	"database/sql"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Scheduler Leader", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var ctx context.Context
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		ctx = context.Background()
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		sqlDB.Close()
+	})
+
+	ginkgo.It("should elect a single replica and hand the leadership over when it resigns", func() {
+		if db.Dialector.Name() == migrations.DialectSQLite {
+			ginkgo.Skip("SQLite databases belong to a single process, which always leads")
+		}
+
+		// Replicas share the database, not the pool, like two instances of the service would
+		first := pkg.NewLeader(sqlDB, db.Dialector.Name())
+		second := pkg.NewLeader(sqlDB, db.Dialector.Name())
+		defer first.Resign()
+		defer second.Resign()
+
+		Expect(first.IsLeader(ctx)).To(BeTrue())
+		Expect(second.IsLeader(ctx)).To(BeFalse())
+		Expect(first.IsLeader(ctx)).To(BeTrue(), "the leader keeps the leadership while its session lives")
+
+		first.Resign()
+		Expect(second.IsLeader(ctx)).To(BeTrue())
+		Expect(first.IsLeader(ctx)).To(BeFalse())
+	})
+
+	ginkgo.It("should always lead on SQLite", func() {
+		if db.Dialector.Name() != migrations.DialectSQLite {
+			ginkgo.Skip("only SQLite has a single leader by construction")
+		}
+
+		Expect(pkg.NewLeader(sqlDB, db.Dialector.Name()).IsLeader(ctx)).To(BeTrue())
+		Expect(pkg.NewLeader(sqlDB, db.Dialector.Name()).IsLeader(ctx)).To(BeTrue())
+	})
+
+	ginkgo.It("should only run the scheduled jobs on the leader", func() {
+		leader := pkg.NewLeader(sqlDB, db.Dialector.Name())
+		defer leader.Resign()
+		Expect(leader.IsLeader(ctx)).To(BeTrue())
+
+		runs := map[string]int{}
+		leaderJob := runner.OnLeader(leader)(cron.FuncJob(func() { runs["leader"]++ }))
+		leaderJob.Run()
+		Expect(runs["leader"]).To(Equal(1))
+
+		if db.Dialector.Name() == migrations.DialectPostgres {
+			follower := pkg.NewLeader(sqlDB, db.Dialector.Name())
+			defer follower.Resign()
+			followerJob := runner.OnLeader(follower)(cron.FuncJob(func() { runs["follower"]++ }))
+			followerJob.Run()
+			Expect(runs["follower"]).To(BeZero())
+		}
+	})
+	ginkgo.It("should let a single run of every replica hold the lock of a job", func() {
+		// Replicas share the database on Postgres, on SQLite the runs of the process share the lock
+		jobLock := pkg.NewJobLock(sqlDB, db.Dialector.Name())
+		other := jobLock
+		if db.Dialector.Name() == migrations.DialectPostgres {
+			other = pkg.NewJobLock(sqlDB, db.Dialector.Name())
+		}
+
+		unlock, acquired, err := jobLock.TryLock(ctx, "update_payment_status:default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(acquired).To(BeTrue())
+
+		_, acquired, err = other.TryLock(ctx, "update_payment_status:default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(acquired).To(BeFalse())
+
+		unlockOther, acquired, err := other.TryLock(ctx, "update_payment_status:brand-b")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(acquired).To(BeTrue(), "the jobs of other tenants run meanwhile")
+		unlockOther()
+
+		unlock()
+		unlock, acquired, err = other.TryLock(ctx, "update_payment_status:default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(acquired).To(BeTrue())
+		unlock()
+	})
+})
@@ -0,0 +1,173 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/notification"
+	"billing_enginee/internal/repository"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Simulation Mode", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var channel *notification.MemoryChannel
+	var notificationUsecase usecase.NotificationUsecase
+	var clockRepo repository.SimulationClockRepository
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+		channel = env.NotificationChannel
+		notificationUsecase = env.NotificationUsecase
+		clockRepo = env.SimulationClockRepo
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "job_runs", "simulation_clock", "audit_logs", "outbox_events", "notifications", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	// call sends the request to the router and checks the status of the response
+	call := func(method string, path string, payload interface{}, expectedStatus int) map[string]interface{} {
+		body := bytes.NewBuffer(nil)
+		if payload != nil {
+			payloadJSON, _ := json.Marshal(payload)
+			body = bytes.NewBuffer(payloadJSON)
+		}
+		req, _ := http.NewRequest(method, path, body)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(expectedStatus), resp.Body.String())
+
+		var response map[string]interface{}
+		Expect(json.Unmarshal(resp.Body.Bytes(), &response)).To(Succeed())
+		return response
+	}
+
+	advance := func(days int) map[string]interface{} {
+		return call("POST", "/api/v1/simulation/clock/advance", map[string]interface{}{"days": days}, http.StatusOK)
+	}
+
+	ginkgo.It("should walk a loan through its whole life by advancing the business date", func() {
+		loan := call("POST", "/api/v1/loans", map[string]interface{}{
+			"customer_id": 1,
+			"name":        "John Doe",
+			"email":       "johndoe@example.com",
+			"phone":       "+6281234567890",
+			"amount":      1000000,
+			"term_weeks":  4,
+			"rates":       10,
+		}, http.StatusOK)
+		loanID := loan["loan_id"].(string)
+		Expect(loan["due_date"]).To(Equal(businessDate(7)))
+
+		// The reminder of the first installment is queued N days before it is due and sent by the delivery run
+		advance(7 - helpers.TestReminderDaysBefore)
+		Expect(channel.Messages()).To(BeEmpty())
+		Expect(notificationUsecase.DeliverPending(pkg.WithAllTenants(context.Background()), time.Now())).To(Succeed())
+		Expect(channel.Messages()).To(HaveLen(1))
+		Expect(channel.Messages()[0].Subject).To(ContainSubstring("Installment 1"))
+		call("POST", "/api/v1/loans/"+loanID+"/payment?amount=275000", nil, http.StatusOK)
+
+		// Missing the second installment makes it pending, the third one is outstanding by then
+		advance(helpers.TestReminderDaysBefore + 12)
+		outstanding := call("GET", "/api/v1/loans/"+loanID+"/outstanding", nil, http.StatusOK)
+		Expect(outstanding["outstanding_amount"]).To(BeEquivalentTo(275000))
+		Expect(outstanding["week"]).To(BeEquivalentTo(2))
+		delinquency := call("GET", "/api/v1/customers/1/is_delinquent", nil, http.StatusOK)
+		Expect(delinquency["is_delinquent"]).To(BeFalse())
+		call("POST", "/api/v1/loans/"+loanID+"/payment?amount=275000", nil, http.StatusOK)
+
+		// The third installment is missed too, then paid late with the last one on time, which closes the loan
+		clock := advance(7)
+		Expect(clock["days_ahead"]).To(BeEquivalentTo(26))
+		Expect(clock["business_dates"]).To(HaveKeyWithValue(tenant.DefaultTenantID, businessDate(26)))
+		outstanding = call("GET", "/api/v1/loans/"+loanID+"/outstanding", nil, http.StatusOK)
+		Expect(outstanding["week"]).To(BeEquivalentTo(3))
+		call("POST", "/api/v1/loans/"+loanID+"/payment?amount=275000", nil, http.StatusOK)
+		call("POST", "/api/v1/loans/"+loanID+"/payment?amount=275000", nil, http.StatusOK)
+
+		// Every simulated day is in the run history
+		runs := call("GET", "/api/v1/jobs/update-payment-status/runs?limit=100", nil, http.StatusOK)
+		Expect(runs["runs"]).To(HaveLen(26))
+		Expect(runs["runs"].([]interface{})[0]).To(HaveKeyWithValue("trigger", "simulation"))
+
+		call("GET", "/api/v1/loans/"+loanID+"/outstanding", nil, http.StatusUnprocessableEntity)
+		var status string
+		Expect(db.Raw("SELECT status FROM loans WHERE id = ?", loanID).Scan(&status).Error).To(Succeed())
+		Expect(status).To(Equal("close"))
+	})
+
+	ginkgo.It("should store the clock so a restarted service keeps the business date", func() {
+		advance(5)
+		advance(3)
+
+		offset, err := clockRepo.GetOffset(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(offset).To(Equal(8 * 24 * time.Hour))
+	})
+
+	ginkgo.It("should create loans and their due dates on the business date", func() {
+		advance(30)
+
+		loan := call("POST", "/api/v1/loans", map[string]interface{}{
+			"customer_id": 1,
+			"name":        "John Doe",
+			"email":       "johndoe@example.com",
+			"amount":      1000000,
+			"term_weeks":  4,
+			"rates":       10,
+		}, http.StatusOK)
+		Expect(loan["due_date"]).To(Equal(businessDate(37)))
+	})
+
+	ginkgo.It("should reject an invalid number of days and leave the clock alone", func() {
+		response := call("POST", "/api/v1/simulation/clock/advance", map[string]interface{}{"days": 0}, http.StatusBadRequest)
+		Expect(response["errors"]).To(HaveKey("days"))
+		call("POST", "/api/v1/simulation/clock/advance", map[string]interface{}{"days": 367}, http.StatusBadRequest)
+
+		clock := call("GET", "/api/v1/simulation/clock", nil, http.StatusOK)
+		Expect(clock["days_ahead"]).To(BeEquivalentTo(0))
+	})
+
+	ginkgo.It("should only let admins move the clock", func() {
+		env := helpers.InitializeTestEnvironmentWithAuthenticator(auth.NewStaticAuthenticator(&auth.Principal{
+			Subject: "finance", Role: auth.RoleFinance, Method: auth.MethodAPIKey, TenantID: helpers.TestPrincipal.TenantID,
+		}))
+		defer env.SQLDB.Close()
+		router = env.Router
+
+		call("POST", "/api/v1/simulation/clock/advance", map[string]interface{}{"days": 1}, http.StatusForbidden)
+		Expect(env.Clock.Offset()).To(BeZero())
+	})
+})
+
+// businessDate returns the date of the default tenant the given number of days from now
+func businessDate(days int) string {
+	location, err := time.LoadLocation(tenant.DefaultTimezone)
+	Expect(err).ToNot(HaveOccurred())
+	return time.Now().In(location).AddDate(0, 0, days).Format("2006-01-02")
+}
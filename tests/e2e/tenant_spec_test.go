@@ -0,0 +1,290 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/model"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+const (
+	brandAKey = "brand-a-admin-key"
+	brandBKey = "brand-b-admin-key"
+)
+
+var _ = ginkgo.Describe("Multi-tenancy", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var paymentUsecase usecase.PaymentUsecase
+	var outboxUsecase usecase.OutboxUsecase
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Brand A only offers a 50 weeks product, brand B considers one missed installment delinquent
+		tenants, err := tenant.NewRegistry([]*tenant.Tenant{
+			{
+				ID:       "brand-a",
+				Timezone: "Asia/Jakarta",
+				Products: []tenant.Product{{Code: "weekly-50", TermWeeks: 50, Rates: 10, MinAmount: 1000000, MaxAmount: 10000000}},
+			},
+			{
+				ID:          "brand-b",
+				Timezone:    "Asia/Makassar",
+				Delinquency: tenant.DelinquencyRules{MinPendingInstallments: 1},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		authenticator := auth.NewAuthenticator([]auth.APIKey{
+			{Name: "brand-a-backoffice", Role: auth.RoleAdmin, Hash: auth.HashAPIKey(brandAKey), TenantID: "brand-a"},
+			{Name: "brand-b-backoffice", Role: auth.RoleAdmin, Hash: auth.HashAPIKey(brandBKey), TenantID: "brand-b"},
+		}, "", "")
+
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironmentWithTenants(authenticator, tenants)
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+		paymentUsecase = env.PaymentUsecase
+		outboxUsecase = env.OutboxUsecase
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "webhook_deliveries", "webhook_subscriptions", "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	request := func(method string, path string, apiKey string, payload interface{}) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			payloadJSON, _ := json.Marshal(payload)
+			body.Write(payloadJSON)
+		}
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(auth.APIKeyHeader, apiKey)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	createLoan := func(apiKey string, customerID int, email string, termWeeks int) *httptest.ResponseRecorder {
+		return request("POST", "/api/v1/loans", apiKey, map[string]interface{}{
+			"customer_id": customerID,
+			"name":        "John Doe",
+			"email":       email,
+			"amount":      5000000,
+			"term_weeks":  termWeeks,
+			"rates":       10,
+		})
+	}
+
+	loanID := func(resp *httptest.ResponseRecorder) string {
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		var loanResponse map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &loanResponse)
+		Expect(err).ToNot(HaveOccurred())
+		return loanResponse["loan_id"].(string)
+	}
+
+	ginkgo.It("should store rows under the tenant of the credential and hide them from other tenants", func() {
+		brandALoanID := loanID(createLoan(brandAKey, 1, "johndoe@example.com", 50))
+
+		var loan model.Loan
+		err := db.First(&loan, brandALoanID).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loan.TenantID).To(Equal("brand-a"))
+
+		var otherTenantPayments int64
+		err = db.Model(&model.Payment{}).Where("loan_id = ? AND tenant_id <> ?", brandALoanID, "brand-a").Count(&otherTenantPayments).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(otherTenantPayments).To(BeEquivalentTo(0))
+
+		resp := request("GET", "/api/v1/loans/"+brandALoanID+"/outstanding", brandAKey, nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		// Brand B can neither read nor pay the loan of brand A
+		resp = request("GET", "/api/v1/loans/"+brandALoanID+"/outstanding", brandBKey, nil)
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+		request("POST", "/api/v1/loans/"+brandALoanID+"/payment?amount=110000", brandBKey, nil)
+
+		var paid int64
+		err = db.Model(&model.Payment{}).Where("loan_id = ? AND status = ?", brandALoanID, "paid").Count(&paid).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(paid).To(BeEquivalentTo(0))
+
+		// Reports only cover the loans of the tenant
+		resp = request("GET", "/api/v1/reports/portfolio", brandBKey, nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		var portfolio map[string]interface{}
+		err = json.Unmarshal(resp.Body.Bytes(), &portfolio)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(portfolio["loan_count"]).To(BeEquivalentTo(0))
+	})
+
+	ginkgo.It("should let every tenant create its own customer with the same ID", func() {
+		brandALoanID := loanID(createLoan(brandAKey, 1, "johndoe@example.com", 50))
+		brandBLoanID := loanID(createLoan(brandBKey, 1, "janedoe@example.com", 50))
+
+		var customers []model.Customer
+		err := db.Where("external_id = ?", 1).Order("tenant_id").Find(&customers).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(customers).To(HaveLen(2))
+		Expect(customers[0].TenantID).To(Equal("brand-a"))
+		Expect(customers[0].Name).To(Equal("John Doe"))
+		Expect(customers[1].TenantID).To(Equal("brand-b"))
+		Expect(customers[0].ID).ToNot(Equal(customers[1].ID))
+
+		// Each loan belongs to the customer of its own tenant
+		var brandALoan, brandBLoan model.Loan
+		Expect(db.First(&brandALoan, brandALoanID).Error).To(Succeed())
+		Expect(db.First(&brandBLoan, brandBLoanID).Error).To(Succeed())
+		Expect(brandALoan.CustomerID).To(Equal(customers[0].ID))
+		Expect(brandBLoan.CustomerID).To(Equal(customers[1].ID))
+
+		// A second loan of the customer reuses it
+		loanID(createLoan(brandBKey, 1, "janedoe@example.com", 50))
+		var brandBCustomers int64
+		Expect(db.Model(&model.Customer{}).Where("tenant_id = ?", "brand-b").Count(&brandBCustomers).Error).To(Succeed())
+		Expect(brandBCustomers).To(BeEquivalentTo(1))
+
+		resp := request("GET", "/api/v1/customers/1/is_delinquent", brandBKey, nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+	})
+
+	ginkgo.It("should only accept loans matching the products of the tenant", func() {
+		resp := createLoan(brandAKey, 1, "johndoe@example.com", 10)
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		var response map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response["code"]).To(Equal("INVALID_INPUT"))
+
+		// Brand B has no product catalogue and accepts any terms
+		loanID(createLoan(brandBKey, 2, "johndoe@example.com", 10))
+	})
+
+	ginkgo.It("should apply the delinquency rules and scheduler runs of each tenant", func() {
+		loanID(createLoan(brandAKey, 1, "johndoe@example.com", 50))
+		loanID(createLoan(brandBKey, 1, "janedoe@example.com", 50))
+
+		// The first installment of both loans is overdue 8 days later
+		for _, tenantID := range []string{"brand-a", "brand-b"} {
+			err := paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenantID), time.Now().AddDate(0, 0, 8))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		isDelinquent := func(apiKey string, customerID string) bool {
+			resp := request("GET", "/api/v1/customers/"+customerID+"/is_delinquent", apiKey, nil)
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			var response map[string]interface{}
+			err := json.Unmarshal(resp.Body.Bytes(), &response)
+			Expect(err).ToNot(HaveOccurred())
+			return response["is_delinquent"].(bool)
+		}
+		Expect(isDelinquent(brandAKey, "1")).To(BeFalse())
+		Expect(isDelinquent(brandBKey, "1")).To(BeTrue())
+	})
+
+	ginkgo.It("should only update the installments of the tenant the scheduler runs for", func() {
+		loanID(createLoan(brandAKey, 1, "johndoe@example.com", 50))
+		brandBLoanID := loanID(createLoan(brandBKey, 2, "janedoe@example.com", 50))
+
+		err := paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext("brand-a"), time.Now().AddDate(0, 0, 8))
+		Expect(err).ToNot(HaveOccurred())
+
+		var pending []model.Payment
+		err = db.Where("status = ?", "pending").Find(&pending).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pending).To(HaveLen(1))
+		Expect(pending[0].TenantID).To(Equal("brand-a"))
+
+		var brandBPending int64
+		err = db.Model(&model.Payment{}).Where("loan_id = ? AND status = ?", brandBLoanID, "pending").Count(&brandBPending).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(brandBPending).To(BeEquivalentTo(0))
+	})
+
+	ginkgo.It("should refuse the work of a context without a tenant unless it opts in to every tenant", func() {
+		loanID(createLoan(brandAKey, 1, "johndoe@example.com", 50))
+		loanID(createLoan(brandBKey, 2, "janedoe@example.com", 50))
+
+		err := paymentUsecase.UpdatePaymentStatus(context.Background(), time.Now().AddDate(0, 0, 8))
+		Expect(err).To(MatchError(ContainSubstring(pkg.ErrNoTenant.Error())))
+
+		var pending int64
+		Expect(db.Model(&model.Payment{}).Where("status = ?", "pending").Count(&pending).Error).To(Succeed())
+		Expect(pending).To(BeEquivalentTo(0))
+
+		// The relay opts in and reads the messages of both tenants
+		relayed, err := outboxUsecase.RelayPending(pkg.WithAllTenants(context.Background()), time.Now(), 100)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(relayed).To(BeNumerically(">=", 2))
+	})
+
+	ginkgo.It("should only deliver the events of a tenant to its own webhook subscriptions", func() {
+		subscribe := func(apiKey string, url string) string {
+			resp := request("POST", "/api/v1/webhooks", apiKey, map[string]interface{}{
+				"url":         url,
+				"secret":      "tenant-webhook-secret",
+				"event_types": []string{"loan.created"},
+			})
+			Expect(resp.Code).To(Equal(http.StatusCreated))
+			var response map[string]interface{}
+			err := json.Unmarshal(resp.Body.Bytes(), &response)
+			Expect(err).ToNot(HaveOccurred())
+			return response["id"].(string)
+		}
+		subscribe(brandAKey, "https://brand-a.example.com/hooks")
+		brandBSubscriptionID := subscribe(brandBKey, "https://brand-b.example.com/hooks")
+
+		// Each tenant only lists its own subscriptions
+		resp := request("GET", "/api/v1/webhooks", brandAKey, nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		var subscriptions struct {
+			Webhooks []map[string]interface{} `json:"webhooks"`
+		}
+		err := json.Unmarshal(resp.Body.Bytes(), &subscriptions)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(subscriptions.Webhooks).To(HaveLen(1))
+		Expect(subscriptions.Webhooks[0]["url"]).To(Equal("https://brand-a.example.com/hooks"))
+
+		loanID(createLoan(brandBKey, 1, "janedoe@example.com", 50))
+
+		var message model.OutboxEvent
+		Expect(db.Where("event_type = ?", "loan.created").First(&message).Error).To(Succeed())
+		Expect(message.TenantID).To(Equal("brand-b"))
+
+		// The relay reads every tenant, the event only fans out to the subscriptions of its own
+		_, err = outboxUsecase.RelayPending(pkg.WithAllTenants(context.Background()), time.Now(), 100)
+		Expect(err).ToNot(HaveOccurred())
+
+		var deliveries []model.WebhookDelivery
+		Expect(db.Find(&deliveries).Error).To(Succeed())
+		Expect(deliveries).To(HaveLen(1))
+		Expect(deliveries[0].TenantID).To(Equal("brand-b"))
+		Expect(strconv.FormatUint(uint64(deliveries[0].SubscriptionID), 10)).To(Equal(brandBSubscriptionID))
+
+		var payload map[string]interface{}
+		Expect(json.Unmarshal([]byte(deliveries[0].Payload), &payload)).To(Succeed())
+		Expect(payload["tenant_id"]).To(Equal("brand-b"))
+	})
+})
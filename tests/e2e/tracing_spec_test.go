@@ -0,0 +1,165 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Tracing", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var paymentUsecase usecase.PaymentUsecase
+	var spans *tracetest.SpanRecorder
+	var ignoredSpans int
+	var logs *test.Hook
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Record the spans in memory and continue the traces of the callers
+		spans = tracetest.NewSpanRecorder()
+		ignoredSpans = 0
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+		paymentUsecase = env.PaymentUsecase
+
+		// Capture the entries logged while serving the requests
+		logs = test.NewLocal(log.StandardLogger())
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		log.StandardLogger().ReplaceHooks(make(log.LevelHooks))
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	createLoan := func() string {
+		payload, _ := json.Marshal(map[string]interface{}{
+			"customer_id": 1,
+			"name":        "John Doe",
+			"email":       "johndoe@example.com",
+			"amount":      5000000,
+			"term_weeks":  50,
+			"rates":       10,
+		})
+		req, _ := http.NewRequest("POST", "/api/v1/loans", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		var loanResponse map[string]interface{}
+		Expect(json.Unmarshal(resp.Body.Bytes(), &loanResponse)).To(Succeed())
+		return loanResponse["loan_id"].(string)
+	}
+
+	// spansNamed returns the ended spans with the name, except the ignored ones ended first
+	spansNamed := func(name string) []sdktrace.ReadOnlySpan {
+		var named []sdktrace.ReadOnlySpan
+		for _, span := range spans.Ended()[ignoredSpans:] {
+			if span.Name() == name {
+				named = append(named, span)
+			}
+		}
+		return named
+	}
+
+	ginkgo.It("should trace a payment from the route to its queries and commit under the trace of the caller", func() {
+		loanID := createLoan()
+		// The loan was created under another trace
+		ignoredSpans = len(spans.Ended())
+
+		const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		req, _ := http.NewRequest("POST", "/api/v1/loans/"+loanID+"/payment?amount=110000", nil)
+		req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+		req.Header.Set("X-Request-ID", "trace-payment-1")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		server := spansNamed("POST /api/v1/loans/:loan_id/payment")
+		Expect(server).To(HaveLen(1))
+		Expect(server[0].SpanContext().TraceID().String()).To(Equal(traceID))
+		Expect(server[0].Attributes()).To(ContainElement(attribute.String("request.id", "trace-payment-1")))
+		Expect(server[0].Attributes()).To(ContainElement(attribute.Int("http.response.status_code", http.StatusOK)))
+
+		// The use case, its unit of work, its queries and the commit all belong to the trace of the request
+		for _, name := range []string{"LoanUsecase.MakePayment", "UnitOfWork.Do", "db.query", "db.update", "db.commit"} {
+			named := spansNamed(name)
+			Expect(named).ToNot(BeEmpty(), "no %s span", name)
+			for _, span := range named {
+				Expect(span.SpanContext().TraceID().String()).To(Equal(traceID), "%s span outside of the trace", name)
+			}
+		}
+		usecaseSpan := spansNamed("LoanUsecase.MakePayment")[0]
+		Expect(usecaseSpan.Parent().SpanID()).To(Equal(server[0].SpanContext().SpanID()))
+
+		// Logs of the request carry its trace ID next to its request ID
+		found := false
+		for _, entry := range logs.AllEntries() {
+			if entry.Data["request_id"] == "trace-payment-1" {
+				Expect(entry.Data).To(HaveKeyWithValue("trace_id", traceID))
+				found = true
+			}
+		}
+		Expect(found).To(BeTrue())
+	})
+
+	ginkgo.It("should trace every installment of the daily status update under its run", func() {
+		createLoan()
+
+		err := paymentUsecase.UpdatePaymentStatus(pkg.NewTenantContext(tenant.DefaultTenantID), time.Now().AddDate(0, 0, 8))
+		Expect(err).ToNot(HaveOccurred())
+
+		run := spansNamed("PaymentUsecase.UpdatePaymentStatus")
+		Expect(run).To(HaveLen(1))
+
+		installments := spansNamed("PaymentUsecase.savePaymentStatus")
+		Expect(installments).ToNot(BeEmpty())
+		for _, span := range installments {
+			Expect(span.SpanContext().TraceID()).To(Equal(run[0].SpanContext().TraceID()))
+		}
+	})
+
+	ginkgo.It("should mark the span of a failing use case as failed", func() {
+		req, _ := http.NewRequest("GET", "/api/v1/loans/999999/outstanding", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+
+		failed := spansNamed("LoanUsecase.GetOutstanding")
+		Expect(failed).To(HaveLen(1))
+		Expect(failed[0].Status().Code).To(Equal(codes.Error))
+	})
+})
@@ -0,0 +1,96 @@
+package e2e_test
+
+import (
+	"billing_enginee/api/routes"
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/model"
+	"billing_enginee/internal/repository"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+// failingNextPaymentRepository fails to look up the next installment, after the paid ones were already written
+type failingNextPaymentRepository struct {
+	repository.PaymentRepository
+}
+
+func (r *failingNextPaymentRepository) GetNextPayment(ctx context.Context, loanID uint) (*entity.Payment, error) {
+	return nil, errors.New("next payment unavailable")
+}
+
+var _ = ginkgo.Describe("Transactions", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var uow pkg.UnitOfWork
+	var router *gin.Engine
+	var loanUsecase usecase.LoanUsecase
+	var loanID uint
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		uow = env.UnitOfWork
+
+		// Loans are created normally, payments fail in updateNextPayment
+		loan, err := env.LoanUsecase.CreateLoan(pkg.NewTenantContext(tenant.DefaultTenantID), 1, "John Doe", "johndoe@example.com", "", 5000000, 50, 10)
+		Expect(err).ToNot(HaveOccurred())
+		loanID = loan.LoanID
+
+		tenants := tenant.DefaultRegistry()
+		loanUsecase = usecase.NewLoanUsecase(uow, env.LoanRepo, env.CustomerRepo, &failingNextPaymentRepository{env.PaymentRepo}, env.OutboxUsecase, tenants, env.Clock)
+		router = helpers.NewRouter(db, auth.NewStaticAuthenticator(helpers.TestPrincipal), tenants)
+		routes.SetupLoanRoutes(router, loanUsecase)
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	expectNothingPaid := func() {
+		var paid int64
+		db.Model(&model.Payment{}).Where("loan_id = ? AND status = ?", loanID, "paid").Count(&paid)
+		Expect(paid).To(BeZero())
+
+		var events int64
+		db.Model(&model.OutboxEvent{}).Where("event_type = ?", "payment.received").Count(&events)
+		Expect(events).To(BeZero())
+	}
+
+	ginkgo.It("should leave no installment paid when a payment request fails in updateNextPayment", func() {
+		req, _ := http.NewRequest("POST", "/api/v1/loans/"+strconv.FormatUint(uint64(loanID), 10)+"/payment?amount=110000", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusInternalServerError))
+		expectNothingPaid()
+	})
+
+	ginkgo.It("should undo a failed payment even when the surrounding unit of work commits", func() {
+		err := uow.Do(pkg.NewTenantContext(tenant.DefaultTenantID), func(ctx context.Context) error {
+			Expect(loanUsecase.MakePayment(ctx, loanID, 110000)).To(HaveOccurred())
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		expectNothingPaid()
+	})
+})
@@ -0,0 +1,78 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/model"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("Unit of Work", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var uow pkg.UnitOfWork
+	var loanUsecase usecase.LoanUsecase
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		uow = env.UnitOfWork
+		loanUsecase = env.LoanUsecase
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	countLoans := func() int64 {
+		var count int64
+		db.Model(&model.Loan{}).Count(&count)
+		return count
+	}
+
+	ginkgo.It("should run use cases outside of an HTTP request as the system actor", func() {
+		ctx := pkg.NewTenantContext(tenant.DefaultTenantID)
+
+		loan, err := loanUsecase.CreateLoan(ctx, 1, "John Doe", "johndoe@example.com", "", 5000000, 50, 10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(countLoans()).To(BeEquivalentTo(1))
+
+		var entry model.AuditLog
+		err = db.Where("entity_type = ? AND entity_id = ?", "loan", loan.LoanID).First(&entry).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entry.Actor).To(Equal(pkg.SystemActor))
+	})
+
+	ginkgo.It("should join the transaction of the context and roll back with it", func() {
+		ctx := pkg.NewTenantContext(tenant.DefaultTenantID)
+		errAbort := errors.New("abort")
+
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			if _, err := loanUsecase.CreateLoan(ctx, 1, "John Doe", "johndoe@example.com", "", 5000000, 50, 10); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		Expect(err).To(MatchError(errAbort))
+
+		// Neither the loan nor its audit entry and event survived the rollback
+		Expect(countLoans()).To(BeZero())
+		var events int64
+		db.Model(&model.OutboxEvent{}).Count(&events)
+		Expect(events).To(BeZero())
+	})
+})
@@ -0,0 +1,250 @@
+package e2e_test
+
+import (
+	"billing_enginee/internal/model"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/internal/webhook"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+const webhookSecret = "super-secret-signing-key"
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+var _ = ginkgo.Describe("Webhook Endpoints", func() {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var router *gin.Engine
+	var webhookUsecase usecase.WebhookUsecase
+	var outboxUsecase usecase.OutboxUsecase
+	var receiver *httptest.Server
+	var receiverStatus int
+	var received []receivedWebhook
+	var mu sync.Mutex
+
+	// Set up the test environment before each test
+	ginkgo.BeforeEach(func() {
+		// Use the helper to initialize the environment
+		env := helpers.InitializeTestEnvironment()
+		db = env.DB
+		sqlDB = env.SQLDB
+		router = env.Router
+		webhookUsecase = env.WebhookUsecase
+		outboxUsecase = env.OutboxUsecase
+
+		// Local stand-in for the subscriber endpoint
+		received = nil
+		receiverStatus = http.StatusOK
+		receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			received = append(received, receivedWebhook{header: r.Header.Clone(), body: body})
+			status := receiverStatus
+			mu.Unlock()
+			w.WriteHeader(status)
+		}))
+	})
+	// Tear down after each test
+	ginkgo.AfterEach(func() {
+		receiver.Close()
+		// Clean up the database by truncating tables
+		err := helpers.TruncateTables(db, "webhook_deliveries", "webhook_subscriptions", "audit_logs", "outbox_events", "loans", "customers", "payments")
+		Expect(err).ToNot(HaveOccurred(), "Failed to truncate tables before running tests")
+		sqlDB.Close()
+	})
+
+	setReceiverStatus := func(status int) {
+		mu.Lock()
+		defer mu.Unlock()
+		receiverStatus = status
+	}
+
+	receivedCalls := func() []receivedWebhook {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedWebhook(nil), received...)
+	}
+
+	subscribe := func(eventTypes ...string) string {
+		payload := map[string]interface{}{
+			"url":         receiver.URL,
+			"secret":      webhookSecret,
+			"event_types": eventTypes,
+		}
+		payloadJSON, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/api/v1/webhooks", bytes.NewBuffer(payloadJSON))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusCreated))
+
+		var response map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response).ToNot(HaveKey("secret"))
+		return response["id"].(string)
+	}
+
+	createLoan := func() string {
+		payload := map[string]interface{}{
+			"customer_id": 1,
+			"name":        "John Doe",
+			"email":       "johndoe@example.com",
+			"amount":      5000000,
+			"term_weeks":  50,
+			"rates":       10,
+		}
+		payloadJSON, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/api/v1/loans", bytes.NewBuffer(payloadJSON))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		var loanResponse map[string]interface{}
+		err := json.Unmarshal(resp.Body.Bytes(), &loanResponse)
+		Expect(err).ToNot(HaveOccurred())
+		return loanResponse["loan_id"].(string)
+	}
+
+	// relayOutbox hands the events written by the API calls to the broker, which queues the webhook deliveries
+	relayOutbox := func() {
+		_, err := outboxUsecase.RelayPending(pkg.WithAllTenants(context.Background()), time.Now(), 100)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	ginkgo.It("should deliver a signed loan.created event to the subscriber", func() {
+		subscribe("loan.created")
+		createLoan()
+		relayOutbox()
+
+		err := webhookUsecase.DeliverPending(pkg.WithAllTenants(context.Background()), time.Now())
+		Expect(err).ToNot(HaveOccurred())
+
+		calls := receivedCalls()
+		Expect(calls).To(HaveLen(1))
+		call := calls[0]
+		Expect(call.header.Get(webhook.HeaderEvent)).To(Equal("loan.created"))
+
+		// The receiver can verify the payload with the shared secret
+		expected := webhook.Sign(webhookSecret, call.header.Get(webhook.HeaderTimestamp), call.body)
+		Expect(call.header.Get(webhook.HeaderSignature)).To(Equal(expected))
+
+		var event map[string]interface{}
+		err = json.Unmarshal(call.body, &event)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(event["type"]).To(Equal("loan.created"))
+		data := event["data"].(map[string]interface{})
+		Expect(data["total_amount"]).To(BeEquivalentTo(5500000.0))
+	})
+
+	ginkgo.It("should only queue events the subscription asked for", func() {
+		subscribe("payment.received")
+		loanID := createLoan()
+
+		req, _ := http.NewRequest("POST", "/api/v1/loans/"+loanID+"/payment?amount=110000", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		relayOutbox()
+
+		var deliveries []model.WebhookDelivery
+		err := db.Find(&deliveries).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deliveries).To(HaveLen(1))
+		Expect(deliveries[0].EventType).To(Equal("payment.received"))
+	})
+
+	ginkgo.It("should retry failed deliveries with backoff and expose them in the delivery log", func() {
+		webhookID := subscribe("loan.created")
+		createLoan()
+		relayOutbox()
+		setReceiverStatus(http.StatusInternalServerError)
+
+		now := time.Now()
+		err := webhookUsecase.DeliverPending(pkg.WithAllTenants(context.Background()), now)
+		Expect(err).ToNot(HaveOccurred())
+
+		var delivery model.WebhookDelivery
+		err = db.First(&delivery).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(delivery.Status).To(Equal("pending"))
+		Expect(delivery.Attempts).To(Equal(1))
+		Expect(*delivery.ResponseStatus).To(Equal(http.StatusInternalServerError))
+		Expect(delivery.NextAttemptAt).To(BeTemporally(">", now))
+
+		// The delivery is not attempted again before its backoff elapsed
+		err = webhookUsecase.DeliverPending(pkg.WithAllTenants(context.Background()), now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(receivedCalls()).To(HaveLen(1))
+
+		// Once the subscriber recovers the retry succeeds
+		setReceiverStatus(http.StatusOK)
+		err = webhookUsecase.DeliverPending(pkg.WithAllTenants(context.Background()), now.Add(time.Minute))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(receivedCalls()).To(HaveLen(2))
+
+		req, _ := http.NewRequest("GET", "/api/v1/webhooks/"+webhookID+"/deliveries", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		var response map[string][]map[string]interface{}
+		err = json.Unmarshal(resp.Body.Bytes(), &response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response["deliveries"]).To(HaveLen(1))
+		Expect(response["deliveries"][0]["status"]).To(Equal("delivered"))
+		Expect(response["deliveries"][0]["attempts"]).To(BeEquivalentTo(2))
+	})
+
+	ginkgo.It("should queue a relayed event only once when the broker redelivers it", func() {
+		subscribe("loan.created")
+		createLoan()
+		relayOutbox()
+
+		// Simulate an at-least-once redelivery of the already relayed message
+		var outboxEvent model.OutboxEvent
+		err := db.First(&outboxEvent).Error
+		Expect(err).ToNot(HaveOccurred())
+		err = db.Model(&outboxEvent).Update("published_at", nil).Error
+		Expect(err).ToNot(HaveOccurred())
+		relayOutbox()
+
+		var count int64
+		err = db.Model(&model.WebhookDelivery{}).Count(&count).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(BeEquivalentTo(1))
+	})
+
+	ginkgo.It("should reject unknown event types", func() {
+		payload := map[string]interface{}{
+			"url":         receiver.URL,
+			"secret":      webhookSecret,
+			"event_types": []string{"loan.exploded"},
+		}
+		payloadJSON, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/api/v1/webhooks", bytes.NewBuffer(payloadJSON))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+	})
+})
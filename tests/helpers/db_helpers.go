@@ -10,12 +10,20 @@ import (
 // TruncateTables truncates the specified tables and resets their IDs.
 // It also ensures that the tables are properly emptied before the tests proceed.
 func TruncateTables(db *gorm.DB, tables ...string) error {
+	if db.Dialector.Name() == "sqlite" {
+		if err := truncateSQLiteTables(db, tables); err != nil {
+			return err
+		}
+	}
+
 	for _, table := range tables {
 		log.WithField("table", table).Info("Truncating table")
 
 		// Execute the truncation query
-		if err := db.Exec(fmt.Sprintf("TRUNCATE TABLE %s RESTART IDENTITY CASCADE;", table)).Error; err != nil {
-			return fmt.Errorf("failed to truncate table %s: %w", table, err)
+		if db.Dialector.Name() != "sqlite" {
+			if err := db.Exec(fmt.Sprintf("TRUNCATE TABLE %s RESTART IDENTITY CASCADE;", table)).Error; err != nil {
+				return fmt.Errorf("failed to truncate table %s: %w", table, err)
+			}
 		}
 
 		// Verify that the table is empty
@@ -29,3 +37,43 @@ func TruncateTables(db *gorm.DB, tables ...string) error {
 	}
 	return nil
 }
+
+// truncateSQLiteTables empties the tables in one transaction, SQLite has no TRUNCATE. The foreign keys are
+// checked at commit, once every table is empty, and the triggers of the tables, like the one keeping the
+// audit log append-only, are dropped and created again around the deletes.
+func truncateSQLiteTables(db *gorm.DB, tables []string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("PRAGMA defer_foreign_keys = ON").Error; err != nil {
+			return fmt.Errorf("failed to defer foreign keys: %w", err)
+		}
+
+		for _, table := range tables {
+			var triggers []struct {
+				Name string
+				SQL  string
+			}
+			if err := tx.Raw("SELECT name, sql FROM sqlite_master WHERE type = 'trigger' AND tbl_name = ?", table).Scan(&triggers).Error; err != nil {
+				return fmt.Errorf("failed to read the triggers of table %s: %w", table, err)
+			}
+			for _, trigger := range triggers {
+				if err := tx.Exec(fmt.Sprintf("DROP TRIGGER %s", trigger.Name)).Error; err != nil {
+					return fmt.Errorf("failed to drop trigger %s: %w", trigger.Name, err)
+				}
+			}
+
+			if err := tx.Exec(fmt.Sprintf("DELETE FROM %s", table)).Error; err != nil {
+				return fmt.Errorf("failed to truncate table %s: %w", table, err)
+			}
+			if err := tx.Exec("DELETE FROM sqlite_sequence WHERE name = ?", table).Error; err != nil {
+				return fmt.Errorf("failed to reset the IDs of table %s: %w", table, err)
+			}
+
+			for _, trigger := range triggers {
+				if err := tx.Exec(trigger.SQL).Error; err != nil {
+					return fmt.Errorf("failed to create trigger %s again: %w", trigger.Name, err)
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,118 @@
+package helpers
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/notification"
+	"billing_enginee/internal/repository"
+	"billing_enginee/internal/repository/memory"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MemoryEnvironment holds the use cases wired to the in-memory repositories and a simulated clock, for unit tests without a database
+type MemoryEnvironment struct {
+	Store               *memory.Store
+	LoanRepo            repository.LoanRepository
+	CustomerRepo        repository.CustomerRepository
+	PaymentRepo         repository.PaymentRepository
+	JobRunRepo          repository.JobRunRepository
+	SimulationClockRepo repository.SimulationClockRepository
+	NotificationRepo    repository.NotificationRepository
+	LoanUsecase         usecase.LoanUsecase
+	PaymentUsecase      usecase.PaymentUsecase
+	JobRunUsecase       usecase.JobRunUsecase
+	SimulationUsecase   usecase.SimulationUsecase
+	NotificationUsecase usecase.NotificationUsecase
+	Clock               *pkg.SimulatedClock
+	NotificationChannel *notification.MemoryChannel
+	Events              *EventRecorder
+}
+
+// InitializeMemoryEnvironment sets up the loan and payment use cases on an empty in-memory store
+func InitializeMemoryEnvironment() *MemoryEnvironment {
+	return InitializeMemoryEnvironmentWithTenants(tenant.DefaultRegistry())
+}
+
+// InitializeMemoryEnvironmentWithTenants sets up the loan and payment use cases of the tenants on an empty in-memory store
+func InitializeMemoryEnvironmentWithTenants(tenants tenant.Registry) *MemoryEnvironment {
+	store := memory.NewStore()
+	loanRepo := memory.NewLoanRepository(store)
+	customerRepo := memory.NewCustomerRepository(store)
+	paymentRepo := memory.NewPaymentRepository(store)
+	jobRunRepo := memory.NewJobRunRepository(store)
+	simulationClockRepo := memory.NewSimulationClockRepository(store)
+	notificationRepo := memory.NewNotificationRepository(store)
+
+	clock := pkg.NewSimulatedClock()
+	events := &EventRecorder{}
+	notificationChannel := notification.NewMemoryChannel("memory")
+	notificationUsecase := usecase.NewNotificationUsecase(store, paymentRepo, notificationRepo, notification.NewNotifier(notificationChannel), TestReminderDaysBefore)
+
+	paymentUsecase := usecase.NewPaymentUsecase(store, paymentRepo, notificationUsecase, events, TestStatusUpdateBatchSize, TestStatusUpdateWorkers)
+	jobRunUsecase := usecase.NewJobRunUsecase(jobRunRepo, paymentUsecase, tenants, clock, pkg.NewLocalJobLock())
+
+	return &MemoryEnvironment{
+		Store:               store,
+		LoanRepo:            loanRepo,
+		CustomerRepo:        customerRepo,
+		PaymentRepo:         paymentRepo,
+		JobRunRepo:          jobRunRepo,
+		SimulationClockRepo: simulationClockRepo,
+		NotificationRepo:    notificationRepo,
+		LoanUsecase:         usecase.NewLoanUsecase(store, loanRepo, customerRepo, paymentRepo, events, tenants, clock),
+		PaymentUsecase:      paymentUsecase,
+		JobRunUsecase:       jobRunUsecase,
+		SimulationUsecase:   usecase.NewSimulationUsecase(clock, simulationClockRepo, tenants, jobRunUsecase, notificationUsecase),
+		NotificationUsecase: notificationUsecase,
+		Clock:               clock,
+		NotificationChannel: notificationChannel,
+		Events:              events,
+	}
+}
+
+// EventRecorder is an event publisher keeping the events in memory. It does not follow the units of work:
+// an event published by one rolled back afterwards stays recorded.
+type EventRecorder struct {
+	mu      sync.Mutex
+	events  []*entity.Event
+	failing map[enum.EventType]bool
+}
+
+func (r *EventRecorder) Publish(ctx context.Context, event *entity.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.failing[event.Type()] {
+		return fmt.Errorf("publishing %s events is failing", event.Type())
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+// FailOn makes publishing the events of the type fail
+func (r *EventRecorder) FailOn(eventType enum.EventType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.failing == nil {
+		r.failing = make(map[enum.EventType]bool)
+	}
+	r.failing[eventType] = true
+}
+
+// Types returns the types of the recorded events, in the order they were published
+func (r *EventRecorder) Types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	types := make([]string, len(r.events))
+	for i, event := range r.events {
+		types[i] = event.Type().String()
+	}
+	return types
+}
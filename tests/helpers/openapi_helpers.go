@@ -0,0 +1,106 @@
+package helpers
+
+import (
+	"billing_enginee/api/openapi"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+)
+
+// ContractValidator checks requests and responses against the OpenAPI document served by the API
+type ContractValidator struct {
+	doc    *openapi3.T
+	router routers.Router
+}
+
+// NewContractValidator loads and validates the OpenAPI document
+func NewContractValidator() (*ContractValidator, error) {
+	doc, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+	router, err := openapi.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &ContractValidator{doc: doc, router: router}, nil
+}
+
+// DocumentedRoutes lists the operations of the document as "METHOD /path/{param}"
+func (v *ContractValidator) DocumentedRoutes() []string {
+	var documented []string
+	for path, item := range v.doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+	sort.Strings(documented)
+	return documented
+}
+
+// RegisteredRoutes lists the routes of the router under the prefix in the notation of DocumentedRoutes
+func RegisteredRoutes(router *gin.Engine, prefix string) []string {
+	var registered []string
+	for _, route := range router.Routes() {
+		if !strings.HasPrefix(route.Path, prefix) {
+			continue
+		}
+		segments := strings.Split(route.Path, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segments[i] = "{" + strings.TrimPrefix(segment, ":") + "}"
+			}
+		}
+		registered = append(registered, route.Method+" "+strings.Join(segments, "/"))
+	}
+	sort.Strings(registered)
+	return registered
+}
+
+// Serve validates the request, sends it to the router and validates the response.
+// Requests expected to be rejected by the API are not validated, only their responses.
+func (v *ContractValidator) Serve(handler http.Handler, req *http.Request, validateRequest bool) (*httptest.ResponseRecorder, error) {
+	route, pathParams, err := v.router.FindRoute(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s is not documented: %w", req.Method, req.URL.Path, err)
+	}
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: pathParams,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+			IncludeResponseStatus: true,
+		},
+	}
+	if validateRequest {
+		if err := openapi3filter.ValidateRequest(req.Context(), input); err != nil {
+			return nil, fmt.Errorf("request does not match the OpenAPI document: %w", err)
+		}
+	}
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	err = openapi3filter.ValidateResponse(req.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 resp.Code,
+		Header:                 resp.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(resp.Body.Bytes())),
+		Options:                input.Options,
+	})
+	if err != nil {
+		return resp, fmt.Errorf("%d response of %s %s does not match the OpenAPI document: %w", resp.Code, req.Method, req.URL.Path, err)
+	}
+	return resp, nil
+}
@@ -2,13 +2,25 @@ package helpers
 
 import (
 	"billing_enginee/api/middleware"
+	"billing_enginee/api/openapi"
 	"billing_enginee/api/routes"
-	"billing_enginee/internal/model"
+	"billing_enginee/internal/auth"
+	"billing_enginee/internal/broker"
+	"billing_enginee/internal/health"
+	"billing_enginee/internal/metrics"
+	"billing_enginee/internal/notification"
 	"billing_enginee/internal/repository"
+	"billing_enginee/internal/tenant"
 	"billing_enginee/internal/usecase"
+	"billing_enginee/internal/webhook"
+	"billing_enginee/migrations"
 	"billing_enginee/pkg"
+	"context"
 	"database/sql"
+	"slices"
+	"time"
 
+	"github.com/getkin/kin-openapi/routers"
 	"github.com/gin-gonic/gin"
 	"github.com/onsi/gomega"
 	"gorm.io/gorm"
@@ -16,54 +28,175 @@ import (
 
 // TestEnvironment holds the components needed for testing
 type TestEnvironment struct {
-	DB              *gorm.DB
-	SQLDB           *sql.DB
-	Router          *gin.Engine
-	LoanRepo        repository.LoanRepository
-	CustomerRepo    repository.CustomerRepository
-	PaymentRepo     repository.PaymentRepository
-	LoanUsecase     usecase.LoanUsecase
-	PaymentUsecase  usecase.PaymentUsecase
-	CustomerUsecase usecase.CustomerUsecase
+	DB                  *gorm.DB
+	SQLDB               *sql.DB
+	UnitOfWork          pkg.UnitOfWork
+	Router              *gin.Engine
+	LoanRepo            repository.LoanRepository
+	CustomerRepo        repository.CustomerRepository
+	PaymentRepo         repository.PaymentRepository
+	LoanUsecase         usecase.LoanUsecase
+	PaymentUsecase      usecase.PaymentUsecase
+	CustomerUsecase     usecase.CustomerUsecase
+	ReportUsecase       usecase.ReportUsecase
+	NotificationUsecase usecase.NotificationUsecase
+	NotificationChannel *notification.MemoryChannel
+	WebhookRepo         repository.WebhookRepository
+	WebhookUsecase      usecase.WebhookUsecase
+	OutboxUsecase       usecase.OutboxUsecase
+	EventBroker         *broker.InProcessBroker
+	AuditUsecase        usecase.AuditUsecase
+	JobRunRepo          repository.JobRunRepository
+	JobRunUsecase       usecase.JobRunUsecase
+	SimulationClockRepo repository.SimulationClockRepository
+	SimulationUsecase   usecase.SimulationUsecase
+	Clock               *pkg.SimulatedClock
+	Health              *health.Checker
 }
 
-// InitializeTestEnvironment sets up the common test environment, including DB, router, and validators
+// TestReminderDaysBefore is the number of days before the due date reminders are sent in tests
+const TestReminderDaysBefore = 3
+
+// TestStatusUpdateBatchSize and TestStatusUpdateWorkers are small, so specs with a few loans read several
+// batches and update loans in parallel
+const (
+	TestStatusUpdateBatchSize = 2
+	TestStatusUpdateWorkers   = 4
+)
+
+// TestPrincipal is the caller of every request made through the router of InitializeTestEnvironment
+var TestPrincipal = &auth.Principal{Subject: "e2e-tests", Role: auth.RoleAdmin, Method: auth.MethodAPIKey, TenantID: tenant.DefaultTenantID}
+
+// InitializeTestEnvironment sets up the common test environment, including DB, router, and validators.
+// Requests are authenticated as TestPrincipal, so specs do not need to send credentials.
 func InitializeTestEnvironment() *TestEnvironment {
+	return InitializeTestEnvironmentWithAuthenticator(auth.NewStaticAuthenticator(TestPrincipal))
+}
+
+// InitializeTestEnvironmentWithAuthenticator sets up the test environment with the given authenticator
+func InitializeTestEnvironmentWithAuthenticator(authenticator auth.Authenticator) *TestEnvironment {
+	return InitializeTestEnvironmentWithTenants(authenticator, tenant.DefaultRegistry())
+}
+
+// InitializeTestEnvironmentWithTenants sets up the test environment with the given authenticator and tenants
+func InitializeTestEnvironmentWithTenants(authenticator auth.Authenticator, tenants tenant.Registry) *TestEnvironment {
 	// Initialize the validators to be used globally
 	pkg.InitValidators()
 
 	// Initialize the test database
 	db, sqlDB, _ := pkg.InitTestDB()
-	// Migrate the database schema for testing
-	err := db.AutoMigrate(&model.Customer{}, &model.Loan{}, &model.Payment{})
+	// Migrate the database schema with the migrations of production, pending ones only
+	_, err := migrations.NewMigrator(sqlDB, db.Dialector.Name()).Up(context.Background())
 	gomega.Expect(err).ToNot(gomega.HaveOccurred())
 
 	// Initialize repositories
 	loanRepo := repository.NewLoanRepository(db)
 	customerRepo := repository.NewCustomerRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	jobRunRepo := repository.NewJobRunRepository(db)
+	simulationClockRepo := repository.NewSimulationClockRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
 
-	// Initialize use cases
-	loanUsecase := usecase.NewLoanUsecase(loanRepo, customerRepo, paymentRepo)
-	paymentUsecase := usecase.NewPaymentUsecase(paymentRepo)
-	customerUsecase := usecase.NewCustomerUsecase(customerRepo)
+	// Initialize use cases, on a clock the specs can advance like in the simulation mode
+	clock := pkg.NewSimulatedClock()
+	uow := pkg.NewUnitOfWork(db)
+	webhookUsecase := usecase.NewWebhookUsecase(uow, webhookRepo, webhook.NewHTTPSender(5*time.Second))
+	eventBroker := broker.NewInProcessBroker()
+	eventBroker.Subscribe(webhookUsecase.HandleMessage)
+	outboxUsecase := usecase.NewOutboxUsecase(outboxRepo, eventBroker)
+	loanUsecase := usecase.NewLoanUsecase(uow, loanRepo, customerRepo, paymentRepo, outboxUsecase, tenants, clock)
+	notificationChannel := notification.NewMemoryChannel("memory")
+	notificationUsecase := usecase.NewNotificationUsecase(uow, paymentRepo, notificationRepo, notification.NewNotifier(notificationChannel), TestReminderDaysBefore)
+	paymentUsecase := usecase.NewPaymentUsecase(uow, paymentRepo, notificationUsecase, outboxUsecase, TestStatusUpdateBatchSize, TestStatusUpdateWorkers)
+	customerUsecase := usecase.NewCustomerUsecase(customerRepo, tenants)
+	reportUsecase := usecase.NewReportUsecase(reportRepo)
+	auditUsecase := usecase.NewAuditUsecase(auditRepo)
+	jobRunUsecase := usecase.NewJobRunUsecase(jobRunRepo, paymentUsecase, tenants, clock, pkg.NewJobLock(sqlDB, db.Dialector.Name()))
+	simulationUsecase := usecase.NewSimulationUsecase(clock, simulationClockRepo, tenants, jobRunUsecase, notificationUsecase)
 
-	// Setup router without running the server
-	router := gin.Default()
-	router.Use(middleware.TransactionMiddleware(db))
+	// Readiness checks like in main, without the scheduler the specs do not start
+	healthChecker := health.NewChecker()
+	healthChecker.AddCheck("database", health.DatabaseCheck(sqlDB))
+	healthChecker.AddCheck("schema", health.SchemaCheck(sqlDB, migrations.LatestVersion()))
+
+	// Setup router without running the server, the probes are registered before the middlewares like in main
+	router := newEngine()
+	routes.SetupHealthRoutes(router, healthChecker, sqlDB)
+	useMiddlewares(router, db, authenticator, tenants)
 	routes.SetupLoanRoutes(router, loanUsecase)
 	routes.SetupCustomerRoutes(router, customerUsecase)
+	routes.SetupReportRoutes(router, reportUsecase, tenants, clock)
+	routes.SetupWebhookRoutes(router, webhookUsecase)
+	routes.SetupAuditRoutes(router, auditUsecase)
+	routes.SetupJobRoutes(router, jobRunUsecase)
+	routes.SetupSimulationRoutes(router, simulationUsecase)
+	routes.SetupOpenAPIRoutes(router)
+	routes.SetupMetricsRoutes(router, metrics.NewRegistry(sqlDB, usecase.NewPortfolioMetricsSource(reportUsecase), tenants))
 
 	// Return a struct containing all components for flexible use in tests
 	return &TestEnvironment{
-		DB:              db,
-		SQLDB:           sqlDB,
-		Router:          router,
-		LoanRepo:        loanRepo,
-		CustomerRepo:    customerRepo,
-		PaymentRepo:     paymentRepo,
-		LoanUsecase:     loanUsecase,
-		PaymentUsecase:  paymentUsecase,
-		CustomerUsecase: customerUsecase,
+		DB:                  db,
+		SQLDB:               sqlDB,
+		UnitOfWork:          uow,
+		Router:              router,
+		LoanRepo:            loanRepo,
+		CustomerRepo:        customerRepo,
+		PaymentRepo:         paymentRepo,
+		LoanUsecase:         loanUsecase,
+		PaymentUsecase:      paymentUsecase,
+		CustomerUsecase:     customerUsecase,
+		ReportUsecase:       reportUsecase,
+		NotificationUsecase: notificationUsecase,
+		NotificationChannel: notificationChannel,
+		WebhookRepo:         webhookRepo,
+		WebhookUsecase:      webhookUsecase,
+		OutboxUsecase:       outboxUsecase,
+		EventBroker:         eventBroker,
+		AuditUsecase:        auditUsecase,
+		JobRunRepo:          jobRunRepo,
+		JobRunUsecase:       jobRunUsecase,
+		SimulationClockRepo: simulationClockRepo,
+		SimulationUsecase:   simulationUsecase,
+		Clock:               clock,
+		Health:              healthChecker,
 	}
 }
+
+// NewRouter returns a router with the middlewares of the API and no routes, for specs that wire their own use cases
+func NewRouter(db *gorm.DB, authenticator auth.Authenticator, tenants tenant.Registry) *gin.Engine {
+	router := newEngine()
+	useMiddlewares(router, db, authenticator, tenants)
+	return router
+}
+
+func newEngine() *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Recovery())
+	return router
+}
+
+// openAPIRouter returns the router of the OpenAPI document the requests are validated against
+func openAPIRouter() routers.Router {
+	doc, err := openapi.Load()
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	router, err := openapi.NewRouter(doc)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	return router
+}
+
+// useMiddlewares applies the middlewares of main, routes registered before it do not go through them
+func useMiddlewares(router *gin.Engine, db *gorm.DB, authenticator auth.Authenticator, tenants tenant.Registry) {
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.TracingMiddleware())
+	router.Use(middleware.RequestLoggerMiddleware())
+	router.Use(middleware.MetricsMiddleware())
+	router.Use(middleware.AuditContextMiddleware())
+	router.Use(middleware.ErrorMiddleware())
+	router.Use(middleware.AuthMiddleware(authenticator, tenants))
+	router.Use(middleware.RequestValidationMiddleware(openAPIRouter()))
+	router.Use(middleware.TransactionMiddleware(db, slices.Concat(routes.JobRoutesOutsideTransaction, routes.SimulationRoutesOutsideTransaction)...))
+}
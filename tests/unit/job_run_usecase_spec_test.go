@@ -0,0 +1,248 @@
+package unit_test
+
+import (
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// failingPaymentUsecase fails every payment status update before it updates a loan
+type failingPaymentUsecase struct {
+	usecase.PaymentUsecase
+}
+
+func (u *failingPaymentUsecase) RunPaymentStatusUpdate(ctx context.Context, currentDate time.Time) (*usecase.PaymentStatusUpdate, error) {
+	return &usecase.PaymentStatusUpdate{}, errors.New("error fetching payments: connection refused")
+}
+
+// concurrentPaymentUsecase re-runs the job of the tenant, and runs the one of another tenant, while its first
+// run goes on
+type concurrentPaymentUsecase struct {
+	usecase.PaymentUsecase
+	jobRunUsecase usecase.JobRunUsecase
+	otherTenant   string
+	started       bool
+	err           error
+	otherErr      error
+}
+
+func (u *concurrentPaymentUsecase) RunPaymentStatusUpdate(ctx context.Context, currentDate time.Time) (*usecase.PaymentStatusUpdate, error) {
+	if u.started {
+		return &usecase.PaymentStatusUpdate{}, nil
+	}
+	u.started = true
+	_, u.err = u.jobRunUsecase.RerunUpdatePaymentStatus(ctx, currentDate)
+	_, u.otherErr = u.jobRunUsecase.RunUpdatePaymentStatus(pkg.NewTenantContext(u.otherTenant), currentDate, enum.JobRunTriggerManual)
+	return &usecase.PaymentStatusUpdate{}, nil
+}
+
+var _ = ginkgo.Describe("Job Run Usecase", func() {
+	var env *helpers.MemoryEnvironment
+	var ctx context.Context
+	var today time.Time
+
+	// date returns the business date of the default tenant the given number of days from today, as recorded
+	date := func(days int) string {
+		return today.AddDate(0, 0, days).Format(time.DateOnly)
+	}
+	// businessDates returns the business dates of the runs, in their order
+	businessDates := func(ctx context.Context) []string {
+		runs, err := env.JobRunUsecase.GetRuns(ctx, usecase.MaxJobRuns)
+		Expect(err).ToNot(HaveOccurred())
+		dates := make([]string, len(runs))
+		for i, run := range runs {
+			dates[i] = run.BusinessDate().Format(time.DateOnly)
+		}
+		return dates
+	}
+
+	ginkgo.BeforeEach(func() {
+		env = helpers.InitializeMemoryEnvironment()
+		ctx = pkg.NewTenantContext(tenant.DefaultTenantID)
+
+		defaultTenant, err := tenant.DefaultRegistry().Get(tenant.DefaultTenantID)
+		Expect(err).ToNot(HaveOccurred())
+		today = defaultTenant.Now(env.Clock)
+
+		_, err = env.LoanUsecase.CreateLoan(ctx, 1, "John Doe", "johndoe@example.com", "", 5000000, 50, 10)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	ginkgo.Describe("RunUpdatePaymentStatus", func() {
+		ginkgo.It("should record a succeeded run with the installments it processed", func() {
+			// The first installment is due in a week, it turns outstanding the day after
+			run, err := env.JobRunUsecase.RunUpdatePaymentStatus(ctx, today.AddDate(0, 0, 1), enum.JobRunTriggerSchedule)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(run.GetID()).ToNot(BeZero())
+			Expect(run.Status()).To(Equal("succeeded"))
+			Expect(run.Trigger()).To(Equal("schedule"))
+			Expect(run.ItemsProcessed()).To(Equal(1))
+			Expect(run.FinishedAt()).ToNot(BeNil())
+			Expect(businessDates(ctx)).To(Equal([]string{date(1)}))
+		})
+
+		ginkgo.It("should record a partially failed run with the error of its loans", func() {
+			env.Events.FailOn(enum.EventTypeInstallmentOverdue)
+
+			run, err := env.JobRunUsecase.RunUpdatePaymentStatus(ctx, today.AddDate(0, 0, 8), enum.JobRunTriggerManual)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(run.Status()).To(Equal("partially_failed"))
+			Expect(run.ItemsProcessed()).To(Equal(2))
+			Expect(run.ItemsFailed()).To(Equal(2))
+			Expect(run.ErrorMessage()).To(ContainSubstring("installment.overdue"))
+
+			runs, err := env.JobRunUsecase.GetRuns(ctx, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runs[0].Status()).To(Equal("partially_failed"))
+		})
+
+		ginkgo.It("should record a failed run with its error", func() {
+			jobRunUsecase := usecase.NewJobRunUsecase(env.JobRunRepo, &failingPaymentUsecase{}, tenant.DefaultRegistry(), env.Clock, pkg.NewLocalJobLock())
+
+			run, err := jobRunUsecase.RunUpdatePaymentStatus(ctx, today, enum.JobRunTriggerManual)
+			Expect(err).To(MatchError(ContainSubstring("connection refused")))
+			Expect(run.Status()).To(Equal("failed"))
+			Expect(run.ErrorMessage()).To(ContainSubstring("connection refused"))
+
+			runs, err := env.JobRunUsecase.GetRuns(ctx, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runs[0].Status()).To(Equal("failed"))
+		})
+	})
+
+	ginkgo.Describe("RerunUpdatePaymentStatus", func() {
+		ginkgo.It("should not run while the job of the tenant runs", func() {
+			paymentUsecase := &concurrentPaymentUsecase{otherTenant: "other"}
+			paymentUsecase.jobRunUsecase = usecase.NewJobRunUsecase(env.JobRunRepo, paymentUsecase, tenant.DefaultRegistry(), env.Clock, pkg.NewLocalJobLock())
+
+			run, err := paymentUsecase.jobRunUsecase.RunUpdatePaymentStatus(ctx, today, enum.JobRunTriggerSchedule)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(run.Status()).To(Equal("succeeded"))
+			Expect(paymentUsecase.err).To(MatchError(usecase.ErrJobRunning))
+			// The job of another tenant is not held up
+			Expect(paymentUsecase.otherErr).ToNot(HaveOccurred())
+			Expect(businessDates(ctx)).To(Equal([]string{date(0)}))
+		})
+
+		ginkgo.It("should run again as of a past business date", func() {
+			run, err := env.JobRunUsecase.RerunUpdatePaymentStatus(ctx, today.AddDate(0, 0, -3))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(run.Trigger()).To(Equal("manual"))
+			Expect(run.BusinessDate().Format(time.DateOnly)).To(Equal(date(-3)))
+		})
+
+		ginkgo.It("should reject a business date after today", func() {
+			_, err := env.JobRunUsecase.RerunUpdatePaymentStatus(ctx, today.AddDate(0, 0, 1))
+			Expect(err).To(MatchError(usecase.ErrFutureBusinessDate))
+			Expect(businessDates(ctx)).To(BeEmpty())
+		})
+	})
+
+	ginkgo.Describe("CatchUpUpdatePaymentStatus", func() {
+		ginkgo.It("should only run today when the job never succeeded", func() {
+			runs, err := env.JobRunUsecase.CatchUpUpdatePaymentStatus(ctx, 7)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runs).To(HaveLen(1))
+			Expect(runs[0].Trigger()).To(Equal("catch_up"))
+			Expect(businessDates(ctx)).To(Equal([]string{date(0)}))
+		})
+
+		ginkgo.It("should run every business date missed since the last successful run", func() {
+			_, err := env.JobRunUsecase.RunUpdatePaymentStatus(ctx, today, enum.JobRunTriggerSchedule)
+			Expect(err).ToNot(HaveOccurred())
+			env.Clock.Advance(3 * 24 * time.Hour)
+
+			runs, err := env.JobRunUsecase.CatchUpUpdatePaymentStatus(ctx, 7)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runs).To(HaveLen(3))
+			Expect(businessDates(ctx)).To(Equal([]string{date(3), date(2), date(1), date(0)}))
+		})
+
+		ginkgo.It("should do nothing when today already succeeded", func() {
+			_, err := env.JobRunUsecase.RunUpdatePaymentStatus(ctx, today, enum.JobRunTriggerSchedule)
+			Expect(err).ToNot(HaveOccurred())
+
+			runs, err := env.JobRunUsecase.CatchUpUpdatePaymentStatus(ctx, 7)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runs).To(BeEmpty())
+		})
+
+		ginkgo.It("should only run the latest business dates when too many were missed", func() {
+			_, err := env.JobRunUsecase.RunUpdatePaymentStatus(ctx, today, enum.JobRunTriggerSchedule)
+			Expect(err).ToNot(HaveOccurred())
+			env.Clock.Advance(10 * 24 * time.Hour)
+
+			runs, err := env.JobRunUsecase.CatchUpUpdatePaymentStatus(ctx, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runs).To(HaveLen(2))
+			Expect(businessDates(ctx)).To(Equal([]string{date(10), date(9), date(0)}))
+		})
+
+		ginkgo.It("should carry on past the business dates where loans failed", func() {
+			_, err := env.JobRunUsecase.RunUpdatePaymentStatus(ctx, today, enum.JobRunTriggerSchedule)
+			Expect(err).ToNot(HaveOccurred())
+			env.Clock.Advance(10 * 24 * time.Hour)
+			env.Events.FailOn(enum.EventTypeInstallmentOverdue)
+
+			// The first installment is due in a week, it turns pending on the eighth day
+			runs, err := env.JobRunUsecase.CatchUpUpdatePaymentStatus(ctx, 30)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runs).To(HaveLen(10))
+			Expect(runs[6].Status()).To(Equal("succeeded"))
+			Expect(runs[7].Status()).To(Equal("partially_failed"))
+			Expect(runs[9].Status()).To(Equal("partially_failed"))
+
+			// The next catch-up starts after the partially failed dates
+			runs, err = env.JobRunUsecase.CatchUpUpdatePaymentStatus(ctx, 30)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runs).To(BeEmpty())
+		})
+
+		ginkgo.It("should not catch up while another run of the tenant holds the job lock", func() {
+			jobLock := pkg.NewLocalJobLock()
+			jobRunUsecase := usecase.NewJobRunUsecase(env.JobRunRepo, env.PaymentUsecase, tenant.DefaultRegistry(), env.Clock, jobLock)
+			unlock, acquired, err := jobLock.TryLock(ctx, "update_payment_status:"+tenant.DefaultTenantID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(acquired).To(BeTrue())
+			defer unlock()
+
+			_, err = jobRunUsecase.CatchUpUpdatePaymentStatus(ctx, 7)
+			Expect(err).To(MatchError(usecase.ErrJobRunning))
+			Expect(businessDates(ctx)).To(BeEmpty())
+		})
+
+		ginkgo.It("should stop at the first business date that fails", func() {
+			jobRunUsecase := usecase.NewJobRunUsecase(env.JobRunRepo, &failingPaymentUsecase{}, tenant.DefaultRegistry(), env.Clock, pkg.NewLocalJobLock())
+			env.Clock.Advance(3 * 24 * time.Hour)
+
+			runs, err := jobRunUsecase.CatchUpUpdatePaymentStatus(ctx, 30)
+			Expect(err).To(MatchError(ContainSubstring("failed to catch up payment status update of " + date(3))))
+			Expect(runs).To(HaveLen(1))
+			Expect(runs[0].Status()).To(Equal("failed"))
+		})
+	})
+
+	ginkgo.Describe("GetRuns", func() {
+		ginkgo.It("should reject an invalid limit", func() {
+			_, err := env.JobRunUsecase.GetRuns(ctx, 0)
+			Expect(err).To(MatchError(usecase.ErrInvalidJobRunLimit))
+			_, err = env.JobRunUsecase.GetRuns(ctx, usecase.MaxJobRuns+1)
+			Expect(err).To(MatchError(usecase.ErrInvalidJobRunLimit))
+		})
+
+		ginkgo.It("should only list the runs of the tenant of the context", func() {
+			_, err := env.JobRunUsecase.RunUpdatePaymentStatus(ctx, today, enum.JobRunTriggerSchedule)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(businessDates(pkg.NewTenantContext("other"))).To(BeEmpty())
+		})
+	})
+})
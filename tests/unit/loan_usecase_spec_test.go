@@ -0,0 +1,170 @@
+package unit_test
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"context"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Loan Usecase", func() {
+	var env *helpers.MemoryEnvironment
+	var loanUsecase usecase.LoanUsecase
+	var ctx context.Context
+
+	ginkgo.BeforeEach(func() {
+		env = helpers.InitializeMemoryEnvironment()
+		loanUsecase = env.LoanUsecase
+		ctx = pkg.NewTenantContext(tenant.DefaultTenantID)
+	})
+
+	createLoan := func(customerID uint, termWeeks int) *usecase.LoanResponse {
+		loan, err := loanUsecase.CreateLoan(ctx, customerID, "John Doe", "johndoe@example.com", "", 5000000, termWeeks, 10)
+		Expect(err).ToNot(HaveOccurred())
+		return loan
+	}
+
+	paymentStatuses := func(loanID uint) []string {
+		customer, err := env.CustomerRepo.GetCustomerByExternalID(ctx, 1)
+		Expect(err).ToNot(HaveOccurred())
+		var statuses []string
+		for _, loan := range *customer.Loans() {
+			if loan.GetID() == loanID {
+				for _, payment := range *loan.GetPayments() {
+					statuses = append(statuses, payment.Status())
+				}
+			}
+		}
+		return statuses
+	}
+
+	ginkgo.Describe("CreateLoan", func() {
+		ginkgo.It("should create the customer, the loan and its weekly installments", func() {
+			loan := createLoan(1, 50)
+			Expect(loan.TotalAmount).To(BeEquivalentTo(5500000))
+			Expect(loan.OutstandingAmount).To(BeEquivalentTo(110000))
+			Expect(loan.Week).To(Equal(1))
+
+			customer, err := env.CustomerRepo.GetCustomerByExternalID(ctx, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(customer.Email()).To(Equal("johndoe@example.com"))
+
+			statuses := paymentStatuses(loan.LoanID)
+			Expect(statuses).To(HaveLen(50))
+			Expect(statuses[0]).To(Equal("outstanding"))
+			Expect(statuses[1:]).To(HaveEach("scheduled"))
+			Expect(env.Events.Types()).To(Equal([]string{"loan.created"}))
+		})
+
+		ginkgo.It("should lend again to an existing customer", func() {
+			first := createLoan(1, 50)
+			second := createLoan(1, 10)
+			Expect(second.LoanID).ToNot(Equal(first.LoanID))
+
+			customer, err := env.CustomerRepo.GetCustomerByExternalID(ctx, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*customer.Loans()).To(HaveLen(2))
+		})
+
+		ginkgo.It("should not create a loan without a tenant", func() {
+			_, err := loanUsecase.CreateLoan(context.Background(), 1, "John Doe", "johndoe@example.com", "", 5000000, 50, 10)
+			Expect(err).To(MatchError(ContainSubstring(pkg.ErrNoTenant.Error())))
+		})
+
+		ginkgo.It("should roll back the customer and the loan when the event cannot be published", func() {
+			env.Events.FailOn(enum.EventTypeLoanCreated)
+
+			_, err := loanUsecase.CreateLoan(ctx, 1, "John Doe", "johndoe@example.com", "", 5000000, 50, 10)
+			Expect(err).To(MatchError(ContainSubstring("failed to publish loan created event")))
+
+			_, err = env.CustomerRepo.GetCustomerByExternalID(ctx, 1)
+			Expect(err).To(MatchError(entity.ErrCustomerNotFound))
+			_, err = env.LoanRepo.GetLoanByID(ctx, 1)
+			Expect(err).To(MatchError(entity.ErrLoanNotFound))
+		})
+	})
+
+	ginkgo.Describe("GetOutstanding", func() {
+		ginkgo.It("should return the first installment of a new loan", func() {
+			loan := createLoan(1, 50)
+
+			outstanding, err := loanUsecase.GetOutstanding(ctx, loan.LoanID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(outstanding.OutstandingAmount).To(BeEquivalentTo(110000))
+			Expect(outstanding.WeeksOutstanding).To(Equal(1))
+		})
+
+		ginkgo.It("should not find the loans of another tenant", func() {
+			loan := createLoan(1, 50)
+
+			_, err := loanUsecase.GetOutstanding(pkg.NewTenantContext("brand-b"), loan.LoanID)
+			Expect(err).To(MatchError(entity.ErrLoanNotFound))
+		})
+
+		ginkgo.It("should not find any loan without a tenant unless the context opts in to every tenant", func() {
+			loan := createLoan(1, 50)
+
+			_, err := loanUsecase.GetOutstanding(context.Background(), loan.LoanID)
+			Expect(err).To(MatchError(entity.ErrLoanNotFound))
+
+			_, err = loanUsecase.GetOutstanding(pkg.WithAllTenants(context.Background()), loan.LoanID)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	ginkgo.Describe("MakePayment", func() {
+		ginkgo.It("should pay the outstanding installment and make the next one outstanding", func() {
+			loan := createLoan(1, 50)
+
+			Expect(loanUsecase.MakePayment(ctx, loan.LoanID, 110000)).To(Succeed())
+
+			statuses := paymentStatuses(loan.LoanID)
+			Expect(statuses[:3]).To(Equal([]string{"paid", "outstanding", "scheduled"}))
+			Expect(env.Events.Types()).To(Equal([]string{"loan.created", "payment.received"}))
+		})
+
+		ginkgo.It("should reject an amount that does not match the outstanding balance and change nothing", func() {
+			loan := createLoan(1, 50)
+
+			err := loanUsecase.MakePayment(ctx, loan.LoanID, 100000)
+			Expect(err).To(MatchError(entity.ErrPaymentAmountMismatch))
+			Expect(paymentStatuses(loan.LoanID)[:2]).To(Equal([]string{"outstanding", "scheduled"}))
+		})
+
+		ginkgo.It("should roll back the paid installment when the event cannot be published", func() {
+			loan := createLoan(1, 50)
+			env.Events.FailOn(enum.EventTypePaymentReceived)
+
+			err := loanUsecase.MakePayment(ctx, loan.LoanID, 110000)
+			Expect(err).To(MatchError(ContainSubstring("failed to publish payment received event")))
+			Expect(paymentStatuses(loan.LoanID)[:2]).To(Equal([]string{"outstanding", "scheduled"}))
+		})
+
+		ginkgo.It("should close the loan once every installment is paid", func() {
+			loan := createLoan(1, 2)
+
+			Expect(loanUsecase.MakePayment(ctx, loan.LoanID, 2750000)).To(Succeed())
+			Expect(loanUsecase.MakePayment(ctx, loan.LoanID, 2750000)).To(Succeed())
+
+			closed, err := env.LoanRepo.GetLoanByID(ctx, loan.LoanID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(closed.GetStatus()).To(Equal("close"))
+			Expect(paymentStatuses(loan.LoanID)).To(Equal([]string{"paid", "paid"}))
+			Expect(env.Events.Types()).To(Equal([]string{"loan.created", "payment.received", "payment.received", "loan.closed"}))
+
+			_, err = loanUsecase.GetOutstanding(ctx, loan.LoanID)
+			Expect(err).To(MatchError(entity.ErrNoOutstandingPayment))
+		})
+
+		ginkgo.It("should not find a loan that does not exist", func() {
+			err := loanUsecase.MakePayment(ctx, 42, 110000)
+			Expect(err).To(MatchError(entity.ErrLoanNotFound))
+		})
+	})
+})
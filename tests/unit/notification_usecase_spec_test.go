@@ -0,0 +1,133 @@
+package unit_test
+
+import (
+	"billing_enginee/internal/entity"
+	"billing_enginee/internal/notification"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// failingChannel fails every message it is given and counts the attempts
+type failingChannel struct {
+	attempts atomic.Int32
+}
+
+func (ch *failingChannel) Name() string {
+	return "failing"
+}
+
+func (ch *failingChannel) Send(ctx context.Context, msg notification.Message) error {
+	ch.attempts.Add(1)
+	return errors.New("provider is down")
+}
+
+var _ = ginkgo.Describe("Notification Usecase", func() {
+	var env *helpers.MemoryEnvironment
+	var ctx context.Context
+	var reminderDate time.Time
+
+	ginkgo.BeforeEach(func() {
+		env = helpers.InitializeMemoryEnvironment()
+		ctx = pkg.NewTenantContext(tenant.DefaultTenantID)
+
+		_, err := env.LoanUsecase.CreateLoan(ctx, 1, "John Doe", "johndoe@example.com", "", 5000000, 50, 10)
+		Expect(err).ToNot(HaveOccurred())
+		// The first installment is due in 7 days
+		reminderDate = time.Now().AddDate(0, 0, 7-helpers.TestReminderDaysBefore)
+	})
+
+	ginkgo.It("should only send the queued reminders when they are delivered", func() {
+		Expect(env.NotificationUsecase.QueueUpcomingReminders(ctx, reminderDate)).To(Succeed())
+		Expect(env.NotificationChannel.Messages()).To(BeEmpty())
+
+		Expect(env.NotificationUsecase.DeliverPending(ctx, time.Now())).To(Succeed())
+		Expect(env.NotificationChannel.Messages()).To(HaveLen(1))
+
+		// A sent notification is not sent again
+		Expect(env.NotificationUsecase.DeliverPending(ctx, time.Now())).To(Succeed())
+		Expect(env.NotificationChannel.Messages()).To(HaveLen(1))
+	})
+
+	ginkgo.It("should drop the notices queued by a rolled back change", func() {
+		payments, err := env.PaymentRepo.GetPaymentsDueOnDateWithStatus(ctx, time.Now().AddDate(0, 0, 7), []string{"outstanding"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payments).To(HaveLen(1))
+
+		err = env.Store.Do(ctx, func(ctx context.Context) error {
+			Expect(env.NotificationUsecase.QueuePendingInstallmentNotices(ctx, []uint{payments[0].GetID()})).To(Succeed())
+			return errors.New("status update failed")
+		})
+		Expect(err).To(HaveOccurred())
+
+		Expect(env.NotificationUsecase.DeliverPending(ctx, time.Now())).To(Succeed())
+		Expect(env.NotificationChannel.Messages()).To(BeEmpty())
+	})
+
+	ginkgo.It("should retry a failed notification with backoff until it gives up", func() {
+		channel := &failingChannel{}
+		notificationUsecase := usecase.NewNotificationUsecase(env.Store, env.PaymentRepo, env.NotificationRepo, notification.NewNotifier(channel), helpers.TestReminderDaysBefore)
+		Expect(notificationUsecase.QueueUpcomingReminders(ctx, reminderDate)).To(Succeed())
+
+		now := time.Now()
+		Expect(notificationUsecase.DeliverPending(ctx, now)).To(Succeed())
+		Expect(channel.attempts.Load()).To(BeEquivalentTo(1))
+
+		// The next attempt waits for the backoff
+		Expect(notificationUsecase.DeliverPending(ctx, now)).To(Succeed())
+		Expect(channel.attempts.Load()).To(BeEquivalentTo(1))
+
+		for i := 0; i < entity.MaxNotificationAttempts+2; i++ {
+			now = now.Add(24 * time.Hour)
+			Expect(notificationUsecase.DeliverPending(ctx, now)).To(Succeed())
+		}
+		Expect(channel.attempts.Load()).To(BeEquivalentTo(entity.MaxNotificationAttempts))
+
+		due, err := env.NotificationRepo.GetDueNotifications(ctx, now, 10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(due).To(BeEmpty())
+	})
+
+	ginkgo.It("should give up on an SMTP server that never answers", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		defer listener.Close()
+		go func() {
+			// Accept connections without ever greeting the client
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+			}
+		}()
+
+		host, port, err := net.SplitHostPort(listener.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+		channel := notification.NewSMTPChannel(notification.SMTPConfig{
+			Host:    host,
+			Port:    port,
+			From:    "billing@example.com",
+			Timeout: 200 * time.Millisecond,
+		})
+
+		started := time.Now()
+		err = channel.Send(ctx, notification.Message{
+			Recipient: notification.Recipient{Email: "johndoe@example.com"},
+			Subject:   "Installment 1",
+			Body:      "Your installment is due",
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(time.Since(started)).To(BeNumerically("<", 5*time.Second))
+	})
+})
@@ -0,0 +1,170 @@
+package unit_test
+
+import (
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/notification"
+	"billing_enginee/internal/repository"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// payingPaymentRepository pays an installment of every loan it pages through, like a payment made while the
+// status update runs
+type payingPaymentRepository struct {
+	repository.PaymentRepository
+	pay func(loanID uint)
+}
+
+func (r *payingPaymentRepository) GetLoanIDsWithPaymentsDueBeforeDate(ctx context.Context, nextWeek time.Time, afterLoanID uint, loanLimit int) ([]uint, error) {
+	loanIDs, err := r.PaymentRepository.GetLoanIDsWithPaymentsDueBeforeDate(ctx, nextWeek, afterLoanID, loanLimit)
+	for _, loanID := range loanIDs {
+		r.pay(loanID)
+	}
+	return loanIDs, err
+}
+
+var _ = ginkgo.Describe("Payment Usecase", func() {
+	var env *helpers.MemoryEnvironment
+	var ctx context.Context
+	var loanID uint
+	var createdAt time.Time
+
+	ginkgo.BeforeEach(func() {
+		env = helpers.InitializeMemoryEnvironment()
+		ctx = pkg.NewTenantContext(tenant.DefaultTenantID)
+
+		createdAt = time.Now()
+		loan, err := env.LoanUsecase.CreateLoan(ctx, 1, "John Doe", "johndoe@example.com", "", 5000000, 50, 10)
+		Expect(err).ToNot(HaveOccurred())
+		loanID = loan.LoanID
+	})
+
+	// paymentStatuses returns the statuses of the first installments of the loan, by week
+	paymentStatuses := func(weeks int) []string {
+		customer, err := env.CustomerRepo.GetCustomerByExternalID(ctx, 1)
+		Expect(err).ToNot(HaveOccurred())
+		var statuses []string
+		for _, loan := range *customer.Loans() {
+			if loan.GetID() == loanID {
+				for _, payment := range (*loan.GetPayments())[:weeks] {
+					statuses = append(statuses, payment.Status())
+				}
+			}
+		}
+		return statuses
+	}
+
+	// sentNotifications sends the queued notifications and returns the messages sent so far
+	sentNotifications := func() []notification.Message {
+		Expect(env.NotificationUsecase.DeliverPending(ctx, time.Now())).To(Succeed())
+		return env.NotificationChannel.Messages()
+	}
+
+	ginkgo.Describe("UpdatePaymentStatus", func() {
+		ginkgo.It("should leave the installments alone before the first one is due", func() {
+			Expect(env.PaymentUsecase.UpdatePaymentStatus(ctx, createdAt)).To(Succeed())
+
+			Expect(paymentStatuses(3)).To(Equal([]string{"outstanding", "scheduled", "scheduled"}))
+			Expect(sentNotifications()).To(BeEmpty())
+		})
+
+		ginkgo.It("should mark missed installments pending, make the next one outstanding and notify the customer", func() {
+			Expect(env.PaymentUsecase.UpdatePaymentStatus(ctx, createdAt.AddDate(0, 0, 8))).To(Succeed())
+
+			Expect(paymentStatuses(3)).To(Equal([]string{"pending", "outstanding", "scheduled"}))
+			Expect(env.Events.Types()).To(Equal([]string{"loan.created", "installment.overdue"}))
+
+			messages := sentNotifications()
+			Expect(messages).To(HaveLen(1))
+			Expect(messages[0].Recipient.Email).To(Equal("johndoe@example.com"))
+		})
+
+		ginkgo.It("should keep marking installments pending on later runs", func() {
+			Expect(env.PaymentUsecase.UpdatePaymentStatus(ctx, createdAt.AddDate(0, 0, 8))).To(Succeed())
+			Expect(env.PaymentUsecase.UpdatePaymentStatus(ctx, createdAt.AddDate(0, 0, 15))).To(Succeed())
+
+			Expect(paymentStatuses(4)).To(Equal([]string{"pending", "pending", "outstanding", "scheduled"}))
+		})
+
+		ginkgo.It("should not change paid installments", func() {
+			Expect(env.LoanUsecase.MakePayment(ctx, loanID, 110000)).To(Succeed())
+
+			Expect(env.PaymentUsecase.UpdatePaymentStatus(ctx, createdAt.AddDate(0, 0, 8))).To(Succeed())
+			Expect(paymentStatuses(3)).To(Equal([]string{"paid", "outstanding", "scheduled"}))
+			Expect(sentNotifications()).To(BeEmpty())
+		})
+
+		ginkgo.It("should not overwrite an installment paid after its loan was read", func() {
+			paymentRepo := &payingPaymentRepository{
+				PaymentRepository: env.PaymentRepo,
+				pay: func(loanID uint) {
+					Expect(env.LoanUsecase.MakePayment(ctx, loanID, 110000)).To(Succeed())
+				},
+			}
+			notificationUsecase := usecase.NewNotificationUsecase(env.Store, env.PaymentRepo, env.NotificationRepo, notification.NewNotifier(env.NotificationChannel), helpers.TestReminderDaysBefore)
+			paymentUsecase := usecase.NewPaymentUsecase(env.Store, paymentRepo, notificationUsecase, env.Events, helpers.TestStatusUpdateBatchSize, helpers.TestStatusUpdateWorkers)
+
+			update, err := paymentUsecase.RunPaymentStatusUpdate(ctx, createdAt.AddDate(0, 0, 8))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(update.ItemsProcessed).To(Equal(1))
+			Expect(update.TurnedPending).To(BeZero())
+			Expect(paymentStatuses(3)).To(Equal([]string{"paid", "outstanding", "scheduled"}))
+		})
+
+		ginkgo.It("should roll back the status of an installment whose overdue event cannot be published", func() {
+			env.Events.FailOn(enum.EventTypeInstallmentOverdue)
+
+			update, err := env.PaymentUsecase.RunPaymentStatusUpdate(ctx, createdAt.AddDate(0, 0, 8))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(update.Err()).To(MatchError(ContainSubstring("failed to publish installment overdue event")))
+			Expect(paymentStatuses(2)).To(Equal([]string{"outstanding", "scheduled"}))
+		})
+
+		ginkgo.It("should update every loan over several batches", func() {
+			for customerID := uint(2); customerID <= 5; customerID++ {
+				_, err := env.LoanUsecase.CreateLoan(ctx, customerID, "Jane Doe", fmt.Sprintf("janedoe%d@example.com", customerID), "", 5000000, 50, 10)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			update, err := env.PaymentUsecase.RunPaymentStatusUpdate(ctx, createdAt.AddDate(0, 0, 8))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(update.LoansProcessed).To(Equal(5))
+			Expect(update.ItemsProcessed).To(Equal(10))
+			Expect(update.TurnedPending).To(Equal(5))
+			Expect(update.LoansFailed).To(BeZero())
+			Expect(sentNotifications()).To(HaveLen(5))
+		})
+
+		ginkgo.It("should carry on past a loan that fails and report it", func() {
+			// The second loan starts 5 days later, its first installment is not overdue yet
+			env.Clock.Advance(5 * 24 * time.Hour)
+			loan, err := env.LoanUsecase.CreateLoan(ctx, 2, "Jane Doe", "janedoe@example.com", "", 5000000, 50, 10)
+			Expect(err).ToNot(HaveOccurred())
+			env.Events.FailOn(enum.EventTypeInstallmentOverdue)
+
+			update, err := env.PaymentUsecase.RunPaymentStatusUpdate(ctx, createdAt.AddDate(0, 0, 8))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(update.Err()).To(MatchError(ContainSubstring(fmt.Sprintf("failed to update the payments of 1 of 2 loans, first loan %d", loanID))))
+			Expect(update.LoansProcessed).To(Equal(2))
+			Expect(update.LoansFailed).To(Equal(1))
+			Expect(update.ItemsProcessed).To(Equal(3))
+			Expect(update.ItemsFailed).To(Equal(2))
+			Expect(update.Failures).To(HaveLen(1))
+			Expect(update.Failures[0].LoanID).To(Equal(loanID))
+
+			// The failed loan is left unchanged as a whole, the other one is updated
+			Expect(paymentStatuses(2)).To(Equal([]string{"outstanding", "scheduled"}))
+			next, err := env.PaymentRepo.GetNextPayment(ctx, loan.LoanID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(next.Status()).To(Equal("outstanding"))
+		})
+	})
+})
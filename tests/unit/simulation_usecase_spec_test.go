@@ -0,0 +1,132 @@
+package unit_test
+
+import (
+	"billing_enginee/internal/entity/enum"
+	"billing_enginee/internal/notification"
+	"billing_enginee/internal/tenant"
+	"billing_enginee/internal/usecase"
+	"billing_enginee/pkg"
+	"billing_enginee/tests/helpers"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// laterFailingPaymentUsecase runs the payment status updates of the first days, then fails the next one
+type laterFailingPaymentUsecase struct {
+	usecase.PaymentUsecase
+	days int
+}
+
+func (u *laterFailingPaymentUsecase) RunPaymentStatusUpdate(ctx context.Context, currentDate time.Time) (*usecase.PaymentStatusUpdate, error) {
+	if u.days == 0 {
+		return &usecase.PaymentStatusUpdate{}, errors.New("error fetching payments: connection refused")
+	}
+	u.days--
+	return u.PaymentUsecase.RunPaymentStatusUpdate(ctx, currentDate)
+}
+
+var _ = ginkgo.Describe("Simulation Usecase", func() {
+	var env *helpers.MemoryEnvironment
+	var ctx context.Context
+	var loanID uint
+
+	ginkgo.BeforeEach(func() {
+		env = helpers.InitializeMemoryEnvironment()
+		ctx = pkg.NewTenantContext(tenant.DefaultTenantID)
+
+		loan, err := env.LoanUsecase.CreateLoan(ctx, 1, "John Doe", "johndoe@example.com", "", 5000000, 50, 10)
+		Expect(err).ToNot(HaveOccurred())
+		loanID = loan.LoanID
+	})
+
+	ginkgo.Describe("Advance", func() {
+		ginkgo.It("should run the daily jobs for every day skipped and move the clock", func() {
+			clock, err := env.SimulationUsecase.Advance(ctx, 8)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(clock.DaysAhead).To(Equal(8))
+			Expect(env.Clock.Offset()).To(Equal(8 * 24 * time.Hour))
+
+			// The reminder of the first installment was queued on its day, then it turned pending
+			Expect(env.NotificationUsecase.DeliverPending(ctx, time.Now())).To(Succeed())
+			Expect(env.NotificationChannel.Messages()).To(HaveLen(2))
+			Expect(env.Events.Types()).To(Equal([]string{"loan.created", "installment.overdue"}))
+
+			outstanding, err := env.LoanUsecase.GetOutstanding(ctx, loanID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(outstanding.WeeksOutstanding).To(Equal(1))
+		})
+
+		ginkgo.It("should store the clock for the next process to restore", func() {
+			_, err := env.SimulationUsecase.Advance(ctx, 8)
+			Expect(err).ToNot(HaveOccurred())
+
+			offset, err := env.SimulationClockRepo.GetOffset(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(offset).To(Equal(8 * 24 * time.Hour))
+
+			restarted := pkg.NewSimulatedClock()
+			restarted.Restore(offset)
+			Expect(restarted.Now()).To(BeTemporally("~", env.Clock.Now(), time.Second))
+		})
+
+		ginkgo.It("should create loans on the business date of the clock", func() {
+			_, err := env.SimulationUsecase.Advance(ctx, 10)
+			Expect(err).ToNot(HaveOccurred())
+
+			loan, err := env.LoanUsecase.CreateLoan(ctx, 2, "Jane Doe", "janedoe@example.com", "", 5000000, 50, 10)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loan.DueDate).To(BeTemporally("~", time.Now().AddDate(0, 0, 17), time.Minute))
+		})
+
+		ginkgo.It("should reject an invalid number of days", func() {
+			_, err := env.SimulationUsecase.Advance(ctx, 0)
+			Expect(err).To(MatchError(usecase.ErrInvalidSimulationDays))
+			Expect(env.Clock.Offset()).To(BeZero())
+		})
+
+		ginkgo.It("should carry on past the loans that fail", func() {
+			env.Events.FailOn(enum.EventTypeInstallmentOverdue)
+
+			_, err := env.SimulationUsecase.Advance(ctx, 8)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(env.Clock.Offset()).To(Equal(8 * 24 * time.Hour))
+
+			runs, err := env.JobRunUsecase.GetRuns(ctx, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runs[0].Status()).To(Equal("partially_failed"))
+		})
+
+		ginkgo.It("should leave the clock alone when a day fails", func() {
+			jobRunUsecase := usecase.NewJobRunUsecase(env.JobRunRepo, &failingPaymentUsecase{}, tenant.DefaultRegistry(), env.Clock, pkg.NewLocalJobLock())
+			notificationUsecase := usecase.NewNotificationUsecase(env.Store, env.PaymentRepo, env.NotificationRepo, notification.NewNotifier(env.NotificationChannel), helpers.TestReminderDaysBefore)
+			simulationUsecase := usecase.NewSimulationUsecase(env.Clock, env.SimulationClockRepo, tenant.DefaultRegistry(), jobRunUsecase, notificationUsecase)
+
+			_, err := simulationUsecase.Advance(ctx, 8)
+			Expect(err).To(MatchError(ContainSubstring("failed to update payment statuses of tenant default")))
+			Expect(env.Clock.Offset()).To(BeZero())
+			Expect(env.SimulationClockRepo.GetOffset(ctx)).To(BeZero())
+		})
+
+		ginkgo.It("should keep the days before the one that failed", func() {
+			paymentUsecase := &laterFailingPaymentUsecase{PaymentUsecase: env.PaymentUsecase, days: 8}
+			jobRunUsecase := usecase.NewJobRunUsecase(env.JobRunRepo, paymentUsecase, tenant.DefaultRegistry(), env.Clock, pkg.NewLocalJobLock())
+			simulationUsecase := usecase.NewSimulationUsecase(env.Clock, env.SimulationClockRepo, tenant.DefaultRegistry(), jobRunUsecase, env.NotificationUsecase)
+
+			_, err := simulationUsecase.Advance(ctx, 10)
+			Expect(err).To(MatchError(ContainSubstring("failed to update payment statuses of tenant default")))
+			Expect(env.Clock.Offset()).To(Equal(8 * 24 * time.Hour))
+			Expect(env.SimulationClockRepo.GetOffset(ctx)).To(Equal(8 * 24 * time.Hour))
+
+			// The first installment turned pending on the eighth day and stays so
+			outstanding, err := env.LoanUsecase.GetOutstanding(ctx, loanID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(outstanding.WeeksOutstanding).To(Equal(1))
+			Expect(env.NotificationUsecase.DeliverPending(ctx, time.Now())).To(Succeed())
+			Expect(env.NotificationChannel.Messages()).To(HaveLen(2))
+		})
+	})
+})
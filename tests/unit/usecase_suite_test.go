@@ -0,0 +1,17 @@
+package unit_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestUsecases(t *testing.T) {
+	// The use cases log every step, which would bury the report of the specs
+	log.SetLevel(log.WarnLevel)
+
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Usecase Unit Suite")
+}